@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxExpiry is how far in the future a signed request may expire.
+	MaxExpiry = time.Hour
+
+	maxNonce   = 64
+	pruneEvery = 10 * time.Minute
+	sigField   = "sig_msg"
+)
+
+var (
+	ErrExpired = errors.New("signed request expired")
+	ErrReplay  = errors.New("nonce already used")
+)
+
+// Signed are the fields every signed request body carries next to its payload.
+type Signed struct {
+	Address string `json:"address"`
+	Nonce   string `json:"nonce"`
+	Expiry  int64  `json:"expiry"`
+	SigMsg  string `json:"sig_msg"`
+}
+
+// Message is the canonical text a wallet signs for a request:
+//
+//	Unielon Signed Request
+//	action: <action>
+//	payload: <PayloadHash of the body>
+//	nonce: <nonce>
+//	expiry: <unix seconds>
+func Message(action, payloadHash, nonce string, expiry int64) string {
+	return fmt.Sprintf("Unielon Signed Request\naction: %s\npayload: %s\nnonce: %s\nexpiry: %d", action, payloadHash, nonce, expiry)
+}
+
+// PayloadHash is the hex sha256 of the request body without sig_msg, as JSON with sorted keys,
+// no whitespace and no HTML escaping.
+func PayloadHash(body []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	payload := make(map[string]interface{})
+	if err := decoder.Decode(&payload); err != nil {
+		return "", err
+	}
+	delete(payload, sigField)
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(payload); err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Verifier checks signed requests and keeps their nonces so that none is accepted twice.
+type Verifier struct {
+	dbc *storage.DBClient
+
+	lock     *sync.Mutex
+	prunedAt time.Time
+}
+
+func NewVerifier(dbc *storage.DBClient) *Verifier {
+	return &Verifier{
+		dbc:  dbc,
+		lock: &sync.Mutex{},
+	}
+}
+
+// Verify checks that s.Address signed the Message of action and body, and uses up the nonce.
+func (v *Verifier) Verify(action string, body []byte, s *Signed) error {
+	now := time.Now()
+	if s.Expiry <= now.Unix() {
+		return ErrExpired
+	}
+
+	if s.Expiry > now.Add(MaxExpiry).Unix() {
+		return fmt.Errorf("expiry must be within %s", MaxExpiry)
+	}
+
+	if s.Nonce == "" || len(s.Nonce) > maxNonce {
+		return fmt.Errorf("nonce must be 1 to %d characters", maxNonce)
+	}
+
+	if s.Address == "" {
+		return errors.New("address is required")
+	}
+
+	payloadHash, err := PayloadHash(body)
+	if err != nil {
+		return err
+	}
+
+	if err := VerifyMessage(s.Address, Message(action, payloadHash, s.Nonce, s.Expiry), s.SigMsg); err != nil {
+		return err
+	}
+
+	v.prune(now)
+
+	ok, err := v.dbc.UseNonce(&models.AuthNonce{
+		Address: s.Address,
+		Nonce:   s.Nonce,
+		Action:  action,
+		Expiry:  s.Expiry,
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrReplay
+	}
+	return nil
+}
+
+func (v *Verifier) prune(now time.Time) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if now.Sub(v.prunedAt) < pruneEvery {
+		return
+	}
+	v.prunedAt = now
+
+	if err := v.dbc.PruneNonces(now.Unix()); err != nil {
+		log.Error("auth", "PruneNonces", err)
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/doged/btcec/ecdsa"
+	"github.com/dogecoinw/doged/btcutil"
+	"github.com/dogecoinw/doged/chaincfg"
+	"github.com/dogecoinw/doged/txscript"
+	"github.com/unielon-org/unielon-indexer/utils"
+)
+
+const (
+	DogecoinPrefix = "\x19Dogecoin Signed Message:\n"
+	BitcoinPrefix  = "\x18Bitcoin Signed Message:\n"
+)
+
+// Prefixes are tried in order, most Dogecoin wallets sign with the first one.
+var Prefixes = []string{DogecoinPrefix, BitcoinPrefix}
+
+var ErrBadSignature = errors.New("bad signature")
+
+// messageHash is the double sha256 of the prefixed message, as signmessage computes it.
+func messageHash(prefix, message string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(prefix)
+	utils.WriteVarInt(&buf, int64(len(message)))
+	buf.WriteString(message)
+
+	hash := sha256.Sum256(buf.Bytes())
+	hash = sha256.Sum256(hash[:])
+	return hash[:]
+}
+
+// RecoverAddress returns the address that signed message with prefix. The header of the base64
+// compact signature tells the key type like BIP137: 27-30 uncompressed P2PKH, 31-34 compressed
+// P2PKH and 35-38 a compressed key wrapped in P2SH.
+func RecoverAddress(prefix, message, sig string) (string, error) {
+	sigBytes, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil || len(sigBytes) != 65 {
+		return "", ErrBadSignature
+	}
+
+	header := sigBytes[0]
+	wrapped := false
+	switch {
+	case header >= 27 && header <= 34:
+	case header >= 35 && header <= 38:
+		wrapped = true
+		sigBytes = append([]byte{header - 4}, sigBytes[1:]...)
+	default:
+		return "", ErrBadSignature
+	}
+
+	publicKey, compressed, err := ecdsa.RecoverCompact(sigBytes, messageHash(prefix, message))
+	if err != nil {
+		return "", ErrBadSignature
+	}
+
+	if !compressed {
+		address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(publicKey.SerializeUncompressed()), &chaincfg.MainNetParams)
+		if err != nil {
+			return "", err
+		}
+		return address.String(), nil
+	}
+
+	pubKeyHash := btcutil.Hash160(publicKey.SerializeCompressed())
+	if !wrapped {
+		address, err := btcutil.NewAddressPubKeyHash(pubKeyHash, &chaincfg.MainNetParams)
+		if err != nil {
+			return "", err
+		}
+		return address.String(), nil
+	}
+
+	redeemScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(pubKeyHash).Script()
+	if err != nil {
+		return "", err
+	}
+
+	address, err := btcutil.NewAddressScriptHash(redeemScript, &chaincfg.MainNetParams)
+	if err != nil {
+		return "", err
+	}
+	return address.String(), nil
+}
+
+// VerifyMessage checks that address signed message with any of the Prefixes, a prefix the signature
+// does not recover a key for is skipped.
+func VerifyMessage(address, message, sig string) error {
+	for _, prefix := range Prefixes {
+		recovered, err := RecoverAddress(prefix, message, sig)
+		if err != nil {
+			continue
+		}
+		if recovered == address {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: not signed by %s", ErrBadSignature, address)
+}
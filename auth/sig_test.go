@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"encoding/base64"
+	"github.com/dogecoinw/doged/btcec"
+	"github.com/dogecoinw/doged/btcec/ecdsa"
+	"github.com/dogecoinw/doged/btcutil"
+	"github.com/dogecoinw/doged/chaincfg"
+	"github.com/dogecoinw/doged/txscript"
+	"testing"
+)
+
+func sign(t *testing.T, key *btcec.PrivateKey, prefix, message string, compressed, wrapped bool) string {
+	sig, err := ecdsa.SignCompact(key, messageHash(prefix, message), compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrapped {
+		sig[0] += 4
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func TestVerifyMessage(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pubKeyHash := btcutil.Hash160(key.PubKey().SerializeCompressed())
+	p2pkh, _ := btcutil.NewAddressPubKeyHash(pubKeyHash, &chaincfg.MainNetParams)
+	uncompressed, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(key.PubKey().SerializeUncompressed()), &chaincfg.MainNetParams)
+	redeemScript, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(pubKeyHash).Script()
+	p2sh, _ := btcutil.NewAddressScriptHash(redeemScript, &chaincfg.MainNetParams)
+
+	message := Message("drc20-meta", "00", "n1", 1700000000)
+	tests := []struct {
+		name       string
+		prefix     string
+		address    string
+		compressed bool
+		wrapped    bool
+	}{
+		{"dogecoin p2pkh", DogecoinPrefix, p2pkh.String(), true, false},
+		{"bitcoin p2pkh", BitcoinPrefix, p2pkh.String(), true, false},
+		{"uncompressed", DogecoinPrefix, uncompressed.String(), false, false},
+		{"p2sh wrapped", DogecoinPrefix, p2sh.String(), true, true},
+	}
+
+	for _, tt := range tests {
+		sig := sign(t, key, tt.prefix, message, tt.compressed, tt.wrapped)
+		if err := VerifyMessage(tt.address, message, sig); err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+		}
+		if err := VerifyMessage(tt.address, message+" ", sig); err == nil {
+			t.Errorf("%s: changed message verified", tt.name)
+		}
+	}
+
+	sig := sign(t, key, DogecoinPrefix, message, true, false)
+	if err := VerifyMessage(p2sh.String(), message, sig); err == nil {
+		t.Error("p2pkh signature verified for the p2sh address")
+	}
+}
+
+func TestPayloadHash(t *testing.T) {
+	a, err := PayloadHash([]byte(`{"tick":"UNIX","nonce":"n1","sig_msg":"abc","expiry":1700000000,"logo":"<a&b>"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := PayloadHash([]byte(`{"logo":"<a&b>", "expiry":1700000000, "nonce":"n1", "tick":"UNIX"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a != b {
+		t.Errorf("hash depends on key order or sig_msg: %s != %s", a, b)
+	}
+
+	c, _ := PayloadHash([]byte(`{"logo":"<a&b>","expiry":1700000000,"nonce":"n1","tick":"UNIX2"}`))
+	if a == c {
+		t.Error("hash ignores the payload")
+	}
+}
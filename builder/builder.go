@@ -0,0 +1,513 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/doged/btcutil"
+	"github.com/dogecoinw/doged/chaincfg"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/doged/txscript"
+	"github.com/dogecoinw/doged/wire"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"math/big"
+	"strings"
+)
+
+const (
+	BaseAmount     = 100000
+	MaxRepeat      = 30
+	DefaultFeeRate = 1000000 // koinu per kB
+
+	wdogeFeeMin = 50000000
+
+	maxScriptElementSize = 520
+	fileChunkSize        = 470
+
+	// Serialized size estimates used for the fee calculation.
+	txOverhead       = 10
+	p2pkhInputSize   = 148
+	outputSize       = 34
+	revealInputExtra = 41 + 3 + 73 + 3
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
+// Utxo is an output of the holder address that funds the commit transaction.
+type Utxo struct {
+	TxHash string `json:"tx_hash"`
+	Index  uint32 `json:"index"`
+	Amount int64  `json:"amount"`
+}
+
+// Request describes the inscriptions to build.
+// Payloads holds one inscription json per reveal input, pair-v1 accepts several of them.
+type Request struct {
+	PubKey        string            `json:"pub_key"`
+	HolderAddress string            `json:"holder_address"`
+	ToAddress     string            `json:"to_address"`
+	Utxos         []*Utxo           `json:"utxos"`
+	FeeRate       int64             `json:"fee_rate"`
+	Repeat        int64             `json:"repeat"`
+	Payloads      []json.RawMessage `json:"payloads"`
+	FileData      string            `json:"file_data"`
+	CommitTxHash  string            `json:"commit_tx_hash"`
+}
+
+// Result carries the unsigned transactions.
+// The reveal transaction spends the commit outputs by hash, so when the commit transaction is
+// signed its hash changes and the reveal has to be rebuilt with CommitTxHash set.
+type Result struct {
+	CommitTx      string   `json:"commit_tx"`
+	CommitTxHash  string   `json:"commit_tx_hash"`
+	CommitFee     int64    `json:"commit_fee"`
+	RevealTx      string   `json:"reveal_tx"`
+	RevealFee     int64    `json:"reveal_fee"`
+	RedeemScripts []string `json:"redeem_scripts"`
+	P2SHAddresses []string `json:"p2sh_addresses"`
+}
+
+// InscriptionScript builds the redeem script the explorer decodes:
+// OP_1 <pubkey> OP_1 OP_CHECKMULTISIGVERIFY "ord" "text/plain;charset=utf-8" <json> OP_DROP OP_DROP OP_DROP
+func InscriptionScript(pubKey []byte, data []byte) ([]byte, error) {
+	builder := txscript.NewScriptBuilder()
+	builder.AddOp(txscript.OP_1).AddData(pubKey).AddOp(txscript.OP_1)
+	builder.AddOp(txscript.OP_CHECKMULTISIGVERIFY)
+	builder.AddData([]byte("ord")).AddData([]byte("text/plain;charset=utf-8")).AddData(data)
+	builder.AddOp(txscript.OP_DROP).AddOp(txscript.OP_DROP).AddOp(txscript.OP_DROP)
+
+	script, err := builder.Script()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(script) > maxScriptElementSize {
+		return nil, fmt.Errorf("redeem script is %d bytes, the limit is %d", len(script), maxScriptElementSize)
+	}
+
+	return script, nil
+}
+
+// dataScript builds the redeem script of the extra inputs that carry file data.
+func dataScript(pubKey []byte, data []byte) ([]byte, error) {
+	builder := txscript.NewScriptBuilder()
+	builder.AddOp(txscript.OP_1).AddData(pubKey).AddOp(txscript.OP_1)
+	builder.AddOp(txscript.OP_CHECKMULTISIGVERIFY)
+	builder.AddData(data)
+	builder.AddOp(txscript.OP_DROP)
+
+	script, err := builder.Script()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(script) > maxScriptElementSize {
+		return nil, fmt.Errorf("file data redeem script is %d bytes, the limit is %d, use a compressed pub_key", len(script), maxScriptElementSize)
+	}
+
+	return script, nil
+}
+
+// SignatureScript is the script the signer places on a reveal input once it has the signature.
+func SignatureScript(sig []byte, redeemScript []byte) ([]byte, error) {
+	builder := txscript.NewScriptBuilder()
+	builder.AddOp(txscript.OP_10).AddOp(txscript.OP_FALSE).AddData(sig)
+	builder.AddData(redeemScript)
+	return builder.Script()
+}
+
+// Build creates the unsigned commit and reveal transactions for the request.
+func Build(req *Request) (*Result, error) {
+
+	if len(req.Payloads) == 0 {
+		return nil, errors.New("payloads is empty")
+	}
+
+	pubKey, err := hex.DecodeString(req.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("pub_key err: %s", err.Error())
+	}
+
+	if len(pubKey) != 33 && len(pubKey) != 65 {
+		return nil, errors.New("pub_key must be a serialized public key")
+	}
+
+	holderScript, err := addressScript(req.HolderAddress)
+	if err != nil {
+		return nil, fmt.Errorf("holder_address err: %s", err.Error())
+	}
+
+	feeRate := req.FeeRate
+	if feeRate <= 0 {
+		feeRate = DefaultFeeRate
+	}
+
+	base := &models.BaseInscription{}
+	if err := json.Unmarshal(req.Payloads[0], base); err != nil {
+		return nil, fmt.Errorf("payload err: %s", err.Error())
+	}
+
+	redeemScripts, err := redeemScripts(req, base, pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	outs, err := revealOutputs(req, base)
+	if err != nil {
+		return nil, err
+	}
+
+	// reveal
+	revealTx := wire.NewMsgTx(wire.TxVersion)
+	revealSize := txOverhead
+	revealOut := int64(0)
+	for _, out := range outs {
+		revealTx.AddTxOut(out)
+		revealOut += out.Value
+		revealSize += outputSize
+	}
+
+	for _, script := range redeemScripts {
+		revealSize += revealInputExtra + len(script)
+	}
+	revealFee := fee(revealSize, feeRate)
+
+	// commit
+	commitTx := wire.NewMsgTx(wire.TxVersion)
+	p2shAddresses := make([]string, 0, len(redeemScripts))
+	commitOut := int64(0)
+	for i, script := range redeemScripts {
+		addr, err := btcutil.NewAddressScriptHash(script, &chaincfg.MainNetParams)
+		if err != nil {
+			return nil, err
+		}
+
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		value := int64(BaseAmount)
+		if i == 0 {
+			value = revealOut + revealFee - int64(len(redeemScripts)-1)*BaseAmount
+			if value < BaseAmount {
+				value = BaseAmount
+			}
+		}
+
+		commitTx.AddTxOut(wire.NewTxOut(value, pkScript))
+		p2shAddresses = append(p2shAddresses, addr.EncodeAddress())
+		commitOut += value
+	}
+
+	commitIn := int64(0)
+	for _, utxo := range req.Utxos {
+		hash, err := chainhash.NewHashFromStr(utxo.TxHash)
+		if err != nil {
+			return nil, fmt.Errorf("utxo err: %s", err.Error())
+		}
+
+		commitTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, utxo.Index), nil, nil))
+		commitIn += utxo.Amount
+	}
+
+	if len(commitTx.TxIn) == 0 {
+		return nil, errors.New("utxos is empty")
+	}
+
+	commitSize := txOverhead + len(commitTx.TxIn)*p2pkhInputSize + (len(commitTx.TxOut)+1)*outputSize
+	commitFee := fee(commitSize, feeRate)
+	change := commitIn - commitOut - commitFee
+	if change < 0 {
+		return nil, fmt.Errorf("%w: need %d, have %d", ErrInsufficientFunds, commitOut+commitFee, commitIn)
+	}
+
+	if change >= BaseAmount {
+		commitTx.AddTxOut(wire.NewTxOut(change, holderScript))
+	} else {
+		commitFee += change
+	}
+
+	commitHash := commitTx.TxHash()
+	if req.CommitTxHash != "" {
+		hash, err := chainhash.NewHashFromStr(req.CommitTxHash)
+		if err != nil {
+			return nil, fmt.Errorf("commit_tx_hash err: %s", err.Error())
+		}
+		commitHash = *hash
+	}
+
+	for i := range redeemScripts {
+		revealTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&commitHash, uint32(i)), nil, nil))
+	}
+
+	result := &Result{
+		CommitTxHash:  commitHash.String(),
+		CommitFee:     commitFee,
+		RevealFee:     commitOut - revealOut,
+		P2SHAddresses: p2shAddresses,
+	}
+
+	result.CommitTx, err = serialize(commitTx)
+	if err != nil {
+		return nil, err
+	}
+
+	result.RevealTx, err = serialize(revealTx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, script := range redeemScripts {
+		result.RedeemScripts = append(result.RedeemScripts, hex.EncodeToString(script))
+	}
+
+	return result, nil
+}
+
+func redeemScripts(req *Request, base *models.BaseInscription, pubKey []byte) ([][]byte, error) {
+
+	scripts := make([][]byte, 0, len(req.Payloads))
+
+	if base.P != "pair-v1" && len(req.Payloads) > 1 {
+		return nil, fmt.Errorf("%s accepts a single payload", base.P)
+	}
+
+	for i, payload := range req.Payloads {
+		if i > 0 {
+			other := &models.BaseInscription{}
+			if err := json.Unmarshal(payload, other); err != nil {
+				return nil, fmt.Errorf("payload %d err: %s", i, err.Error())
+			}
+
+			if other.P != base.P {
+				return nil, fmt.Errorf("payload %d is not %s", i, base.P)
+			}
+		}
+
+		script, err := InscriptionScript(pubKey, payload)
+		if err != nil {
+			return nil, fmt.Errorf("payload %d err: %s", i, err.Error())
+		}
+		scripts = append(scripts, script)
+	}
+
+	if base.P != "file" {
+		return scripts, nil
+	}
+
+	fileData, err := hex.DecodeString(req.FileData)
+	if err != nil {
+		return nil, fmt.Errorf("file_data err: %s", err.Error())
+	}
+
+	for start := 0; start < len(fileData); start += fileChunkSize {
+		end := start + fileChunkSize
+		if end > len(fileData) {
+			end = len(fileData)
+		}
+
+		script, err := dataScript(pubKey, fileData[start:end])
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, script)
+	}
+
+	return scripts, nil
+}
+
+// revealOutputs lays out the outputs the explorer expects for each protocol.
+func revealOutputs(req *Request, base *models.BaseInscription) ([]*wire.TxOut, error) {
+
+	receiver := req.HolderAddress
+	if req.ToAddress != "" {
+		receiver = req.ToAddress
+	}
+
+	holderOut, err := txOut(req.HolderAddress, BaseAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	switch base.P {
+	case "drc-20":
+		switch base.Op {
+		case "mint":
+			repeat := req.Repeat
+			if repeat <= 0 {
+				repeat = 1
+			}
+
+			if repeat > MaxRepeat {
+				return nil, fmt.Errorf("repeat can not exceed %d", MaxRepeat)
+			}
+
+			out, err := txOut(receiver, BaseAmount*repeat)
+			if err != nil {
+				return nil, err
+			}
+			return []*wire.TxOut{out}, nil
+
+		case "transfer":
+			return transferOutputs(req)
+		}
+
+		return []*wire.TxOut{holderOut}, nil
+
+	case "file":
+		if base.Op == "transfer" {
+			return transferOutputs(req)
+		}
+		return []*wire.TxOut{holderOut}, nil
+
+	case "wdoge":
+		if base.Op != "deposit" {
+			return []*wire.TxOut{holderOut}, nil
+		}
+
+		inscription := &models.WDogeInscription{}
+		if err := json.Unmarshal(req.Payloads[0], inscription); err != nil {
+			return nil, fmt.Errorf("payload err: %s", err.Error())
+		}
+
+		amt, err := utils.ConvetStr(inscription.Amt)
+		if err != nil {
+			return nil, fmt.Errorf("amt err: %s", err.Error())
+		}
+
+		return depositOutputs(holderOut, amt)
+
+	case "pair-v1":
+		dogeDepositAmt := big.NewInt(0)
+		for _, payload := range req.Payloads {
+			inscription := &models.SwapInscription{}
+			if err := json.Unmarshal(payload, inscription); err != nil {
+				return nil, fmt.Errorf("payload err: %s", err.Error())
+			}
+
+			if inscription.Doge != 1 {
+				continue
+			}
+
+			tick0 := strings.ToUpper(inscription.Tick0)
+			tick1 := strings.ToUpper(inscription.Tick1)
+			if (inscription.Op == "create" || inscription.Op == "add" || inscription.Op == "swap") && tick0 == "WDOGE(WRAPPED-DOGE)" {
+				amt, err := utils.ConvetStr(inscription.Amt0)
+				if err != nil {
+					return nil, fmt.Errorf("amt0 err: %s", err.Error())
+				}
+				dogeDepositAmt.Add(dogeDepositAmt, amt)
+			}
+
+			if (inscription.Op == "create" || inscription.Op == "add") && tick1 == "WDOGE(WRAPPED-DOGE)" {
+				amt, err := utils.ConvetStr(inscription.Amt1)
+				if err != nil {
+					return nil, fmt.Errorf("amt1 err: %s", err.Error())
+				}
+				dogeDepositAmt.Add(dogeDepositAmt, amt)
+			}
+		}
+
+		if dogeDepositAmt.Sign() > 0 {
+			return depositOutputs(holderOut, dogeDepositAmt)
+		}
+
+		return []*wire.TxOut{holderOut}, nil
+
+	case "stake-v1", "order-v1", "order-v2", "box-v1", "cross":
+		return []*wire.TxOut{holderOut}, nil
+	}
+
+	return nil, fmt.Errorf("protocol %s is not supported", base.P)
+}
+
+// transferOutputs pays every receiver, the explorer ignores the last output when there is
+// more than one, so a trailing output back to the holder is added in that case.
+func transferOutputs(req *Request) ([]*wire.TxOut, error) {
+	if req.ToAddress == "" {
+		return nil, errors.New("to_address is empty")
+	}
+
+	receivers := strings.Split(req.ToAddress, ",")
+	outs := make([]*wire.TxOut, 0, len(receivers)+1)
+	for _, receiver := range receivers {
+		if receiver == req.HolderAddress {
+			return nil, errors.New("the holder address can not be a receiver")
+		}
+
+		out, err := txOut(receiver, BaseAmount)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, out)
+	}
+
+	if len(receivers) > 1 {
+		out, err := txOut(req.HolderAddress, BaseAmount)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, out)
+	}
+
+	return outs, nil
+}
+
+func depositOutputs(holderOut *wire.TxOut, amt *big.Int) ([]*wire.TxOut, error) {
+	if !amt.IsInt64() || amt.Sign() <= 0 {
+		return nil, errors.New("deposit amount is out of range")
+	}
+
+	fee := big.NewInt(0)
+	fee.Mul(amt, big.NewInt(3))
+	fee.Div(fee, big.NewInt(1000))
+	if fee.Cmp(big.NewInt(wdogeFeeMin)) == -1 {
+		fee = big.NewInt(wdogeFeeMin)
+	}
+
+	coolOut, err := txOut(utils.WDogeCoolAddress, amt.Int64())
+	if err != nil {
+		return nil, err
+	}
+
+	feeOut, err := txOut(utils.WDogeFeeAddress, fee.Int64())
+	if err != nil {
+		return nil, err
+	}
+
+	return []*wire.TxOut{holderOut, coolOut, feeOut}, nil
+}
+
+func addressScript(address string) ([]byte, error) {
+	addr, err := btcutil.DecodeAddress(address, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+func txOut(address string, value int64) (*wire.TxOut, error) {
+	pkScript, err := addressScript(address)
+	if err != nil {
+		return nil, fmt.Errorf("address %s err: %s", address, err.Error())
+	}
+	return wire.NewTxOut(value, pkScript), nil
+}
+
+func fee(size int, feeRate int64) int64 {
+	return (int64(size)*feeRate + 999) / 1000
+}
+
+func serialize(tx *wire.MsgTx) (string, error) {
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
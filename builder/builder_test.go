@@ -0,0 +1,259 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/dogecoinw/doged/btcec"
+	"github.com/dogecoinw/doged/btcutil"
+	"github.com/dogecoinw/doged/chaincfg"
+	"github.com/dogecoinw/doged/wire"
+	"github.com/unielon-org/unielon-indexer/explorer"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"testing"
+)
+
+func TestBuildMint(t *testing.T) {
+	key, _ := btcec.NewPrivateKey()
+	pubKey := key.PubKey().SerializeCompressed()
+	holder, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.MainNetParams)
+
+	payload := json.RawMessage(`{"p":"drc-20","op":"mint","tick":"WOW","amt":"100000000"}`)
+	req := &Request{
+		PubKey:        hex.EncodeToString(pubKey),
+		HolderAddress: holder.EncodeAddress(),
+		Utxos:         []*Utxo{{TxHash: "52491cf5bafff1b1098d997a93429f818239e764084007e4fbef8b290dde051e", Index: 0, Amount: 100000000}},
+		Repeat:        3,
+		Payloads:      []json.RawMessage{payload},
+	}
+
+	result, err := Build(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revealBytes, _ := hex.DecodeString(result.RevealTx)
+	reveal := new(wire.MsgTx)
+	if err := reveal.Deserialize(bytes.NewReader(revealBytes)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reveal.TxOut) != 1 || reveal.TxOut[0].Value != 3*BaseAmount {
+		t.Fatalf("unexpected reveal outputs %v", reveal.TxOut)
+	}
+
+	if reveal.TxIn[0].PreviousOutPoint.Hash.String() != result.CommitTxHash {
+		t.Fatalf("reveal does not spend the commit transaction")
+	}
+
+	redeemScript, _ := hex.DecodeString(result.RedeemScripts[0])
+	sigScript, err := SignatureScript(make([]byte, 72), redeemScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decode, data, err := explorer.DecodeInscription(sigScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decode.P != "drc-20" || decode.Op != "mint" || !bytes.Equal(data, payload) {
+		t.Fatalf("unexpected inscription %s", data)
+	}
+}
+
+// testRequest funds a request of payloads from a fresh key, uncompressed selects the 65 byte pub key.
+func testRequest(t *testing.T, uncompressed bool, payloads ...string) *Request {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pubKey := key.PubKey().SerializeCompressed()
+	if uncompressed {
+		pubKey = key.PubKey().SerializeUncompressed()
+	}
+	holder, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.MainNetParams)
+
+	req := &Request{
+		PubKey:        hex.EncodeToString(pubKey),
+		HolderAddress: holder.EncodeAddress(),
+		Utxos:         []*Utxo{{TxHash: "52491cf5bafff1b1098d997a93429f818239e764084007e4fbef8b290dde051e", Index: 0, Amount: 1000000000000}},
+	}
+	for _, payload := range payloads {
+		req.Payloads = append(req.Payloads, json.RawMessage(payload))
+	}
+	return req
+}
+
+func decodeReveal(t *testing.T, result *Result) *wire.MsgTx {
+	revealBytes, _ := hex.DecodeString(result.RevealTx)
+	reveal := new(wire.MsgTx)
+	if err := reveal.Deserialize(bytes.NewReader(revealBytes)); err != nil {
+		t.Fatal(err)
+	}
+	return reveal
+}
+
+func TestBuildFile(t *testing.T) {
+	fileData := make([]byte, 2*fileChunkSize+10)
+	for i := range fileData {
+		fileData[i] = byte(i)
+	}
+
+	payload := `{"p":"file","op":"deploy","name":"test.png"}`
+	req := testRequest(t, false, payload)
+	req.FileData = hex.EncodeToString(fileData)
+
+	result, err := Build(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.RedeemScripts) != 4 || len(decodeReveal(t, result).TxIn) != 4 {
+		t.Fatalf("the json and three chunks need 4 inputs, got %d scripts", len(result.RedeemScripts))
+	}
+
+	sigScripts := make([][]byte, 0, len(result.RedeemScripts))
+	for _, s := range result.RedeemScripts {
+		redeemScript, _ := hex.DecodeString(s)
+		if len(redeemScript) > maxScriptElementSize {
+			t.Fatalf("redeem script of %d bytes exceeds the push limit", len(redeemScript))
+		}
+
+		sigScript, err := SignatureScript(make([]byte, 72), redeemScript)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigScripts = append(sigScripts, sigScript)
+	}
+
+	inscription, err := explorer.DecodeFileInscription(sigScripts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inscription.P != "file" || inscription.Op != "deploy" || !bytes.Equal(inscription.File, fileData) {
+		t.Fatalf("unexpected file inscription %s %s, %d bytes", inscription.P, inscription.Op, len(inscription.File))
+	}
+
+	req = testRequest(t, true, payload)
+	req.FileData = hex.EncodeToString(fileData)
+	if _, err := Build(req); err == nil {
+		t.Fatal("file chunks of an uncompressed pub key exceed the push limit")
+	}
+}
+
+func TestBuildProtocols(t *testing.T) {
+	receiver0 := testRequest(t, false).HolderAddress
+	receiver1 := testRequest(t, false).HolderAddress
+
+	tests := []struct {
+		name     string
+		payloads []string
+		to       string
+		// outs are the reveal outputs by address, an empty address is the holder
+		outs   []string
+		values []int64
+		err    bool
+	}{
+		{
+			name:     "drc-20 transfer",
+			payloads: []string{`{"p":"drc-20","op":"transfer","tick":"WOW","amt":"100"}`},
+			to:       receiver0,
+			outs:     []string{receiver0},
+			values:   []int64{BaseAmount},
+		},
+		{
+			name:     "drc-20 transfer to several receivers",
+			payloads: []string{`{"p":"drc-20","op":"transfer","tick":"WOW","amt":"100"}`},
+			to:       receiver0 + "," + receiver1,
+			outs:     []string{receiver0, receiver1, ""},
+			values:   []int64{BaseAmount, BaseAmount, BaseAmount},
+		},
+		{
+			name:     "drc-20 transfer without receiver",
+			payloads: []string{`{"p":"drc-20","op":"transfer","tick":"WOW","amt":"100"}`},
+			err:      true,
+		},
+		{
+			name:     "wdoge deposit",
+			payloads: []string{`{"p":"wdoge","op":"deposit","tick":"WDOGE(WRAPPED-DOGE)","amt":"100000000000"}`},
+			outs:     []string{"", utils.WDogeCoolAddress, utils.WDogeFeeAddress},
+			values:   []int64{BaseAmount, 100000000000, 300000000},
+		},
+		{
+			name:     "wdoge withdraw",
+			payloads: []string{`{"p":"wdoge","op":"withdraw","tick":"WDOGE(WRAPPED-DOGE)","amt":"100000000000"}`},
+			outs:     []string{""},
+			values:   []int64{BaseAmount},
+		},
+		{
+			name: "pair-v1 payloads with doge",
+			payloads: []string{
+				`{"p":"pair-v1","op":"swap","tick0":"WDOGE(WRAPPED-DOGE)","tick1":"WOW","amt0":"20000000000","amt1_min":"1","doge":1}`,
+				`{"p":"pair-v1","op":"add","tick0":"WOW","tick1":"WDOGE(WRAPPED-DOGE)","amt0":"1","amt1":"30000000000","doge":1}`,
+			},
+			outs:   []string{"", utils.WDogeCoolAddress, utils.WDogeFeeAddress},
+			values: []int64{BaseAmount, 50000000000, 150000000},
+		},
+		{
+			name:     "pair-v1 without doge",
+			payloads: []string{`{"p":"pair-v1","op":"swap","tick0":"WOW","tick1":"CARDI","amt0":"1","amt1_min":"1"}`},
+			outs:     []string{""},
+			values:   []int64{BaseAmount},
+		},
+		{
+			name:     "order-v1 create",
+			payloads: []string{`{"p":"order-v1","op":"create","tick0":"WOW","tick1":"CARDI","amt0":"1","amt1":"1"}`},
+			outs:     []string{""},
+			values:   []int64{BaseAmount},
+		},
+		{
+			name: "order-v1 takes a single payload",
+			payloads: []string{
+				`{"p":"order-v1","op":"create","tick0":"WOW","tick1":"CARDI","amt0":"1","amt1":"1"}`,
+				`{"p":"order-v1","op":"create","tick0":"WOW","tick1":"CARDI","amt0":"1","amt1":"1"}`,
+			},
+			err: true,
+		},
+		{
+			name:     "unknown protocol",
+			payloads: []string{`{"p":"brc-20","op":"mint"}`},
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		req := testRequest(t, false, tt.payloads...)
+		req.ToAddress = tt.to
+
+		result, err := Build(req)
+		if tt.err {
+			if err == nil {
+				t.Fatalf("%s: expected an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+
+		reveal := decodeReveal(t, result)
+		if len(reveal.TxIn) != len(tt.payloads) || len(reveal.TxOut) != len(tt.outs) {
+			t.Fatalf("%s: %d inputs and %d outputs", tt.name, len(reveal.TxIn), len(reveal.TxOut))
+		}
+
+		for i, out := range reveal.TxOut {
+			address := tt.outs[i]
+			if address == "" {
+				address = req.HolderAddress
+			}
+
+			script, _ := addressScript(address)
+			if !bytes.Equal(out.PkScript, script) || out.Value != tt.values[i] {
+				t.Fatalf("%s: output %d pays %d to %x, want %d to %s", tt.name, i, out.Value, out.PkScript, tt.values[i], address)
+			}
+		}
+	}
+}
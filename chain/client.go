@@ -0,0 +1,342 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/doged/rpcclient"
+	"github.com/dogecoinw/doged/wire"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTimeout = 30 * time.Second
+	DefaultRetries = 3
+
+	baseBackoff = 500 * time.Millisecond
+	maxBackoff  = 10 * time.Second
+
+	maxScore     = 100
+	successScore = 10
+	failureScore = 30
+)
+
+// transientCodes are the RPC errors of a node that cannot answer yet, another node or a later call may:
+// warming up, not connected to peers, in the initial download, and a transaction not found on a node that
+// lags behind or has no txindex.
+var transientCodes = map[btcjson.RPCErrorCode]bool{
+	btcjson.ErrRPCInWarmup:                true,
+	btcjson.ErrRPCClientNotConnected:      true,
+	btcjson.ErrRPCClientInInitialDownload: true,
+	btcjson.ErrRPCInvalidAddressOrKey:     true,
+}
+
+// missingRetries bounds the retries of a not found answer, it is also the answer about a transaction that
+// does not exist at all. Once spent the answer is returned as an error about the request.
+const missingRetries = 2
+
+var (
+	// ErrNetwork is wrapped by every error caused by the nodes being unreachable, as opposed to
+	// an error returned by a node about the request itself.
+	ErrNetwork = errors.New("chain network error")
+	ErrTimeout = errors.New("rpc call timed out")
+)
+
+// Client spreads calls to dogecoind over a primary node and its fallbacks. Every call has a
+// timeout, failed calls are retried with exponential backoff on the healthiest node until the context
+// of the client is done.
+type Client struct {
+	nodes   []*node
+	timeout time.Duration
+	retries int
+	lock    *sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type node struct {
+	host     string
+	client   *rpcclient.Client
+	score    int
+	calls    uint64
+	failures uint64
+	lastErr  error
+	lastFail time.Time
+}
+
+// NodeStatus is the health of one node as seen by the client.
+type NodeStatus struct {
+	Host     string `json:"host"`
+	Score    int    `json:"score"`
+	Calls    uint64 `json:"calls"`
+	Failures uint64 `json:"failures"`
+	LastErr  string `json:"last_err,omitempty"`
+	LastFail int64  `json:"last_fail,omitempty"`
+}
+
+func NewClient(cfg utils.ChainConfig) (*Client, error) {
+	c := &Client{
+		timeout: time.Duration(cfg.Timeout) * time.Second,
+		retries: cfg.Retries,
+		lock:    &sync.Mutex{},
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	if c.timeout <= 0 {
+		c.timeout = DefaultTimeout
+	}
+
+	if c.retries <= 0 {
+		c.retries = DefaultRetries
+	}
+
+	nodes := append([]utils.NodeConfig{{Rpc: cfg.Rpc, UserName: cfg.UserName, PassWord: cfg.PassWord}}, cfg.Fallbacks...)
+	for _, n := range nodes {
+		connCfg := &rpcclient.ConnConfig{
+			Host:         n.Rpc,
+			Endpoint:     "ws",
+			User:         n.UserName,
+			Pass:         n.PassWord,
+			HTTPPostMode: true, // Bitcoin core only supports HTTP POST mode
+			DisableTLS:   true, // Bitcoin core does not provide TLS by default
+		}
+
+		// Notice the notification parameter is nil since notifications are
+		// not supported in HTTP POST mode.
+		client, err := rpcclient.New(connCfg, nil)
+		if err != nil {
+			c.Shutdown()
+			return nil, fmt.Errorf("NewClient %s err: %s", n.Rpc, err.Error())
+		}
+
+		c.nodes = append(c.nodes, &node{host: n.Rpc, client: client, score: maxScore})
+	}
+
+	return c, nil
+}
+
+// SetContext stops the retries of the calls once ctx is done, it must be called before the client is used.
+func (c *Client) SetContext(ctx context.Context) {
+	c.cancel()
+	c.ctx, c.cancel = context.WithCancel(ctx)
+}
+
+func (c *Client) Shutdown() {
+	c.cancel()
+	for _, n := range c.nodes {
+		n.client.Shutdown()
+	}
+}
+
+// Nodes reports the health of every node, the primary first.
+func (c *Client) Nodes() []*NodeStatus {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	list := make([]*NodeStatus, 0, len(c.nodes))
+	for _, n := range c.nodes {
+		status := &NodeStatus{Host: n.host, Score: n.score, Calls: n.calls, Failures: n.failures}
+		if n.lastErr != nil {
+			status.LastErr = n.lastErr.Error()
+			status.LastFail = n.lastFail.Unix()
+		}
+		list = append(list, status)
+	}
+	return list
+}
+
+// IsNetworkErr reports whether err means the nodes could not be reached, the call may succeed later.
+func IsNetworkErr(err error) bool {
+	return errors.Is(err, ErrNetwork)
+}
+
+// best returns the node with the highest score, the earlier node in the list wins a tie.
+func (c *Client) best(skip map[*node]bool) *node {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var found *node
+	for _, n := range c.nodes {
+		if skip[n] && len(skip) < len(c.nodes) {
+			continue
+		}
+		if found == nil || n.score > found.score {
+			found = n
+		}
+	}
+	return found
+}
+
+func (c *Client) report(n *node, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	n.calls++
+	if err == nil {
+		n.score += successScore
+		if n.score > maxScore {
+			n.score = maxScore
+		}
+		return
+	}
+
+	n.failures++
+	n.lastErr = err
+	n.lastFail = time.Now()
+	n.score -= failureScore
+	if n.score < 0 {
+		n.score = 0
+	}
+}
+
+// call runs fn on the healthiest node until it succeeds, the node answers with an RPC error about
+// the request or all retries are spent. Only the latter returns an error wrapping ErrNetwork, as do
+// the transient RPC errors and a done context, except a not found answer that outlasts its retries.
+func (c *Client) call(method string, fn func(client *rpcclient.Client) (interface{}, error)) (interface{}, error) {
+	var lastErr error
+	missing := 0
+	tried := make(map[*node]bool)
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			backoff := baseBackoff << uint(attempt-1)
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-c.ctx.Done():
+				timer.Stop()
+				return nil, fmt.Errorf("%w: %s: %s, last err: %s", ErrNetwork, method, c.ctx.Err().Error(), lastErr.Error())
+			}
+		}
+
+		n := c.best(tried)
+		tried[n] = true
+
+		result, err := c.timed(n, fn)
+		if err == nil {
+			c.report(n, nil)
+			return result, nil
+		}
+
+		// the node is fine, it rejected the request
+		var rpcErr *btcjson.RPCError
+		if errors.As(err, &rpcErr) && !transientCodes[rpcErr.Code] {
+			c.report(n, nil)
+			return nil, err
+		}
+
+		if rpcErr != nil && rpcErr.Code == btcjson.ErrRPCInvalidAddressOrKey {
+			missing++
+			if missing > missingRetries || attempt == c.retries {
+				c.report(n, nil)
+				return nil, err
+			}
+		}
+
+		c.report(n, err)
+		lastErr = err
+		log.Warn("chain", "method", method, "host", n.host, "attempt", attempt+1, "err", err)
+	}
+
+	return nil, fmt.Errorf("%w: %s: %s", ErrNetwork, method, lastErr.Error())
+}
+
+// timed gives up waiting for fn after the timeout, the underlying request is left to finish on its own.
+func (c *Client) timed(n *node, fn func(client *rpcclient.Client) (interface{}, error)) (interface{}, error) {
+	type reply struct {
+		result interface{}
+		err    error
+	}
+
+	done := make(chan *reply, 1)
+	go func() {
+		result, err := fn(n.client)
+		done <- &reply{result: result, err: err}
+	}()
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.result, r.err
+	case <-timer.C:
+		return nil, ErrTimeout
+	}
+}
+
+func (c *Client) GetBlockCount() (int64, error) {
+	result, err := c.call("getblockcount", func(client *rpcclient.Client) (interface{}, error) {
+		return client.GetBlockCount()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+func (c *Client) GetBlockHash(height int64) (*chainhash.Hash, error) {
+	result, err := c.call("getblockhash", func(client *rpcclient.Client) (interface{}, error) {
+		return client.GetBlockHash(height)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*chainhash.Hash), nil
+}
+
+func (c *Client) GetBlockVerboseBool(blockHash *chainhash.Hash) (*btcjson.GetBlockVerboseResult, error) {
+	result, err := c.call("getblock", func(client *rpcclient.Client) (interface{}, error) {
+		return client.GetBlockVerboseBool(blockHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*btcjson.GetBlockVerboseResult), nil
+}
+
+func (c *Client) GetRawTransactionVerboseBool(txHash *chainhash.Hash) (*btcjson.TxRawResult, error) {
+	result, err := c.call("getrawtransaction", func(client *rpcclient.Client) (interface{}, error) {
+		return client.GetRawTransactionVerboseBool(txHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*btcjson.TxRawResult), nil
+}
+
+// SendRawTransaction is retried like every other call. A retry after a timeout may find the transaction
+// already sent, a transaction the node already has counts as sent.
+func (c *Client) SendRawTransaction(tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
+	result, err := c.call("sendrawtransaction", func(client *rpcclient.Client) (interface{}, error) {
+		hash, err := client.SendRawTransaction(tx, allowHighFees)
+		if err != nil && alreadySent(err) {
+			txHash := tx.TxHash()
+			return &txHash, nil
+		}
+		return hash, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*chainhash.Hash), nil
+}
+
+// alreadySent tells whether err is the answer to a transaction that is already in the mempool or a block.
+func alreadySent(err error) bool {
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	return rpcErr.Code == btcjson.ErrRPCVerifyAlreadyInChain || (rpcErr.Code == btcjson.ErrRPCVerifyRejected && strings.Contains(rpcErr.Message, "txn-already"))
+}
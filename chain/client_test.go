@@ -0,0 +1,198 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/dogecoinw/doged/wire"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newNode(t *testing.T, handler func(method string) (interface{}, *rpcError)) (*httptest.Server, *int64) {
+	calls := new(int64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(calls, 1)
+		req := &struct {
+			Id     interface{} `json:"id"`
+			Method string      `json:"method"`
+		}{}
+		json.NewDecoder(r.Body).Decode(req)
+
+		result, rpcErr := handler(req.Method)
+		if result == nil && rpcErr == nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": req.Id, "result": result, "error": rpcErr})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, calls
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func testClient(t *testing.T, nodes ...*httptest.Server) *Client {
+	cfg := utils.ChainConfig{UserName: "user", PassWord: "pass", Timeout: 5, Retries: 2}
+	for i, n := range nodes {
+		host := strings.TrimPrefix(n.URL, "http://")
+		if i == 0 {
+			cfg.Rpc = host
+			continue
+		}
+		cfg.Fallbacks = append(cfg.Fallbacks, utils.NodeConfig{Rpc: host, UserName: "user", PassWord: "pass"})
+	}
+
+	c, err := NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Shutdown)
+	return c
+}
+
+func TestFailover(t *testing.T) {
+	primary, _ := newNode(t, func(method string) (interface{}, *rpcError) {
+		return nil, nil
+	})
+	fallback, _ := newNode(t, func(method string) (interface{}, *rpcError) {
+		return 5000000, nil
+	})
+
+	c := testClient(t, primary, fallback)
+	height, err := c.GetBlockCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if height != 5000000 {
+		t.Fatalf("height %d", height)
+	}
+
+	nodes := c.Nodes()
+	if nodes[0].Score >= nodes[1].Score {
+		t.Fatalf("primary should score lower than the fallback: %d %d", nodes[0].Score, nodes[1].Score)
+	}
+}
+
+func TestRpcErrorIsNotRetried(t *testing.T) {
+	primary, calls := newNode(t, func(method string) (interface{}, *rpcError) {
+		return nil, &rpcError{Code: -8, Message: "Block height out of range"}
+	})
+
+	c := testClient(t, primary)
+	_, err := c.GetBlockCount()
+	if err == nil || IsNetworkErr(err) {
+		t.Fatalf("expected a protocol error, got %v", err)
+	}
+
+	if *calls != 1 {
+		t.Fatalf("expected one call, got %d", *calls)
+	}
+}
+
+func TestNetworkError(t *testing.T) {
+	primary, calls := newNode(t, func(method string) (interface{}, *rpcError) {
+		return nil, nil
+	})
+
+	c := testClient(t, primary)
+	_, err := c.GetBlockCount()
+	if !IsNetworkErr(err) || !errors.Is(err, ErrNetwork) {
+		t.Fatalf("expected a network error, got %v", err)
+	}
+
+	if *calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", *calls)
+	}
+}
+
+func TestTransientRpcError(t *testing.T) {
+	primary, _ := newNode(t, func(method string) (interface{}, *rpcError) {
+		return nil, &rpcError{Code: -28, Message: "Loading block index..."}
+	})
+	fallback, _ := newNode(t, func(method string) (interface{}, *rpcError) {
+		return 5000000, nil
+	})
+
+	c := testClient(t, primary, fallback)
+	height, err := c.GetBlockCount()
+	if err != nil || height != 5000000 {
+		t.Fatalf("a warming up node must fail over, got %d %v", height, err)
+	}
+
+	missing, _ := newNode(t, func(method string) (interface{}, *rpcError) {
+		return nil, &rpcError{Code: -5, Message: "No such mempool or blockchain transaction"}
+	})
+	lagging, _ := newNode(t, func(method string) (interface{}, *rpcError) {
+		return 5000000, nil
+	})
+
+	c = testClient(t, missing, lagging)
+	height, err = c.GetBlockCount()
+	if err != nil || height != 5000000 {
+		t.Fatalf("a transaction missing on one node must fail over, got %d %v", height, err)
+	}
+}
+
+func TestMissingRpcError(t *testing.T) {
+	missing, calls := newNode(t, func(method string) (interface{}, *rpcError) {
+		return nil, &rpcError{Code: -5, Message: "No such mempool or blockchain transaction"}
+	})
+
+	c := testClient(t, missing)
+	c.retries = 5
+	_, err := c.GetBlockCount()
+	if err == nil || IsNetworkErr(err) {
+		t.Fatalf("a transaction missing on every node must be an error about the request, got %v", err)
+	}
+
+	if *calls != missingRetries+1 {
+		t.Fatalf("expected %d calls, got %d", missingRetries+1, *calls)
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	primary, calls := newNode(t, func(method string) (interface{}, *rpcError) {
+		return nil, nil
+	})
+
+	c := testClient(t, primary)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.SetContext(ctx)
+
+	start := time.Now()
+	_, err := c.GetBlockCount()
+	if !IsNetworkErr(err) || time.Since(start) > baseBackoff {
+		t.Fatalf("expected a network error without backoff, got %v after %s", err, time.Since(start))
+	}
+
+	if *calls != 1 {
+		t.Fatalf("expected one call, got %d", *calls)
+	}
+}
+
+func TestSendRawTransactionAlreadySent(t *testing.T) {
+	primary, _ := newNode(t, func(method string) (interface{}, *rpcError) {
+		if method == "getinfo" {
+			return map[string]interface{}{"version": 1140600}, nil
+		}
+		return nil, &rpcError{Code: -26, Message: "258: txn-already-in-mempool"}
+	})
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	c := testClient(t, primary)
+	hash, err := c.SendRawTransaction(tx, false)
+	if err != nil || *hash != tx.TxHash() {
+		t.Fatalf("a transaction in the mempool counts as sent, got %v %v", hash, err)
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/unielon-org/unielon-indexer/chain"
+	"github.com/unielon-org/unielon-indexer/config"
+	"github.com/unielon-org/unielon-indexer/explorer"
+	"github.com/unielon-org/unielon-indexer/leader"
+	"github.com/unielon-org/unielon-indexer/logging"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/oracle"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/verifys"
+	"gorm.io/gorm"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// command is a maintenance subcommand. Commands that write must not run while another
+// process is indexing into the same database, with leader election on they take the lease.
+type command struct {
+	check func(args *commandArgs) error
+	run   func(exp *explorer.Explorer, args *commandArgs) error
+	write func(args *commandArgs) bool
+}
+
+type commandArgs struct {
+	positional []string
+	to         int64
+	from       int64
+	height     int64
+	txs        string
+	class      string
+	p          string
+	apply      bool
+}
+
+var commands = map[string]*command{
+	"rollback":     {check: checkRollback, run: cmdRollback, write: always},
+	"reindex":      {check: checkReindex, run: cmdReindex, write: always},
+	"verify-block": {check: checkVerifyBlock, run: cmdVerifyBlock, write: never},
+	"reprocess":    {check: checkReprocess, run: cmdReprocess, write: func(args *commandArgs) bool { return args.apply }},
+}
+
+const commandUsage = `usage:
+  unielon-indexer [flags] [config.json]                 run the indexer
+  unielon-indexer rollback --to H [flags]               revert every block above H
+  unielon-indexer reindex --from H [flags]              revert to H-1 and scan again up to the tip
+  unielon-indexer verify-block H [flags]                decode block H again and diff with the stored rows
+  unielon-indexer reprocess [--tx h1,h2] [--class c] [--p p] [--from H] [--to H] [--apply] [flags]
+                                                        decode and verify rejected inscriptions again,
+                                                        print the result and index them with --apply
+                                                        at the last indexed height
+
+flags:
+  --config file          JSON or YAML config file (default config.json)
+  --print-config         print the effective config with secrets redacted and exit
+  --<key> value          override any config key, e.g. --mysql.pass_word or --http_server.server
+
+environment:
+  UNIELON_<KEY>          override any config key, e.g. UNIELON_MYSQL_PASSWORD or UNIELON_HTTP_SERVER_SERVER`
+
+// runCommand runs the subcommand name and returns the process exit code.
+func runCommand(name string, args []string) int {
+	cmd := commands[name]
+	cargs := &commandArgs{}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	loader := config.NewLoader(fs)
+	fs.Int64Var(&cargs.to, "to", -1, "rollback: last block to keep, reprocess: last block")
+	fs.Int64Var(&cargs.from, "from", -1, "reindex: first block to scan again, reprocess: first block")
+	fs.StringVar(&cargs.txs, "tx", "", "reprocess: comma separated tx hashes")
+	fs.StringVar(&cargs.class, "class", "", "reprocess: error class, json, protocol or decode")
+	fs.StringVar(&cargs.p, "p", "", "reprocess: protocol")
+	fs.BoolVar(&cargs.apply, "apply", false, "reprocess: index the transactions that now decode")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, commandUsage)
+	}
+
+	// verify-block takes its height as the first positional argument
+	for len(args) > 0 {
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		args = fs.Args()
+		if len(args) > 0 {
+			cargs.positional = append(cargs.positional, args[0])
+			args = args[1:]
+		}
+	}
+
+	if err := cmd.check(cargs); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n%s\n", err.Error(), commandUsage)
+		return 2
+	}
+
+	if code, ok := loadConfig(loader, ""); !ok {
+		return code
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	go func() {
+		select {
+		case <-sig:
+			log.Warn("command", "signal", "stopping after the current block")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	dbClient, err := newDBClient()
+	if err != nil {
+		log.Error("command", "database", err)
+		return 1
+	}
+	defer dbClient.Stop()
+
+	// with leader election on, take the lease so the explorer of a running replica cannot interfere
+	var fence func(tx *gorm.DB) error
+	if cfg.Explorer.Leader.Switch && cmd.write(cargs) {
+		leaderCfg := cfg.Explorer.Leader
+		id := leader.DefaultId() + "-" + name
+		fence = func(tx *gorm.DB) error {
+			ok, err := dbClient.HoldsLease(tx, leaderCfg.Name, id)
+			if err == nil && !ok {
+				err = leader.ErrNotLeader
+			}
+			return err
+		}
+		ok, err := dbClient.AcquireLease(leaderCfg.Name, id, time.Duration(leaderCfg.Ttl)*time.Second)
+		if err != nil {
+			log.Error("command", "lease", err)
+			return 1
+		}
+		if !ok {
+			log.Error("command", "lease", "held by a running replica, stop it or wait for the lease to expire")
+			return 1
+		}
+		defer dbClient.ReleaseLease(leaderCfg.Name, id)
+
+		go func() {
+			ticker := time.NewTicker(time.Duration(leaderCfg.Ttl) * time.Second / 3)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if ok, err := dbClient.AcquireLease(leaderCfg.Name, id, time.Duration(leaderCfg.Ttl)*time.Second); err != nil || !ok {
+						log.Error("command", "lease lost", err)
+						cancel()
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	rpcClient, err := newRpcClient()
+	if err != nil {
+		log.Error("command", "rpc", err)
+		return 1
+	}
+	rpcClient.SetContext(ctx)
+	defer rpcClient.Shutdown()
+
+	prices, err := oracle.New(cfg.PriceOracle)
+	if err != nil {
+		log.Error("command", "price oracle", err)
+		return 1
+	}
+
+	exp := explorer.NewExplorer(ctx, &sync.WaitGroup{}, rpcClient, dbClient, shell.NewShell(cfg.Ipfs), verifys.NewVerifys(dbClient, cfg.Activation, cfg.Swap), 0)
+	exp.SetPriceOracle(prices)
+	if fence != nil {
+		exp.SetFence(fence)
+	}
+	if err := cmd.run(exp, cargs); err != nil {
+		log.Error("command", name, err)
+		return 1
+	}
+	return 0
+}
+
+// loadConfig fills cfg and sets up logging, it returns false and the exit code when the process must stop.
+func loadConfig(loader *config.Loader, path string) (int, bool) {
+	loaded, err := loader.Load(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "config error:", err.Error())
+		return 2, false
+	}
+
+	if loader.PrintConfig() {
+		for _, warning := range loader.Warnings() {
+			fmt.Fprintln(os.Stderr, "warning:", warning)
+		}
+		if err := config.Print(os.Stdout, loaded); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1, false
+		}
+		return 0, false
+	}
+
+	cfg = *loaded
+
+	if err := logging.Setup(cfg.Log, cfg.DebugLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2, false
+	}
+
+	for _, warning := range loader.Warnings() {
+		log.Warn("config", "warning", warning)
+	}
+	return 0, true
+}
+
+func always(args *commandArgs) bool {
+	return true
+}
+
+func never(args *commandArgs) bool {
+	return false
+}
+
+func parseHeight(raw string) (int64, error) {
+	height, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || height < 0 {
+		return 0, fmt.Errorf("invalid block height %q", raw)
+	}
+	return height, nil
+}
+
+func checkRollback(args *commandArgs) error {
+	if args.to < 0 {
+		return fmt.Errorf("rollback needs --to")
+	}
+	return nil
+}
+
+func cmdRollback(exp *explorer.Explorer, args *commandArgs) error {
+	return exp.Rollback(args.to)
+}
+
+func checkReindex(args *commandArgs) error {
+	if args.from < 1 {
+		return fmt.Errorf("reindex needs --from")
+	}
+	return nil
+}
+
+func cmdReindex(exp *explorer.Explorer, args *commandArgs) error {
+	return exp.Reindex(args.from)
+}
+
+func checkVerifyBlock(args *commandArgs) error {
+	if len(args.positional) != 1 {
+		return fmt.Errorf("verify-block needs one block height")
+	}
+
+	height, err := parseHeight(args.positional[0])
+	if err != nil {
+		return err
+	}
+	args.height = height
+	return nil
+}
+
+func cmdVerifyBlock(exp *explorer.Explorer, args *commandArgs) error {
+	diffs, err := exp.VerifyBlock(args.height)
+	if err != nil {
+		return err
+	}
+
+	for _, diff := range diffs {
+		fmt.Println(diff.String())
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("block %d: %d differences", args.height, len(diffs))
+	}
+
+	fmt.Printf("block %d: ok\n", args.height)
+	return nil
+}
+
+func checkReprocess(args *commandArgs) error {
+	if args.txs == "" && args.class == "" && args.p == "" && args.from < 0 && args.to < 0 {
+		return fmt.Errorf("reprocess needs at least one of --tx, --class, --p, --from or --to")
+	}
+	return nil
+}
+
+func cmdReprocess(exp *explorer.Explorer, args *commandArgs) error {
+	status := int64(models.RejectStatusRejected)
+	filter := &storage.RejectedFilter{
+		P:         args.p,
+		ErrClass:  args.class,
+		Status:    &status,
+		FromBlock: args.from,
+		ToBlock:   args.to,
+	}
+
+	if args.txs != "" {
+		filter.TxHashes = strings.Split(args.txs, ",")
+	}
+
+	results, err := exp.Reprocess(filter, args.apply)
+	for _, result := range results {
+		fmt.Println(result.String())
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d rejected inscriptions reprocessed, apply: %t\n", len(results), args.apply)
+	return nil
+}
+
+// newDBClient connects the configured database and creates the tables it misses.
+func newDBClient() (*storage.DBClient, error) {
+	var (
+		dbClient *storage.DBClient
+		err      error
+	)
+	if cfg.Sqlite.Switch {
+		dbClient, err = storage.NewSqliteClient(cfg.Sqlite)
+	} else {
+		dbClient, err = storage.NewMysqlClient(cfg.Mysql)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dbClient.Migrate(); err != nil {
+		dbClient.Stop()
+		return nil, err
+	}
+	return dbClient, nil
+}
+
+func newRpcClient() (*chain.Client, error) {
+	return chain.NewClient(cfg.Chain)
+}
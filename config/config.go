@@ -2,48 +2,443 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/logging"
+	"github.com/unielon-org/unielon-indexer/oracle"
 	"github.com/unielon-org/unielon-indexer/utils"
-	"log"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
+const (
+	DefaultConfigFile = "config.json"
+
+	envPrefix = "UNIELON_"
+	redacted  = "******"
+)
+
+// deprecatedKeys were accepted by older versions but never had any effect.
+var deprecatedKeys = map[string]bool{
+	"explorer.init_mint_data": true,
+	"explorer.init_fork_data": true,
+}
+
+// secretKeys are replaced by redacted when the config is printed.
+var secretKeys = map[string]bool{
+	"pass_word": true,
+	"key":       true,
+}
+
+var chainNames = map[string]bool{
+	"dogecoin": true,
+}
+
 type Config struct {
-	HttpServer utils.HttpConfig     `json:"http_server"`
-	LevelDB    utils.LevelDBConfig  `json:"leveldb"`
-	Sqlite     utils.SqliteConfig   `json:"sqlite"`
-	Mysql      utils.MysqlConfig    `json:"mysql"`
-	Chain      utils.ChainConfig    `json:"chain"`
-	Explorer   utils.ExplorerConfig `json:"explorer"`
-	Ipfs       string               `json:"ipfs"`
-	DebugLevel int                  `json:"debug_level"`
+	HttpServer      utils.HttpConfig        `json:"http_server"`
+	LevelDB         utils.LevelDBConfig     `json:"leveldb"`
+	Sqlite          utils.SqliteConfig      `json:"sqlite"`
+	Mysql           utils.MysqlConfig       `json:"mysql"`
+	Chain           utils.ChainConfig       `json:"chain"`
+	Explorer        utils.ExplorerConfig    `json:"explorer"`
+	Activation      utils.ActivationConfig  `json:"activation"`
+	Swap            utils.SwapConfig        `json:"swap"`
+	PriceOracle     utils.PriceOracleConfig `json:"price_oracle"`
+	Ipfs            string                  `json:"ipfs"`
+	Log             utils.LogConfig         `json:"log"`
+	DebugLevel      int                     `json:"debug_level"`
+	ShutdownTimeout int64                   `json:"shutdown_timeout"`
+}
+
+// Default returns the values used for every key that is set nowhere else.
+func Default() *Config {
+	return &Config{
+		HttpServer: utils.HttpConfig{
+			Server:      ":8089",
+			ReadyMaxLag: 10,
+			Cache: utils.CacheConfig{
+				Store: "memory",
+				Size:  10000,
+			},
+			Cors: utils.CorsConfig{
+				AllowOrigins: []string{"*"},
+				MaxAge:       3600,
+			},
+			Auth: utils.AuthConfig{
+				Header: "X-API-Key",
+			},
+			RateLimit: utils.RateLimitConfig{
+				Tiers: []utils.RateTierConfig{
+					{Name: "anonymous", Rate: 10, Burst: 20, ExpensiveRate: 1, ExpensiveBurst: 2},
+					{Name: "default", Rate: 50, Burst: 100, ExpensiveRate: 5, ExpensiveBurst: 10},
+				},
+				Expensive: []string{"/v4/drc20/collect", "/v4/swap/k", "/v4/exchange/orderbook", "/v4/exchange/best", "/v4/swap/position"},
+			},
+		},
+		LevelDB: utils.LevelDBConfig{
+			Path: "data/leveldb",
+		},
+		Sqlite: utils.SqliteConfig{
+			Database: "data/unielon.db",
+		},
+		Mysql: utils.MysqlConfig{
+			Server:   "127.0.0.1",
+			Port:     3306,
+			Database: "unielon",
+		},
+		Chain: utils.ChainConfig{
+			ChainName: "dogecoin",
+			Rpc:       "127.0.0.1:22555",
+			Timeout:   30,
+			Retries:   3,
+		},
+		Explorer: utils.ExplorerConfig{
+			Leader: utils.LeaderConfig{
+				Name: "explorer",
+				Ttl:  15,
+			},
+		},
+		Swap: utils.SwapConfig{
+			FeeTiers: []int{5, 30, 100},
+		},
+		PriceOracle: utils.PriceOracleConfig{
+			Timeout: 10,
+			Ttl:     60,
+		},
+		Log: utils.LogConfig{
+			Format: "terminal",
+		},
+		DebugLevel:      3,
+		ShutdownTimeout: 30,
+	}
+}
+
+// LoadFile merges the JSON or YAML file at path into cfg, keys missing from the file keep their value.
+// Unknown and deprecated keys are not an error, they are returned as warnings.
+func LoadFile(cfg *Config, path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".yaml" || ext == ".yml" {
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err.Error())
+		}
+
+		data, err = json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err.Error())
+		}
+	}
+
+	keys := make(map[string]interface{})
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+
+	// a list in the file replaces the default one, json would decode into the default elements
+	for _, f := range fields(cfg) {
+		if f.value.Kind() == reflect.Slice && hasKey(keys, f.path) {
+			f.value.Set(reflect.Zero(f.value.Type()))
+		}
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+
+	known := make(map[string]bool)
+	for _, f := range fields(cfg) {
+		known[f.path] = true
+		for p := f.path; strings.Contains(p, "."); {
+			p = p[:strings.LastIndex(p, ".")]
+			known[p] = true
+		}
+	}
+
+	warnings := make([]string, 0)
+	unknownKeys(keys, "", known, &warnings)
+	return warnings, nil
+}
+
+func hasKey(m map[string]interface{}, path string) bool {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		v, ok := m[part]
+		if !ok {
+			return false
+		}
+		if i == len(parts)-1 {
+			return true
+		}
+		if m, ok = v.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return false
+}
+
+func unknownKeys(m map[string]interface{}, prefix string, known map[string]bool, warnings *[]string) {
+	for key, v := range m {
+		path := prefix + key
+		if deprecatedKeys[path] {
+			*warnings = append(*warnings, fmt.Sprintf("config key %s is no longer used and is ignored", path))
+			continue
+		}
+
+		if !known[path] {
+			*warnings = append(*warnings, fmt.Sprintf("unknown config key %s", path))
+			continue
+		}
+
+		if sub, ok := v.(map[string]interface{}); ok {
+			unknownKeys(sub, path+".", known, warnings)
+		}
+	}
 }
 
-func LoadConfig(cfg *Config, filep string) {
+// LoadEnv overrides cfg with UNIELON_* environment variables, see EnvNames.
+func LoadEnv(cfg *Config) error {
+	for _, f := range fields(cfg) {
+		for _, name := range f.envNames() {
+			raw, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+
+			if err := f.set(raw); err != nil {
+				return fmt.Errorf("%s: %s", name, err.Error())
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// EnvNames lists the environment variables of every key: the key path in upper case with dots
+// replaced by underscores, e.g. UNIELON_MYSQL_PASS_WORD, or the same without the underscores
+// inside a key name, e.g. UNIELON_MYSQL_PASSWORD.
+func EnvNames() []string {
+	names := make([]string, 0)
+	for _, f := range fields(Default()) {
+		names = append(names, f.envNames()...)
+	}
+	return names
+}
+
+// Validate checks the values that would otherwise only fail once a component starts.
+func (cfg *Config) Validate() error {
+	errs := make([]string, 0)
+
+	if cfg.Sqlite.Switch && cfg.Mysql.Switch {
+		errs = append(errs, "sqlite.switch and mysql.switch are mutually exclusive")
+	}
+
+	if cfg.Sqlite.Switch {
+		if cfg.Sqlite.Database == "" {
+			errs = append(errs, "sqlite.database must be set")
+		}
+	} else {
+		if cfg.Mysql.Server == "" {
+			errs = append(errs, "mysql.server must be set")
+		}
+		if !validPort(cfg.Mysql.Port) {
+			errs = append(errs, fmt.Sprintf("mysql.port %d is not a valid port", cfg.Mysql.Port))
+		}
+		if cfg.Mysql.Database == "" {
+			errs = append(errs, "mysql.database must be set")
+		}
+	}
+
+	if !chainNames[cfg.Chain.ChainName] {
+		errs = append(errs, fmt.Sprintf("chain.chain_name %q is not supported", cfg.Chain.ChainName))
+	}
+
+	if err := validHostPort(cfg.Chain.Rpc); err != nil {
+		errs = append(errs, fmt.Sprintf("chain.rpc: %s", err.Error()))
+	}
+
+	for i, n := range cfg.Chain.Fallbacks {
+		if err := validHostPort(n.Rpc); err != nil {
+			errs = append(errs, fmt.Sprintf("chain.fallbacks[%d].rpc: %s", i, err.Error()))
+		}
+	}
+
+	if cfg.Chain.Timeout < 0 || cfg.Chain.Retries < 0 {
+		errs = append(errs, "chain.timeout and chain.retries must not be negative")
+	}
+
+	if cfg.HttpServer.Switch {
+		if err := validHostPort(cfg.HttpServer.Server); err != nil {
+			errs = append(errs, fmt.Sprintf("http_server.server: %s", err.Error()))
+		}
+		if cfg.LevelDB.Path == "" {
+			errs = append(errs, "leveldb.path must be set when http_server is on")
+		}
+		if cfg.HttpServer.ReadyMaxLag < 0 {
+			errs = append(errs, "http_server.ready_max_lag must not be negative")
+		}
+		if cfg.HttpServer.Cache.Store != "memory" && cfg.HttpServer.Cache.Store != "leveldb" {
+			errs = append(errs, fmt.Sprintf("http_server.cache.store %q must be memory or leveldb", cfg.HttpServer.Cache.Store))
+		}
+		errs = append(errs, validateRateLimit(&cfg.HttpServer)...)
+	}
+
+	if cfg.Explorer.FromBlock < 0 {
+		errs = append(errs, "explorer.from_block must not be negative")
+	}
+
+	if cfg.Explorer.Leader.Switch {
+		if cfg.Explorer.Leader.Name == "" {
+			errs = append(errs, "explorer.leader.name must be set")
+		}
+		if cfg.Explorer.Leader.Ttl < 3 {
+			errs = append(errs, "explorer.leader.ttl must be at least 3 seconds")
+		}
+	}
+
+	if cfg.Activation.Drc20Burn < 0 || cfg.Activation.Drc20Func < 0 || cfg.Activation.ExchangeFill < 0 || cfg.Activation.ExchangeExpire < 0 || cfg.Activation.SwapFee < 0 || cfg.Activation.CrossQueue < 0 || cfg.Activation.CrossAdmin < 0 {
+		errs = append(errs, "activation heights must not be negative")
+	}
+
+	if cfg.Activation.CrossAdmin > 0 && (cfg.Activation.CrossQueue == 0 || cfg.Activation.CrossQueue > cfg.Activation.CrossAdmin) {
+		errs = append(errs, "activation.cross_admin needs activation.cross_queue at or before it")
+	}
+
+	for i, fee := range cfg.Swap.FeeTiers {
+		if fee <= 0 || fee >= 10000 {
+			errs = append(errs, fmt.Sprintf("swap.fee_tiers[%d] %d must be between 1 and 9999 basis points", i, fee))
+		}
+	}
+
+	switch cfg.PriceOracle.Source {
+	case "":
+	case oracle.SourceStatic:
+		if cfg.PriceOracle.Price <= 0 {
+			errs = append(errs, "price_oracle.price must be positive for the static source")
+		}
+	case oracle.SourceFile:
+		if cfg.PriceOracle.File == "" {
+			errs = append(errs, "price_oracle.file must be set for the file source")
+		}
+	case oracle.SourceHttp:
+		if _, err := url.ParseRequestURI(cfg.PriceOracle.Url); err != nil {
+			errs = append(errs, fmt.Sprintf("price_oracle.url: %s", err.Error()))
+		}
+		if !strings.Contains(cfg.PriceOracle.Url, oracle.TimeParam) {
+			errs = append(errs, fmt.Sprintf("price_oracle.url must hold %s, a current price would differ between indexings of a block", oracle.TimeParam))
+		}
+		if cfg.PriceOracle.Timeout < 0 || cfg.PriceOracle.Ttl < 0 {
+			errs = append(errs, "price_oracle.timeout and price_oracle.ttl must not be negative")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("price_oracle.source %q must be static, file or http", cfg.PriceOracle.Source))
+	}
+
+	if cfg.Log.Format != logging.FormatTerminal && cfg.Log.Format != logging.FormatJson {
+		errs = append(errs, fmt.Sprintf("log.format %q must be terminal or json", cfg.Log.Format))
+	}
+
+	if _, err := logging.ParseModules(cfg.Log.Modules); err != nil {
+		errs = append(errs, fmt.Sprintf("log.modules: %s", err.Error()))
+	}
+
+	if cfg.DebugLevel < 0 || cfg.DebugLevel > 5 {
+		errs = append(errs, "debug_level must be between 0 and 5")
+	}
 
-	// Default config.
-	configFileName := "config.json"
-	if len(os.Args) > 1 {
-		configFileName = os.Args[1]
+	if cfg.ShutdownTimeout < 0 {
+		errs = append(errs, "shutdown_timeout must not be negative")
 	}
 
-	configFileName, _ = filepath.Abs(configFileName)
-	log.Printf("Loading config: %v", configFileName)
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Print writes cfg as indented JSON with every secret redacted.
+func Print(w io.Writer, cfg *Config) error {
+	// work on a deep copy, lists would otherwise be shared with cfg
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
 
-	if filep != "" {
-		configFileName = filep
+	c := &Config{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return err
 	}
-	configFile, err := os.Open(configFileName)
+	redact(reflect.ValueOf(c).Elem())
+
+	data, err = json.MarshalIndent(c, "", "  ")
 	if err != nil {
-		log.Fatal("File error: ", err.Error())
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// validateRateLimit checks that the tiers are usable and that every configured key has one.
+func validateRateLimit(cfg *utils.HttpConfig) []string {
+	errs := make([]string, 0)
+	tiers := make(map[string]bool)
+	for i, t := range cfg.RateLimit.Tiers {
+		if t.Name == "" {
+			errs = append(errs, fmt.Sprintf("http_server.rate_limit.tiers[%d].name must be set", i))
+		}
+		if tiers[t.Name] {
+			errs = append(errs, fmt.Sprintf("http_server.rate_limit.tiers[%d]: tier %q is defined twice", i, t.Name))
+		}
+		tiers[t.Name] = true
+		if t.Rate <= 0 || t.Burst <= 0 || t.ExpensiveRate <= 0 || t.ExpensiveBurst <= 0 {
+			errs = append(errs, fmt.Sprintf("http_server.rate_limit.tiers[%d]: rates and bursts must be positive", i))
+		}
+	}
+
+	if cfg.RateLimit.Switch && !tiers["anonymous"] {
+		errs = append(errs, "http_server.rate_limit.tiers must define the anonymous tier")
 	}
-	defer configFile.Close()
-	jsonParser := json.NewDecoder(configFile)
-	if err := jsonParser.Decode(&cfg); err != nil {
-		log.Fatal("Config error: ", err.Error())
+
+	for i, k := range cfg.Auth.Keys {
+		if k.Key == "" {
+			errs = append(errs, fmt.Sprintf("http_server.auth.keys[%d].key must be set", i))
+		}
+		if cfg.RateLimit.Switch && k.Tier != "" && !tiers[k.Tier] {
+			errs = append(errs, fmt.Sprintf("http_server.auth.keys[%d]: unknown tier %q", i, k.Tier))
+		}
 	}
+	return errs
 }
 
 func (cfg *Config) GetConfig() *Config {
 	return cfg
 }
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+func validHostPort(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil || !validPort(p) {
+		return fmt.Errorf("%q is not a valid port", port)
+	}
+	return nil
+}
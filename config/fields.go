@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// field is a settable leaf of Config addressed by its dotted json key path, e.g. mysql.pass_word.
+type field struct {
+	path  string
+	value reflect.Value
+}
+
+func fields(cfg *Config) []*field {
+	list := make([]*field, 0)
+	walk(reflect.ValueOf(cfg).Elem(), "", &list)
+	return list
+}
+
+func walk(v reflect.Value, prefix string, list *[]*field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			walk(fv, prefix+name+".", list)
+			continue
+		}
+
+		*list = append(*list, &field{path: prefix + name, value: fv})
+	}
+}
+
+func (f *field) envNames() []string {
+	parts := strings.Split(f.path, ".")
+	compact := make([]string, len(parts))
+	for i, part := range parts {
+		compact[i] = strings.ReplaceAll(part, "_", "")
+	}
+
+	names := []string{envPrefix + strings.ToUpper(strings.Join(parts, "_"))}
+	if name := envPrefix + strings.ToUpper(strings.Join(compact, "_")); name != names[0] {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (f *field) set(raw string) error {
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a bool", raw)
+		}
+		f.value.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		f.value.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		f.value.SetFloat(n)
+	case reflect.Slice:
+		// lists are given as JSON, e.g. [{"rpc":"10.0.0.2:22555"}]
+		v := reflect.New(f.value.Type())
+		if err := json.Unmarshal([]byte(raw), v.Interface()); err != nil {
+			return fmt.Errorf("%q is not a JSON list: %s", raw, err.Error())
+		}
+		f.value.Set(v.Elem())
+	default:
+		return fmt.Errorf("unsupported type %s", f.value.Kind())
+	}
+	return nil
+}
+
+// redact replaces every non empty secret key below v, lists included.
+func redact(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			fv := v.Field(i)
+			if secretKeys[name] && fv.Kind() == reflect.String && fv.String() != "" {
+				fv.SetString(redacted)
+				continue
+			}
+			redact(fv)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			redact(v.Index(i))
+		}
+	}
+}
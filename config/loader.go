@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"reflect"
+)
+
+// Loader builds a Config from, in increasing priority, Default, the config file,
+// the environment and the command line flags.
+type Loader struct {
+	path        string
+	printConfig bool
+	flags       map[string]string
+	order       []string
+	warnings    []string
+}
+
+// NewLoader registers --config, --print-config and one flag per key, e.g. --mysql.pass_word, on fs.
+func NewLoader(fs *flag.FlagSet) *Loader {
+	l := &Loader{
+		flags: make(map[string]string),
+	}
+
+	fs.StringVar(&l.path, "config", "", "config file, JSON or YAML (default "+DefaultConfigFile+")")
+	fs.BoolVar(&l.printConfig, "print-config", false, "print the effective config with secrets redacted and exit")
+
+	for _, f := range fields(Default()) {
+		fs.Var(&keyFlag{loader: l, path: f.path, bool: f.value.Kind() == reflect.Bool}, f.path, "overrides the config key "+f.path)
+	}
+	return l
+}
+
+// Load builds the config once the flag set is parsed. path is used when --config is not given,
+// a missing file is only an error if it was asked for explicitly.
+func (l *Loader) Load(path string) (*Config, error) {
+	cfg := Default()
+
+	explicit := true
+	if l.path != "" {
+		path = l.path
+	} else if path == "" {
+		path = DefaultConfigFile
+		explicit = false
+	}
+
+	warnings, err := LoadFile(cfg, path)
+	if err != nil {
+		if explicit || !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		warnings = []string{fmt.Sprintf("config file %s not found, using defaults and environment", path)}
+	}
+	l.warnings = warnings
+
+	if err := LoadEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]*field)
+	for _, f := range fields(cfg) {
+		index[f.path] = f
+	}
+
+	for _, path := range l.order {
+		if err := index[path].set(l.flags[path]); err != nil {
+			return nil, fmt.Errorf("--%s: %s", path, err.Error())
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// PrintConfig reports whether --print-config was given.
+func (l *Loader) PrintConfig() bool {
+	return l.printConfig
+}
+
+// Warnings returns the unknown and deprecated keys found by the last Load.
+func (l *Loader) Warnings() []string {
+	return l.warnings
+}
+
+// keyFlag only records the raw value, it is applied on top of the environment by Load.
+type keyFlag struct {
+	loader *Loader
+	path   string
+	bool   bool
+}
+
+func (f *keyFlag) String() string {
+	return ""
+}
+
+func (f *keyFlag) Set(s string) error {
+	if _, ok := f.loader.flags[f.path]; !ok {
+		f.loader.order = append(f.loader.order, f.path)
+	}
+	f.loader.flags[f.path] = s
+	return nil
+}
+
+func (f *keyFlag) IsBoolFlag() bool {
+	return f.bool
+}
@@ -1,16 +1,13 @@
 package explorer
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/btcutil"
 	"github.com/dogecoinw/doged/chaincfg"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/google/uuid"
 	"github.com/unielon-org/unielon-indexer/models"
-	"github.com/unielon-org/unielon-indexer/utils"
 	"gorm.io/gorm"
 )
 
@@ -21,16 +18,10 @@ func (e *Explorer) boxDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 		return nil, fmt.Errorf("box already exist or err %s", tx.Hash)
 	}
 
-	// 解析数据
-	param := &models.BoxInscription{}
-	err = json.Unmarshal(pushedData, param)
+	reveal, commit := e.reveal(tx)
+	box, err := DecodeBox(pushedData, reveal)
 	if err != nil {
-		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
-	}
-
-	box, err := utils.ConvertBox(param)
-	if err != nil {
-		return nil, fmt.Errorf("ConvertBox err: %s", err.Error())
+		return nil, err
 	}
 
 	box.OrderId = uuid.New().String()
@@ -40,28 +31,14 @@ func (e *Explorer) boxDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 	box.BlockNumber = number
 	box.OrderStatus = 1
 
-	if len(tx.Vout) < 1 {
-		return nil, fmt.Errorf("vout length is not enough")
-	}
-
-	box.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
-
-	txHashIn, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txHashIn)
+	txRawResult0, err := commit(0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	box.FeeAddress = txRawResult0.Vout[tx.Vin[0].Vout].ScriptPubKey.Addresses[0]
-
-	txHashIn1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txHashIn1)
+	box.FeeAddress, err = outAddress(txRawResult0, tx.Vin[0].Vout)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
-	}
-
-	if box.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
-		return nil, fmt.Errorf("the address is not the same as the previous transaction")
+		return nil, err
 	}
 
 	err = e.dbc.DB.Save(box).Error
@@ -1,14 +1,11 @@
 package explorer
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/google/uuid"
 	"github.com/unielon-org/unielon-indexer/models"
-	"github.com/unielon-org/unielon-indexer/utils"
 	"gorm.io/gorm"
 )
 
@@ -19,15 +16,10 @@ func (e Explorer) crossDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 		return nil, fmt.Errorf("cross already exist or err %s", tx.Txid)
 	}
 
-	param := &models.CrossInscription{}
-	err = json.Unmarshal(pushedData, param)
+	reveal, _ := e.reveal(tx)
+	cross, err := DecodeCross(pushedData, reveal, e.verify, number)
 	if err != nil {
-		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
-	}
-
-	cross, err := utils.ConvertCross(param)
-	if err != nil {
-		return nil, fmt.Errorf("ConvertCross err: %s", err.Error())
+		return nil, err
 	}
 
 	cross.OrderId = uuid.New().String()
@@ -36,49 +28,67 @@ func (e Explorer) crossDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 	cross.BlockHash = tx.BlockHash
 	cross.BlockNumber = number
 	cross.OrderStatus = 1
-	cross.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
 
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	err = e.dbc.DB.Create(cross).Error
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, fmt.Errorf("err: %s", err.Error())
 	}
 
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	return cross, nil
+}
+
+func (e Explorer) crossDeploy(cross *models.CrossInfo) error {
+
+	tx := e.dbc.DB.Begin()
+	err := e.dbc.CrossDeploy(tx, cross)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		tx.Rollback()
+		return err
 	}
 
-	if cross.Op == "mint" {
-
-		txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-		txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
-		if err != nil {
-			return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
-		}
+	// 更新 status
+	err = tx.Model(&models.CrossInfo{}).Where("tx_hash = ?", cross.TxHash).Update("order_status", 0).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
 
-		cross.HolderAddress = txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0]
+	err = tx.Commit().Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
 
-	} else {
-		if cross.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
-			return nil, fmt.Errorf("the address is not the same as the previous transaction")
-		}
+func (e Explorer) crossMint(cross *models.CrossInfo) error {
 
+	tx := e.dbc.DB.Begin()
+	err := e.dbc.CrossMint(tx, cross)
+	if err != nil {
+		tx.Rollback()
+		return err
 	}
 
-	err = e.dbc.DB.Create(cross).Error
+	// 更新 status
+	err = tx.Model(&models.CrossInfo{}).Where("tx_hash = ?", cross.TxHash).Update("order_status", 0).Error
 	if err != nil {
-		return nil, fmt.Errorf("err: %s", err.Error())
+		tx.Rollback()
+		return err
 	}
 
-	return cross, nil
+	err = tx.Commit().Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
 }
 
-func (e Explorer) crossDeploy(cross *models.CrossInfo) error {
+func (e Explorer) crossBurn(cross *models.CrossInfo) error {
 
 	tx := e.dbc.DB.Begin()
-	err := e.dbc.CrossDeploy(tx, cross)
+	err := e.dbc.CrossBurn(tx, cross)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -99,10 +109,10 @@ func (e Explorer) crossDeploy(cross *models.CrossInfo) error {
 	return nil
 }
 
-func (e Explorer) crossMint(cross *models.CrossInfo) error {
+func (e Explorer) crossRelease(cross *models.CrossInfo) error {
 
 	tx := e.dbc.DB.Begin()
-	err := e.dbc.CrossMint(tx, cross)
+	err := e.dbc.CrossRelease(tx, cross)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -123,10 +133,10 @@ func (e Explorer) crossMint(cross *models.CrossInfo) error {
 	return nil
 }
 
-func (e Explorer) crossBurn(cross *models.CrossInfo) error {
+func (e Explorer) crossSetAdmin(cross *models.CrossInfo) error {
 
 	tx := e.dbc.DB.Begin()
-	err := e.dbc.CrossBurn(tx, cross)
+	err := e.dbc.CrossSetAdmin(tx, cross)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -6,11 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/doged/txscript"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/utils"
 )
 
+// ErrInscriptionJson is returned with the raw data when a reveal input carries json that does not parse.
+var ErrInscriptionJson = errors.New("inscription json")
+
 func (e *Explorer) reDecode(vin btcjson.Vin) (*models.BaseInscription, []byte, error) {
 
 	in := vin
@@ -23,6 +27,13 @@ func (e *Explorer) reDecode(vin btcjson.Vin) (*models.BaseInscription, []byte, e
 		return nil, nil, fmt.Errorf("hex.DecodeString err: %s", err.Error())
 	}
 
+	return DecodeInscription(scriptbytes)
+}
+
+// DecodeInscription extracts the inscription json from a reveal input's signature script.
+// The last push of the script is the redeem script, whose fourth push carries the json.
+func DecodeInscription(scriptbytes []byte) (*models.BaseInscription, []byte, error) {
+
 	pkScript, err := txscript.PushedData(scriptbytes)
 	if err != nil {
 		return nil, nil, fmt.Errorf("PushedData err: %s", err.Error())
@@ -44,7 +55,7 @@ func (e *Explorer) reDecode(vin btcjson.Vin) (*models.BaseInscription, []byte, e
 	param := &models.BaseInscription{}
 	err = json.Unmarshal(pushedData[3], param)
 	if err != nil {
-		return nil, nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+		return nil, pushedData[3], fmt.Errorf("%w: json.Unmarshal err: %s", ErrInscriptionJson, err.Error())
 	}
 
 	return param, pushedData[3], nil
@@ -114,10 +125,18 @@ func (e *Explorer) reDecodeNft(tx *btcjson.TxRawResult) (*models.NftInscription,
 
 func (e *Explorer) reDecodeFile(tx *btcjson.TxRawResult) (*models.FileInscription, error) {
 
-	inscription := &models.FileInscription{}
-	fileDatas := []byte{}
+	scripts, err := scriptsOf(tx)
+	if err != nil {
+		return nil, err
+	}
 
-	for i, in := range tx.Vin {
+	return DecodeFileInscription(scripts)
+}
+
+// scriptsOf returns the signature scripts of the inputs of tx.
+func scriptsOf(tx *btcjson.TxRawResult) ([][]byte, error) {
+	scripts := make([][]byte, 0, len(tx.Vin))
+	for _, in := range tx.Vin {
 
 		if in.ScriptSig == nil {
 			return nil, errors.New("ScriptSig is nil")
@@ -128,6 +147,82 @@ func (e *Explorer) reDecodeFile(tx *btcjson.TxRawResult) (*models.FileInscriptio
 			return nil, fmt.Errorf("hex.DecodeString err: %s", err.Error())
 		}
 
+		scripts = append(scripts, scriptbytes)
+	}
+	return scripts, nil
+}
+
+// reveal reads tx for the shared decoders, commit returns the commit transaction an input spends,
+// each is fetched from the node once.
+func (e *Explorer) reveal(tx *btcjson.TxRawResult) (*Reveal, func(index int) (*btcjson.TxRawResult, error)) {
+	commits := make(map[int]*btcjson.TxRawResult)
+	commit := func(index int) (*btcjson.TxRawResult, error) {
+		if txv, ok := commits[index]; ok {
+			return txv, nil
+		}
+
+		if index >= len(tx.Vin) {
+			return nil, fmt.Errorf("input %d does not exist", index)
+		}
+
+		txhash, _ := chainhash.NewHashFromStr(tx.Vin[index].Txid)
+		txv, err := e.node.GetRawTransactionVerboseBool(txhash)
+		if err != nil {
+			return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %w", err)
+		}
+
+		commits[index] = txv
+		return txv, nil
+	}
+
+	reveal := &Reveal{Hash: tx.Hash, Outs: make([]*TxOut, 0, len(tx.Vout))}
+	for _, out := range tx.Vout {
+		address := ""
+		if len(out.ScriptPubKey.Addresses) > 0 {
+			address = out.ScriptPubKey.Addresses[0]
+		}
+		reveal.Outs = append(reveal.Outs, &TxOut{Address: address, Value: out.Value})
+	}
+
+	reveal.Sender = func(index int) (string, error) {
+		txv, err := commit(index)
+		if err != nil {
+			return "", err
+		}
+
+		if len(txv.Vin) < 1 {
+			return "", errors.New("the commit transaction has no inputs")
+		}
+
+		txhash, _ := chainhash.NewHashFromStr(txv.Vin[0].Txid)
+		fund, err := e.node.GetRawTransactionVerboseBool(txhash)
+		if err != nil {
+			return "", fmt.Errorf("GetRawTransactionVerboseBool err: %w", err)
+		}
+
+		return outAddress(fund, txv.Vin[0].Vout)
+	}
+
+	return reveal, commit
+}
+
+// outAddress returns the address of the output at index of tx.
+func outAddress(tx *btcjson.TxRawResult, index uint32) (string, error) {
+	if int(index) >= len(tx.Vout) || len(tx.Vout[index].ScriptPubKey.Addresses) < 1 {
+		return "", fmt.Errorf("the output %d of %s has no address", index, tx.Txid)
+	}
+	return tx.Vout[index].ScriptPubKey.Addresses[0], nil
+}
+
+// DecodeFileInscription rebuilds a file inscription from the signature scripts of every input.
+// The first input carries the json, the following inputs carry the file data.
+func DecodeFileInscription(scripts [][]byte) (*models.FileInscription, error) {
+
+	inscription := &models.FileInscription{}
+	fileDatas := []byte{}
+
+	for i, scriptbytes := range scripts {
+
 		pkScript, err := txscript.PushedData(scriptbytes)
 		if err != nil {
 			return nil, fmt.Errorf("PushedData err: %s", err.Error())
@@ -1,17 +1,13 @@
 package explorer
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/btcutil"
 	"github.com/dogecoinw/doged/chaincfg"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
-	"github.com/dogecoinw/go-dogecoin/log"
 	"github.com/google/uuid"
 	"github.com/unielon-org/unielon-indexer/models"
-	"github.com/unielon-org/unielon-indexer/utils"
 	"gorm.io/gorm"
 )
 
@@ -22,15 +18,10 @@ func (e *Explorer) exchangeDecode(tx *btcjson.TxRawResult, pushedData []byte, nu
 		return nil, fmt.Errorf("exchange already exist or err %s", tx.Hash)
 	}
 
-	inscription := &models.ExchangeInscription{}
-	err = json.Unmarshal(pushedData, inscription)
+	reveal, commit := e.reveal(tx)
+	ex, err := DecodeExchange(pushedData, reveal, e.verify, number)
 	if err != nil {
-		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
-	}
-
-	ex, err := utils.ConvertExChange(inscription)
-	if err != nil {
-		return nil, fmt.Errorf("exchange err: %s", err.Error())
+		return nil, err
 	}
 
 	ex.OrderId = uuid.New().String()
@@ -38,27 +29,15 @@ func (e *Explorer) exchangeDecode(tx *btcjson.TxRawResult, pushedData []byte, nu
 	ex.TxHash = tx.Hash
 	ex.BlockHash = tx.BlockHash
 	ex.BlockNumber = number
-	ex.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
-	if ex.Op == "create" {
-		ex.ExId = tx.Hash
-	}
 
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	txRawResult0, err := commit(0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	ex.FeeAddress = txRawResult0.Vout[tx.Vin[0].Vout].ScriptPubKey.Addresses[0]
-
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	ex.FeeAddress, err = outAddress(txRawResult0, tx.Vin[0].Vout)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
-	}
-
-	if ex.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
-		return nil, fmt.Errorf("The address is not the same as the previous transaction")
+		return nil, err
 	}
 
 	err = e.dbc.DB.Save(ex).Error
@@ -70,7 +49,7 @@ func (e *Explorer) exchangeDecode(tx *btcjson.TxRawResult, pushedData []byte, nu
 }
 
 func (e *Explorer) exchangeCreate(ex *models.ExchangeInfo) error {
-	log.Info("explorer", "p", "exchange", "op", "create", "tx_hash", ex.TxHash)
+	e.logger.Info("explorer", "p", "exchange", "op", "create", "tx_hash", ex.TxHash)
 	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(ex.ExId), &chaincfg.MainNetParams)
 
 	tx := e.dbc.DB.Begin()
@@ -96,7 +75,7 @@ func (e *Explorer) exchangeCreate(ex *models.ExchangeInfo) error {
 
 func (e *Explorer) exchangeTrade(ex *models.ExchangeInfo) error {
 
-	log.Info("explorer", "p", "exchange", "op", "trade", "tx_hash", ex.TxHash)
+	e.logger.Info("explorer", "p", "exchange", "op", "trade", "tx_hash", ex.TxHash)
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.ExchangeTrade(tx, ex)
 	if err != nil {
@@ -119,7 +98,7 @@ func (e *Explorer) exchangeTrade(ex *models.ExchangeInfo) error {
 }
 
 func (e *Explorer) exchangeCancel(ex *models.ExchangeInfo) error {
-	log.Info("explorer", "p", "exchange", "op", "cancel", "tx_hash", ex.TxHash)
+	e.logger.Info("explorer", "p", "exchange", "op", "cancel", "tx_hash", ex.TxHash)
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.ExchangeCancel(tx, ex)
 	if err != nil {
@@ -0,0 +1,56 @@
+package explorer
+
+import (
+	"testing"
+
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/utils"
+)
+
+func TestExchangeExpireActivation(t *testing.T) {
+	c := newTestChain()
+	order := func(name string, expire interface{}) string {
+		return c.inscribe(t, name, testHolder0, map[string]interface{}{"p": "order-v1", "op": "create", "tick0": "AAAA", "tick1": "BBBB", "amt0": "1000", "amt1": "2000", "expire": expire})
+	}
+
+	// before the activation any expire decodes and is dropped
+	legacyFloat := order("legacy-float", 1.5)
+	legacyString := order("legacy-string", "soon")
+	c.mine(legacyFloat, legacyString)
+
+	expireString := order("expire-string", "100")
+	expireNumber := order("expire-number", 200)
+	expireFloat := order("expire-float", 1.5)
+	c.mine(expireString, expireNumber, expireFloat)
+
+	e := newTestExplorer(t, c, utils.ActivationConfig{ExchangeExpire: 2})
+	if err := e.scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		txHash string
+		expire int64
+	}{
+		{legacyFloat, 0},
+		{legacyString, 0},
+		{expireString, 100},
+		{expireNumber, 200},
+	}
+
+	for _, c := range cases {
+		ex := &models.ExchangeInfo{}
+		if err := e.dbc.DB.Where("tx_hash = ?", c.txHash).First(ex).Error; err != nil {
+			t.Fatal(err)
+		}
+		if ex.OrderStatus != 0 || ex.Expire != c.expire {
+			t.Errorf("order %s: status %d expire %d, want expire %d, err %s", c.txHash, ex.OrderStatus, ex.Expire, c.expire, ex.ErrInfo)
+		}
+	}
+
+	var count int64
+	e.dbc.DB.Model(&models.ExchangeInfo{}).Where("tx_hash = ?", expireFloat).Count(&count)
+	if count != 0 {
+		t.Fatalf("an order with a fractional expire decoded after the activation")
+	}
+}
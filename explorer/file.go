@@ -5,11 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
-	"github.com/dogecoinw/go-dogecoin/log"
 	"github.com/google/uuid"
 	"github.com/unielon-org/unielon-indexer/models"
-	"github.com/unielon-org/unielon-indexer/utils"
 	"gorm.io/gorm"
 	"time"
 )
@@ -26,9 +23,10 @@ func (e Explorer) fileDecode(tx *btcjson.TxRawResult, number int64) (*models.Fil
 		return nil, fmt.Errorf("reDecodeFile err: %s", err.Error())
 	}
 
-	file, err := utils.ConvertFile(inscription)
+	reveal, _ := e.reveal(tx)
+	file, err := DecodeFile(inscription, reveal)
 	if err != nil {
-		return nil, fmt.Errorf("ConvertNft err: %s", err.Error())
+		return nil, err
 	}
 
 	file.OrderId = uuid.New().String()
@@ -41,36 +39,6 @@ func (e Explorer) fileDecode(tx *btcjson.TxRawResult, number int64) (*models.Fil
 	file.UpdateDate = models.LocalTime(time.Now().Unix())
 	file.CreateDate = models.LocalTime(time.Now().Unix())
 
-	if file.Op == "deploy" {
-		file.FileId = tx.Hash
-		file.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
-		if tx.Vout[0].Value != 0.001 {
-			return nil, fmt.Errorf("The amount of tokens exceeds the 0.0001")
-		}
-	}
-
-	txHash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txHash0)
-	if err != nil {
-		return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
-	}
-
-	if file.Op == "transfer" {
-
-		txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-		txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
-		if err != nil {
-			return nil, fmt.Errorf("getRawTransactionVerboseBool err: %s", err.Error())
-		}
-
-		file.HolderAddress = txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0]
-		file.ToAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
-
-		if file.HolderAddress == file.ToAddress {
-			return nil, errors.New("the address is the same")
-		}
-	}
-
 	reader := bytes.NewReader(file.FileData)
 
 	hash, _ := e.ipfs.Add(reader)
@@ -89,7 +57,7 @@ func (e Explorer) fileDecode(tx *btcjson.TxRawResult, number int64) (*models.Fil
 
 func (e Explorer) fileDeploy(model *models.FileInfo) error {
 
-	log.Info("explorer", "p", "file", "op", "deploy", "tx_hash", model.TxHash)
+	e.logger.Info("explorer", "p", "file", "op", "deploy", "tx_hash", model.TxHash)
 
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.FileDeploy(tx, model)
@@ -114,7 +82,7 @@ func (e Explorer) fileDeploy(model *models.FileInfo) error {
 
 func (e *Explorer) fileTransfer(model *models.FileInfo) error {
 
-	log.Info("explorer", "p", "file", "op", "transfer", "tx_hash", model.TxHash)
+	e.logger.Info("explorer", "p", "file", "op", "transfer", "tx_hash", model.TxHash)
 
 	tx := e.dbc.DB.Begin()
 
@@ -1,16 +1,13 @@
 package explorer
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/btcutil"
 	"github.com/dogecoinw/doged/chaincfg"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/google/uuid"
 	"github.com/unielon-org/unielon-indexer/models"
-	"github.com/unielon-org/unielon-indexer/utils"
 	"gorm.io/gorm"
 	"time"
 )
@@ -22,15 +19,10 @@ func (e *Explorer) fileExchangeDecode(tx *btcjson.TxRawResult, pushedData []byte
 		return nil, fmt.Errorf("file-exchange already exist or err %s", tx.Hash)
 	}
 
-	param := &models.FileExchangeInscription{}
-	err = json.Unmarshal(pushedData, param)
+	reveal, commit := e.reveal(tx)
+	ex, err := DecodeFileExchange(pushedData, reveal, e.dbc.DB)
 	if err != nil {
-		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
-	}
-
-	ex, err := utils.ConvertFileExchange(param)
-	if err != nil {
-		return nil, fmt.Errorf("exchange err: %s", err.Error())
+		return nil, err
 	}
 
 	ex.OrderId = uuid.New().String()
@@ -42,51 +34,14 @@ func (e *Explorer) fileExchangeDecode(tx *btcjson.TxRawResult, pushedData []byte
 	ex.UpdateDate = models.LocalTime(time.Now().Unix())
 	ex.CreateDate = models.LocalTime(time.Now().Unix())
 
-	ex.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
-	if ex.Op == "create" {
-		ex.ExId = tx.Hash
-	}
-
-	if ex.Op == "trade" {
-
-		exc := &models.FileExchangeCollect{}
-		err := e.dbc.DB.Where("ex_id = ? ", ex.ExId).First(exc).Error
-		if err != nil {
-			return nil, fmt.Errorf("the contract does not exist err %s", err.Error())
-		}
-
-		ex.FileId = exc.FileId
-	}
-
-	if ex.Op == "cancel" {
-		exc := &models.FileExchangeCollect{}
-		err := e.dbc.DB.Where("ex_id = ? ", ex.ExId).First(exc).Error
-		if err != nil {
-			return nil, fmt.Errorf("the contract does not exist err %s", err.Error())
-		}
-
-		ex.FileId = exc.FileId
-		ex.Tick = exc.Tick
-		ex.Amt = exc.Amt
-
-	}
-
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	txRawResult0, err := commit(0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	ex.FeeAddress = txRawResult0.Vout[tx.Vin[0].Vout].ScriptPubKey.Addresses[0]
-
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	ex.FeeAddress, err = outAddress(txRawResult0, tx.Vin[0].Vout)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
-	}
-
-	if ex.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
-		return nil, fmt.Errorf("the address is not the same as the previous transaction")
+		return nil, err
 	}
 
 	err = e.dbc.DB.Create(ex).Error
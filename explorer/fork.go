@@ -3,7 +3,6 @@ package explorer
 import (
 	"errors"
 	"fmt"
-	"github.com/dogecoinw/go-dogecoin/log"
 	"github.com/unielon-org/unielon-indexer/models"
 	"gorm.io/gorm"
 	"math/big"
@@ -38,7 +37,7 @@ func (e *Explorer) forkBack() error {
 	}
 
 	if localHash != block.PreviousHash {
-		log.Warn("forkBack Begin", "height", height)
+		e.logger.Warn("forkBack Begin", "height", height)
 		for blockHash.String() != localHash {
 			height--
 			blockHash, err = e.node.GetBlockHash(height)
@@ -55,7 +54,7 @@ func (e *Explorer) forkBack() error {
 		tx := e.dbc.DB.Begin()
 		err := e.fork(tx, height)
 		if err != nil {
-			log.Error("fork error", "err", err)
+			e.logger.Error("fork error", "err", err)
 			tx.Rollback()
 			return err
 		}
@@ -66,7 +65,7 @@ func (e *Explorer) forkBack() error {
 		}
 
 		e.currentHeight = height
-		log.Warn("forkBack End", "height", height)
+		e.logger.Warn("forkBack End", "height", height)
 	}
 
 	return nil
@@ -79,7 +78,7 @@ func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 		return err
 	}
 
-	log.Info("fork", "drc20", height)
+	e.logger.Info("fork", "drc20", height)
 	// drc20
 	var drc20Reverts []*models.Drc20Revert
 	err = tx.Model(&models.Drc20Revert{}).
@@ -110,8 +109,49 @@ func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 		}
 	}
 
-	log.Info("fork", "swap", height)
+	err = tx.Where("block_number > ?", height).Delete(&models.Drc20Burn{}).Error
+	if err != nil {
+		return fmt.Errorf("drc20 burn revert error: %v", err)
+	}
+
+	e.logger.Info("fork", "swap", height)
 	// swap
+	var swapReverts []*models.SwapLiquidityRevert
+	err = tx.Model(&models.SwapLiquidityRevert{}).
+		Where("block_number > ?", height).
+		Order("id desc").
+		Find(&swapReverts).Error
+
+	if err != nil {
+		return fmt.Errorf("swap revert error: %v", err)
+	}
+
+	for _, revert := range swapReverts {
+		if revert.Op == "create" {
+			err = tx.Where("tick = ?", revert.Tick).Delete(&models.SwapLiquidity{}).Error
+			if err != nil {
+				return fmt.Errorf("delete swap_liquidity error: %v", err)
+			}
+
+			err = tx.Where("tick = ?", revert.Tick).Delete(&models.Drc20CollectAddress{}).Error
+			if err != nil {
+				return fmt.Errorf("delete drc20_collect_address error: %v", err)
+			}
+
+			err = tx.Where("tick = ?", revert.Tick).Delete(&models.Drc20Collect{}).Error
+			if err != nil {
+				return fmt.Errorf("delete drc20_collect error: %v", err)
+			}
+		}
+
+		if revert.Op == "k_last" {
+			err = tx.Model(&models.SwapLiquidity{}).Where("tick = ?", revert.Tick).Update("k_last", revert.KLast.String()).Error
+			if err != nil {
+				return fmt.Errorf("update swap_liquidity error: %v", err)
+			}
+		}
+	}
+
 	err = e.UpdateLiquidity(tx)
 	if err != nil {
 		return fmt.Errorf("UpdateLiquidity error: %v", err)
@@ -147,7 +187,7 @@ func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 	//	return err
 	//}
 
-	log.Info("fork", "file", height)
+	e.logger.Info("fork", "file", height)
 	// file
 	var fileReverts []*models.FileRevert
 	err = tx.Model(&models.FileRevert{}).
@@ -174,7 +214,7 @@ func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 		}
 	}
 
-	log.Info("fork", "Exchange", height)
+	e.logger.Info("fork", "Exchange", height)
 	// Exchange
 	var exchangeReverts []*models.ExchangeRevert
 	err = tx.Model(&models.ExchangeRevert{}).
@@ -212,6 +252,7 @@ func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 				Updates(map[string]interface{}{
 					"amt0_finish": amt0_0.String(),
 					"amt1_finish": amt1_1.String(),
+					"status":      revert.Status,
 				}).Error
 
 			if err != nil {
@@ -219,7 +260,7 @@ func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 			}
 		}
 
-		if revert.Op == "cancel" {
+		if revert.Op == "cancel" || revert.Op == "expire" {
 
 			ec := &models.ExchangeCollect{}
 			err = tx.Where("ex_id = ?", revert.ExId).First(ec).Error
@@ -228,16 +269,21 @@ func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 			}
 
 			amt0 := ec.Amt0Finish.Int()
-			amt0_0 := big.NewInt(0).Add(amt0, revert.Amt0.Int())
+			amt0_0 := big.NewInt(0).Sub(amt0, revert.Amt0.Int())
 
-			err = tx.Model(&models.ExchangeCollect{}).Where("ex_id = ?", revert.ExId).Update("amt0_finish", amt0_0.String()).Error
+			err = tx.Model(&models.ExchangeCollect{}).
+				Where("ex_id = ?", revert.ExId).
+				Updates(map[string]interface{}{
+					"amt0_finish": amt0_0.String(),
+					"status":      revert.Status,
+				}).Error
 			if err != nil {
 				return fmt.Errorf("update exchange_collect error: %v", err)
 			}
 		}
 	}
 
-	log.Info("fork", "stake", height)
+	e.logger.Info("fork", "stake", height)
 	// stake
 	var stakeReverts []*models.StakeRevert
 	err = tx.Model(&models.StakeRevert{}).
@@ -295,7 +341,7 @@ func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 		}
 	}
 
-	log.Info("fork", "box", height)
+	e.logger.Info("fork", "box", height)
 	// box
 	err = tx.Exec("update box_collect a, drc20_collect_address b set a.liqamt_finish = b.amt_sum where a.tick1 = b.tick and a.reserves_address = b.holder_address").Error
 	if err != nil {
@@ -506,7 +552,8 @@ func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 	}
 
 	for _, revert := range crossReverts {
-		if revert.Op == "deploy" {
+		switch revert.Op {
+		case "deploy":
 
 			err = tx.Where("tick = ?", revert.Tick).Delete(&models.CrossCollect{}).Error
 			if err != nil {
@@ -517,9 +564,58 @@ func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 			if err != nil {
 				return fmt.Errorf("Drc20Collect error: %v", err)
 			}
+
+		case "deposit":
+			err = tx.Where("tx_hash = ?", revert.TxHash).Delete(&models.CrossDeposit{}).Error
+			if err != nil {
+				return fmt.Errorf("CrossDeposit error: %v", err)
+			}
+
+		case "withdraw":
+			err = tx.Where("burn_tx_hash = ?", revert.TxHash).Delete(&models.CrossWithdrawal{}).Error
+			if err != nil {
+				return fmt.Errorf("CrossWithdrawal error: %v", err)
+			}
+
+		case "release":
+			update := map[string]interface{}{
+				"status":               models.CrossWithdrawalPending,
+				"ext_tx_id":            "",
+				"release_address":      "",
+				"release_tx_hash":      "",
+				"release_block_number": 0,
+			}
+			err = tx.Model(&models.CrossWithdrawal{}).Where("release_tx_hash = ?", revert.TxHash).Updates(update).Error
+			if err != nil {
+				return fmt.Errorf("CrossWithdrawal error: %v", err)
+			}
+
+		case "approve":
+			err = tx.Where("tx_hash = ?", revert.TxHash).Delete(&models.CrossApproval{}).Error
+			if err != nil {
+				return fmt.Errorf("CrossApproval error: %v", err)
+			}
+
+		case "admin":
+			update := map[string]interface{}{
+				"admin_address": revert.AdminAddress,
+				"admins":        revert.Admins,
+				"threshold":     revert.Threshold,
+				"nonce":         revert.Nonce,
+			}
+			err = tx.Model(&models.CrossCollect{}).Where("tick = ?", revert.Tick).Updates(update).Error
+			if err != nil {
+				return fmt.Errorf("CrossCollect error: %v", err)
+			}
 		}
 	}
 
+	e.logger.Info("fork", "candle", height)
+	err = e.dbc.CandleRevert(tx, height, e.prices)
+	if err != nil {
+		return err
+	}
+
 	err = e.delRevert(tx, height)
 	if err != nil {
 		return err
@@ -531,7 +627,7 @@ func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 
 func (e *Explorer) delInfo(tx *gorm.DB, height int64) error {
 
-	log.Info("delInfo", "height", height)
+	e.logger.Info("delInfo", "height", height)
 
 	err := tx.Where("block_number > ?", height).Delete(&models.Drc20Info{}).Error
 	if err != nil {
@@ -598,6 +694,11 @@ func (e *Explorer) delInfo(tx *gorm.DB, height int64) error {
 		return fmt.Errorf("CrossInfo error: %v", err)
 	}
 
+	err = tx.Where("block_number > ?", height).Delete(&models.RejectedInscription{}).Error
+	if err != nil {
+		return fmt.Errorf("RejectedInscription error: %v", err)
+	}
+
 	return nil
 
 }
@@ -639,6 +740,11 @@ func (e *Explorer) delRevert(tx *gorm.DB, height int64) error {
 	//	return fmt.Errorf("StakeV2Revert error: %v", err)
 	//}
 
+	err = tx.Where("block_number > ?", height).Delete(&models.SwapLiquidityRevert{}).Error
+	if err != nil {
+		return fmt.Errorf("SwapLiquidityRevert error: %v", err)
+	}
+
 	err = tx.Where("block_number > ?", height).Delete(&models.CrossRevert{}).Error
 	if err != nil {
 		return fmt.Errorf("CrossRevert error: %v", err)
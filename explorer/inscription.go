@@ -0,0 +1,446 @@
+package explorer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"github.com/unielon-org/unielon-indexer/verifys"
+	"gorm.io/gorm"
+	"math/big"
+	"strings"
+)
+
+// The decoders in this file are shared by the explorer and the tx router, so that a transaction the
+// router accepts is decoded the same way once it is mined. They read the inscription and the reveal
+// outputs only, what needs the node comes from the Sender of the reveal.
+
+// TxOut is an output of a reveal transaction, value in doge as the node reports it.
+type TxOut struct {
+	Address string
+	Value   float64
+}
+
+// Reveal is what the decoders read of a reveal transaction besides its inscription.
+type Reveal struct {
+	Hash string
+	Outs []*TxOut
+	// Sender returns the address that funded the commit transaction spent by the input at index
+	Sender func(index int) (string, error)
+}
+
+var errNoOutputs = errors.New("vout length is not enough")
+
+func (r *Reveal) checkSender(index int, holder string) error {
+	sender, err := r.Sender(index)
+	if err != nil {
+		return err
+	}
+
+	if sender != holder {
+		return errors.New("the address is not the same as the previous transaction")
+	}
+	return nil
+}
+
+// DecodeDrc20 decodes a drc-20 inscription, deploy and mint go to the first output, transfer and burn
+// are from the sender to the outputs but the last.
+func DecodeDrc20(pushedData []byte, reveal *Reveal) (*models.Drc20Info, error) {
+	if len(reveal.Outs) < 1 {
+		return nil, errNoOutputs
+	}
+
+	param := &models.Drc20Inscription{}
+	err := json.Unmarshal(pushedData, param)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+
+	card, err := utils.ConvetCard(param)
+	if err != nil {
+		return nil, fmt.Errorf("ConvetCard err: %s", err.Error())
+	}
+
+	card.Repeat = 1
+	outs := reveal.Outs
+	switch card.Op {
+	case "deploy":
+		card.HolderAddress = outs[0].Address
+		if outs[0].Value != 0.001 {
+			return nil, fmt.Errorf("The amount of tokens exceeds the 0.0001")
+		}
+	case "mint":
+		card.HolderAddress = outs[0].Address
+		card.Repeat = int64(outs[0].Value / 0.001)
+		if card.Repeat > 30 {
+			card.Repeat = 30
+		}
+
+		if outs[0].Value != 0.001*float64(card.Repeat) {
+			return nil, fmt.Errorf("The amount of tokens exceeds the 0.0001")
+		}
+	case "transfer", "burn":
+		card.HolderAddress, err = reveal.Sender(0)
+		if err != nil {
+			return nil, err
+		}
+
+		card.ToAddress = outs[0].Address
+		for i := 1; i < len(outs)-1; i++ {
+			card.ToAddress += "," + outs[i].Address
+		}
+	}
+
+	for _, v := range strings.Split(card.ToAddress, ",") {
+		if card.HolderAddress == v {
+			return nil, errors.New("The address is not the same as the previous transaction")
+		}
+	}
+
+	return card, nil
+}
+
+// DecodeSwaps decodes the swap inscriptions of every input, the holder of each is the first output.
+// scripts are the signature scripts of the inputs.
+func DecodeSwaps(scripts [][]byte, reveal *Reveal, verify *verifys.Verifys, height int64) ([]*models.SwapInfo, error) {
+	if len(reveal.Outs) < 1 {
+		return nil, errNoOutputs
+	}
+
+	swaps := make([]*models.SwapInfo, 0, len(scripts))
+	dogeDepositAmt := big.NewInt(0)
+	for i, script := range scripts {
+		decode, pushedData, err := DecodeInscription(script)
+		if err != nil || decode.P != "pair-v1" {
+			return nil, fmt.Errorf("not a swap transaction")
+		}
+
+		param := &models.SwapInscription{}
+		err = json.Unmarshal(pushedData, param)
+		if err != nil {
+			return nil, fmt.Errorf("json Unmarshal err: %s", err.Error())
+		}
+
+		swap, err := utils.ConvetSwap(param)
+		if err != nil {
+			return nil, fmt.Errorf("ConvertSwap err: %s", err.Error())
+		}
+
+		// the fee of an inscription only counts from the activation, older pools keep the default one
+		if swap.Op == "create" && verify.SwapFeeActive(height) {
+			fee, err := utils.ConvertRawInt(param.Fee)
+			if err != nil {
+				return nil, fmt.Errorf("swap fee err: %s", err.Error())
+			}
+			swap.Fee = int(fee)
+		}
+
+		if swap.Doge == 1 {
+			if (swap.Op == "create" || swap.Op == "add" || swap.Op == "swap") && swap.Tick0 == "WDOGE(WRAPPED-DOGE)" {
+				dogeDepositAmt.Add(dogeDepositAmt, swap.Amt0.Int())
+			}
+
+			if (swap.Op == "create" || swap.Op == "add") && swap.Tick1 == "WDOGE(WRAPPED-DOGE)" {
+				dogeDepositAmt.Add(dogeDepositAmt, swap.Amt1.Int())
+			}
+		}
+
+		swap.TxIndex = i
+		swap.HolderAddress = reveal.Outs[0].Address
+		err = reveal.checkSender(i, swap.HolderAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		swaps = append(swaps, swap)
+	}
+
+	if dogeDepositAmt.Sign() > 0 {
+		err := checkWDogeDeposit(reveal.Outs, dogeDepositAmt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return swaps, nil
+}
+
+// DecodeWDoge decodes a wdoge inscription, a deposit pays the amount and the fee to the outputs 1 and 2.
+func DecodeWDoge(pushedData []byte, reveal *Reveal) (*models.WDogeInfo, error) {
+	if len(reveal.Outs) < 1 {
+		return nil, errNoOutputs
+	}
+
+	param := &models.WDogeInscription{}
+	err := json.Unmarshal(pushedData, param)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+
+	wdoge, err := utils.ConvertWDoge(param)
+	if err != nil {
+		return nil, fmt.Errorf("ConvertWDoge err: %s", err.Error())
+	}
+
+	wdoge.Tick = "WDOGE(WRAPPED-DOGE)"
+	if wdoge.Op == "deposit" {
+		err = checkWDogeDeposit(reveal.Outs, wdoge.Amt.Int())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	wdoge.HolderAddress = reveal.Outs[0].Address
+	err = reveal.checkSender(0, wdoge.HolderAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return wdoge, nil
+}
+
+// checkWDogeDeposit checks that outs pay amt koinu to the cool address and the fee, 0.3% and at least
+// 0.5 doge, to the fee address.
+func checkWDogeDeposit(outs []*TxOut, amt *big.Int) error {
+	if len(outs) != 3 {
+		return fmt.Errorf("mint op error, vout length is not 3")
+	}
+
+	fee := big.NewInt(0)
+	fee.Mul(amt, big.NewInt(3))
+	fee.Div(fee, big.NewInt(1000))
+	if fee.Cmp(big.NewInt(50000000)) == -1 {
+		fee = big.NewInt(50000000)
+	}
+
+	if utils.Float64ToBigInt(outs[1].Value*100000000).Cmp(amt) < 0 {
+		return fmt.Errorf("the amount of tokens is incorrect %f %s", outs[1].Value, utils.Float64ToBigInt(outs[1].Value*100000000).String())
+	}
+
+	if outs[1].Address != wdogeCoolAddress {
+		return fmt.Errorf("the address is incorrect")
+	}
+
+	if utils.Float64ToBigInt(outs[2].Value*100000000).Cmp(fee) < 0 {
+		return fmt.Errorf("the amount of tokens is incorrect fee %f", outs[2].Value)
+	}
+
+	if outs[2].Address != wdogeFeeAddress {
+		return fmt.Errorf("the address is incorrect")
+	}
+
+	return nil
+}
+
+// DecodeFile decodes a file inscription, a deploy creates the file with the id of the reveal and a
+// transfer sends it from the sender to the first output.
+func DecodeFile(inscription *models.FileInscription, reveal *Reveal) (*models.FileInfo, error) {
+	if len(reveal.Outs) < 1 {
+		return nil, errNoOutputs
+	}
+
+	file, err := utils.ConvertFile(inscription)
+	if err != nil {
+		return nil, fmt.Errorf("ConvertNft err: %s", err.Error())
+	}
+
+	if file.Op == "deploy" {
+		file.FileId = reveal.Hash
+		file.HolderAddress = reveal.Outs[0].Address
+		if reveal.Outs[0].Value != 0.001 {
+			return nil, fmt.Errorf("The amount of tokens exceeds the 0.0001")
+		}
+	}
+
+	if file.Op == "transfer" {
+		file.HolderAddress, err = reveal.Sender(0)
+		if err != nil {
+			return nil, err
+		}
+
+		file.ToAddress = reveal.Outs[0].Address
+		if file.HolderAddress == file.ToAddress {
+			return nil, errors.New("the address is the same")
+		}
+	}
+
+	return file, nil
+}
+
+// DecodeStake decodes a stake inscription, the holder is the first output.
+func DecodeStake(pushedData []byte, reveal *Reveal) (*models.StakeInfo, error) {
+	if len(reveal.Outs) < 1 {
+		return nil, errNoOutputs
+	}
+
+	param := &models.StakeInscription{}
+	err := json.Unmarshal(pushedData, param)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+
+	stake, err := utils.ConvertStake(param)
+	if err != nil {
+		return nil, fmt.Errorf("ConvertWDoge err: %s", err.Error())
+	}
+
+	stake.HolderAddress = reveal.Outs[0].Address
+	err = reveal.checkSender(0, stake.HolderAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return stake, nil
+}
+
+// DecodeExchange decodes an order-v1 inscription, the holder is the first output and a create is the
+// order of the reveal.
+func DecodeExchange(pushedData []byte, reveal *Reveal, verify *verifys.Verifys, height int64) (*models.ExchangeInfo, error) {
+	if len(reveal.Outs) < 1 {
+		return nil, errNoOutputs
+	}
+
+	inscription := &models.ExchangeInscription{}
+	err := json.Unmarshal(pushedData, inscription)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+
+	ex, err := utils.ConvertExChange(inscription)
+	if err != nil {
+		return nil, fmt.Errorf("exchange err: %s", err.Error())
+	}
+
+	ex.HolderAddress = reveal.Outs[0].Address
+	if ex.Op == "create" {
+		ex.ExId = reveal.Hash
+	}
+
+	// the expire of an order only counts from the activation, older orders never expire
+	if verify.ExchangeExpireActive(height) {
+		ex.Expire, err = utils.ConvertRawInt(inscription.Expire)
+		if err != nil {
+			return nil, fmt.Errorf("exchange expire err: %s", err.Error())
+		}
+	}
+
+	err = reveal.checkSender(0, ex.HolderAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return ex, nil
+}
+
+// DecodeFileExchange decodes an order-v2 inscription, a trade or a cancel reads the file of its order from db.
+func DecodeFileExchange(pushedData []byte, reveal *Reveal, db *gorm.DB) (*models.FileExchangeInfo, error) {
+	if len(reveal.Outs) < 1 {
+		return nil, errNoOutputs
+	}
+
+	param := &models.FileExchangeInscription{}
+	err := json.Unmarshal(pushedData, param)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+
+	ex, err := utils.ConvertFileExchange(param)
+	if err != nil {
+		return nil, fmt.Errorf("exchange err: %s", err.Error())
+	}
+
+	ex.HolderAddress = reveal.Outs[0].Address
+	if ex.Op == "create" {
+		ex.ExId = reveal.Hash
+	}
+
+	if ex.Op == "trade" || ex.Op == "cancel" {
+		exc := &models.FileExchangeCollect{}
+		err := db.Where("ex_id = ? ", ex.ExId).First(exc).Error
+		if err != nil {
+			return nil, fmt.Errorf("the contract does not exist err %s", err.Error())
+		}
+
+		ex.FileId = exc.FileId
+		if ex.Op == "cancel" {
+			ex.Tick = exc.Tick
+			ex.Amt = exc.Amt
+		}
+	}
+
+	err = reveal.checkSender(0, ex.HolderAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return ex, nil
+}
+
+// DecodeBox decodes a box inscription, the holder is the first output.
+func DecodeBox(pushedData []byte, reveal *Reveal) (*models.BoxInfo, error) {
+	if len(reveal.Outs) < 1 {
+		return nil, errNoOutputs
+	}
+
+	param := &models.BoxInscription{}
+	err := json.Unmarshal(pushedData, param)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+
+	box, err := utils.ConvertBox(param)
+	if err != nil {
+		return nil, fmt.Errorf("ConvertBox err: %s", err.Error())
+	}
+
+	box.HolderAddress = reveal.Outs[0].Address
+	err = reveal.checkSender(0, box.HolderAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return box, nil
+}
+
+// DecodeCross decodes a cross inscription, an admin op is signed by the sender and the others are of
+// the first output.
+func DecodeCross(pushedData []byte, reveal *Reveal, verify *verifys.Verifys, height int64) (*models.CrossInfo, error) {
+	if len(reveal.Outs) < 1 {
+		return nil, errNoOutputs
+	}
+
+	param := &models.CrossInscription{}
+	err := json.Unmarshal(pushedData, param)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+
+	cross, err := utils.ConvertCross(param)
+	if err != nil {
+		return nil, fmt.Errorf("ConvertCross err: %s", err.Error())
+	}
+
+	// the admins and the threshold only count for the admin ops from the activation on
+	if verify.CrossAdminActive(height) {
+		err = utils.ConvertCrossAdmin(cross, param)
+		if err != nil {
+			return nil, fmt.Errorf("ConvertCrossAdmin err: %s", err.Error())
+		}
+	}
+
+	if verifys.CrossAdminOp(cross.Op) {
+		cross.HolderAddress, err = reveal.Sender(0)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		cross.HolderAddress = reveal.Outs[0].Address
+		err = reveal.checkSender(0, cross.HolderAddress)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cross, nil
+}
@@ -0,0 +1,287 @@
+package explorer
+
+import (
+	"fmt"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/unielon-org/unielon-indexer/chain"
+	"github.com/unielon-org/unielon-indexer/models"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"sort"
+)
+
+// infoModels are the tables a decoder writes one row per inscription into.
+var infoModels = []schema.Tabler{
+	&models.Drc20Info{},
+	&models.SwapInfo{},
+	&models.WDogeInfo{},
+	&models.FileInfo{},
+	&models.StakeInfo{},
+	&models.ExchangeInfo{},
+	&models.FileExchangeInfo{},
+	&models.BoxInfo{},
+	&models.CrossInfo{},
+}
+
+// diffIgnoreColumns are written by the execution step or by the database, not by the decoder.
+var diffIgnoreColumns = map[string]bool{
+	"id":           true,
+	"order_id":     true,
+	"order_status": true,
+	"err_info":     true,
+	"create_date":  true,
+	"update_date":  true,
+}
+
+// executeColumns are written to the info rows of a table by the execution step, a fresh decode
+// that is not executed leaves them at the decoded value.
+var executeColumns = map[string]map[string]bool{
+	"swap_info":     {"amt0_out": true, "amt1_out": true, "liquidity": true, "fee": true},
+	"exchange_info": {"tick0": true, "tick1": true, "amt0": true},
+}
+
+type BlockDiff struct {
+	Table  string      `json:"table"`
+	TxHash string      `json:"tx_hash"`
+	Column string      `json:"column"`
+	Stored interface{} `json:"stored"`
+	Decode interface{} `json:"decode"`
+}
+
+func (d *BlockDiff) String() string {
+	return fmt.Sprintf("%s %s %s: stored=%v decode=%v", d.Table, d.TxHash, d.Column, d.Stored, d.Decode)
+}
+
+// Rollback reverts every inscription above height and forgets the blocks,
+// the next scan starts again at height + 1.
+func (e *Explorer) Rollback(height int64) error {
+	if height < 0 {
+		return fmt.Errorf("rollback height must not be negative")
+	}
+
+	e.logger.Warn("explorer", "Rollback Begin", height)
+
+	tx := e.dbc.DB.Begin()
+	err := e.fork(tx, height)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Rollback fork err: %s", err.Error())
+	}
+
+	err = tx.Where("block_number > ?", height).Delete(&models.Block{}).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Rollback DeleteBlock err: %s", err.Error())
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return fmt.Errorf("Rollback Commit err: %s", err.Error())
+	}
+
+	e.currentHeight = height + 1
+	e.logger.Warn("explorer", "Rollback End", height)
+	return nil
+}
+
+// Reindex rolls back to from - 1 and scans again until the node tip is reached.
+func (e *Explorer) Reindex(from int64) error {
+	if from < 1 {
+		return fmt.Errorf("reindex height must be positive")
+	}
+
+	err := e.Rollback(from - 1)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return nil
+		default:
+		}
+
+		blockCount, err := e.node.GetBlockCount()
+		if err != nil {
+			return fmt.Errorf("Reindex GetBlockCount err: %s", err.Error())
+		}
+
+		if e.currentHeight >= blockCount {
+			e.logger.Info("explorer", "Reindex End", e.currentHeight-1)
+			return nil
+		}
+
+		err = e.scan()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// VerifyBlock decodes every inscription of the block at height again and compares
+// the result with the stored rows. Nothing is written, the work is done in a
+// transaction that is always rolled back.
+func (e *Explorer) VerifyBlock(height int64) ([]*BlockDiff, error) {
+	blockHash, err := e.node.GetBlockHash(height)
+	if err != nil {
+		return nil, fmt.Errorf("VerifyBlock GetBlockHash err: %s", err.Error())
+	}
+
+	localHash := ""
+	err = e.dbc.DB.Model(&models.Block{}).Where("block_number = ?", height).Select("block_hash").Scan(&localHash).Error
+	if err != nil {
+		return nil, fmt.Errorf("VerifyBlock FindBlock err: %s", err.Error())
+	}
+
+	if localHash == "" {
+		return nil, fmt.Errorf("block %d is not indexed", height)
+	}
+
+	if localHash != blockHash.String() {
+		return nil, fmt.Errorf("block %d hash mismatch: stored %s, node %s", height, localHash, blockHash.String())
+	}
+
+	block, err := e.node.GetBlockVerboseBool(blockHash)
+	if err != nil {
+		return nil, fmt.Errorf("VerifyBlock GetBlockVerboseBool err: %s", err.Error())
+	}
+
+	stored, err := e.blockRows(height)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded map[string]map[string]map[string]interface{}
+	tx := e.dbc.DB.Begin()
+	err = func() error {
+		for _, model := range infoModels {
+			err := tx.Where("block_number = ?", height).Delete(model).Error
+			if err != nil {
+				return fmt.Errorf("VerifyBlock Delete %s err: %s", model.TableName(), err.Error())
+			}
+		}
+
+		dry := *e
+		dry.dbc = e.dbc.WithDB(tx)
+		for _, txid := range block.Tx {
+			err := dry.redecode(txid, height)
+			if err != nil {
+				return err
+			}
+		}
+
+		decoded, err = dry.blockRows(height)
+		return err
+	}()
+	tx.Rollback()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return diffRows(stored, decoded), nil
+}
+
+// redecode runs the decoder of the inscription in txid, if any, without executing it.
+func (e *Explorer) redecode(txid string, height int64) error {
+	txhash, _ := chainhash.NewHashFromStr(txid)
+	txv, err := e.node.GetRawTransactionVerboseBool(txhash)
+	if err != nil {
+		return fmt.Errorf("redecode GetRawtxvBool err: %s", err.Error())
+	}
+
+	decode, pushedData, err := e.reDecode(txv.Vin[0])
+	if err != nil {
+		return nil
+	}
+
+	_, err = e.decodeTx(decode.P, txv, pushedData, height)
+	if err != nil {
+		if chain.IsNetworkErr(err) {
+			return err
+		}
+		e.logger.Debug("explorer", "redecode", err, "txhash", txid)
+	}
+	return nil
+}
+
+// blockRows loads the info rows of a block by table, then by tx hash and tx index.
+func (e *Explorer) blockRows(height int64) (map[string]map[string]map[string]interface{}, error) {
+	rows := make(map[string]map[string]map[string]interface{})
+	for _, model := range infoModels {
+		table := model.TableName()
+		list := make([]map[string]interface{}, 0)
+		err := e.dbc.DB.Table(table).Where("block_number = ?", height).Find(&list).Error
+		if err != nil {
+			return nil, fmt.Errorf("blockRows Find %s err: %s", table, err.Error())
+		}
+
+		rows[table] = make(map[string]map[string]interface{})
+		for _, row := range list {
+			key := fmt.Sprintf("%v", row["tx_hash"])
+			if index, ok := row["tx_index"]; ok {
+				key = fmt.Sprintf("%s:%v", key, index)
+			}
+			rows[table][key] = row
+		}
+	}
+	return rows, nil
+}
+
+func diffRows(stored, decoded map[string]map[string]map[string]interface{}) []*BlockDiff {
+	diffs := make([]*BlockDiff, 0)
+	for _, model := range infoModels {
+		table := model.TableName()
+		keys := make(map[string]bool)
+		for key := range stored[table] {
+			keys[key] = true
+		}
+		for key := range decoded[table] {
+			keys[key] = true
+		}
+
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+
+		for _, key := range sorted {
+			s, sok := stored[table][key]
+			d, dok := decoded[table][key]
+			if !sok || !dok {
+				diffs = append(diffs, &BlockDiff{Table: table, TxHash: key, Column: "*", Stored: sok, Decode: dok})
+				continue
+			}
+
+			columns := make([]string, 0, len(s))
+			for column := range s {
+				columns = append(columns, column)
+			}
+			sort.Strings(columns)
+
+			for _, column := range columns {
+				if diffIgnoreColumns[column] || executeColumns[table][column] {
+					continue
+				}
+				if !reflect.DeepEqual(normalize(s[column]), normalize(d[column])) {
+					diffs = append(diffs, &BlockDiff{Table: table, TxHash: key, Column: column, Stored: s[column], Decode: d[column]})
+				}
+			}
+		}
+	}
+	return diffs
+}
+
+// normalize makes values read from different drivers comparable.
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case []byte:
+		return string(t)
+	case nil:
+		return nil
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
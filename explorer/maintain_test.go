@@ -0,0 +1,232 @@
+package explorer
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/doged/txscript"
+	"github.com/unielon-org/unielon-indexer/chain"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"github.com/unielon-org/unielon-indexer/verifys"
+)
+
+const (
+	testHolder0 = "DHolder0"
+	testHolder1 = "DHolder1"
+	testFee     = "DFee"
+)
+
+// testChain is a node that serves the blocks and transactions added to it, block 0 is the indexed base.
+type testChain struct {
+	blocks []*btcjson.GetBlockVerboseResult
+	txs    map[string]*btcjson.TxRawResult
+}
+
+func newTestChain() *testChain {
+	c := &testChain{txs: make(map[string]*btcjson.TxRawResult)}
+	c.blocks = append(c.blocks, &btcjson.GetBlockVerboseResult{Hash: testHash("block0"), Tx: []string{}})
+	return c
+}
+
+func testHash(name string) string {
+	return chainhash.DoubleHashH([]byte(name)).String()
+}
+
+// inscribe adds a reveal transaction of holder that carries data, funded by holder through a commit transaction.
+func (c *testChain) inscribe(t *testing.T, name, holder string, data interface{}) string {
+	content, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redeem, err := txscript.NewScriptBuilder().AddOp(txscript.OP_1).AddData([]byte("pubkey")).AddOp(txscript.OP_1).AddOp(txscript.OP_CHECKMULTISIGVERIFY).
+		AddData([]byte("ord")).AddData([]byte("text/plain;charset=utf-8")).AddData(content).
+		AddOp(txscript.OP_DROP).AddOp(txscript.OP_DROP).AddOp(txscript.OP_DROP).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := txscript.NewScriptBuilder().AddOp(txscript.OP_10).AddOp(txscript.OP_FALSE).AddData([]byte("sig")).AddData(redeem).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fund := testHash(name + "-fund")
+	c.txs[fund] = &btcjson.TxRawResult{Txid: fund, Hash: fund, Vout: []btcjson.Vout{testVout(holder, 1)}}
+
+	commit := testHash(name + "-commit")
+	c.txs[commit] = &btcjson.TxRawResult{Txid: commit, Hash: commit, Vin: []btcjson.Vin{{Txid: fund}}, Vout: []btcjson.Vout{testVout(testFee, 0.1)}}
+
+	reveal := testHash(name)
+	c.txs[reveal] = &btcjson.TxRawResult{
+		Txid: reveal,
+		Hash: reveal,
+		Vin:  []btcjson.Vin{{Txid: commit, ScriptSig: &btcjson.ScriptSig{Hex: hex.EncodeToString(sig)}}},
+		Vout: []btcjson.Vout{testVout(holder, 0.001)},
+	}
+	return reveal
+}
+
+func testVout(address string, value float64) btcjson.Vout {
+	return btcjson.Vout{Value: value, ScriptPubKey: btcjson.ScriptPubKeyResult{Addresses: []string{address}}}
+}
+
+// mine adds a block with the reveal transactions.
+func (c *testChain) mine(txs ...string) {
+	prev := c.blocks[len(c.blocks)-1]
+	height := int64(len(c.blocks))
+	block := &btcjson.GetBlockVerboseResult{
+		Hash:         testHash(strings.Join(append([]string{prev.Hash}, txs...), ",")),
+		PreviousHash: prev.Hash,
+		Height:       height,
+		Time:         1700000000 + height*60,
+		Tx:           txs,
+	}
+	for _, txid := range txs {
+		c.txs[txid].BlockHash = block.Hash
+	}
+	c.blocks = append(c.blocks, block)
+}
+
+func (c *testChain) serve(t *testing.T) *chain.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &struct {
+			Id     interface{}       `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}{}
+		json.NewDecoder(r.Body).Decode(req)
+
+		var result interface{}
+		switch req.Method {
+		case "getblockcount":
+			// the scanner stops one block before the count
+			result = len(c.blocks)
+		case "getblockhash":
+			var height int
+			json.Unmarshal(req.Params[0], &height)
+			result = c.blocks[height].Hash
+		case "getblock":
+			var hash string
+			json.Unmarshal(req.Params[0], &hash)
+			for _, block := range c.blocks {
+				if block.Hash == hash {
+					result = block
+				}
+			}
+		case "getrawtransaction":
+			var txid string
+			json.Unmarshal(req.Params[0], &txid)
+			result = c.txs[txid]
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": req.Id, "result": result, "error": nil})
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := chain.NewClient(utils.ChainConfig{Rpc: strings.TrimPrefix(srv.URL, "http://"), UserName: "user", PassWord: "pass", Timeout: 5, Retries: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Shutdown)
+	return client
+}
+
+// newTestExplorer indexes block 0 of the chain and funds the holders with two tokens.
+func newTestExplorer(t *testing.T, c *testChain, activation utils.ActivationConfig) *Explorer {
+	dbc, err := storage.NewSqliteClient(utils.SqliteConfig{Database: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(dbc.Stop)
+
+	err = dbc.DB.AutoMigrate(&models.Block{}, &models.Drc20Collect{}, &models.Drc20CollectAddress{}, &models.Drc20Revert{},
+		&models.SwapLiquidity{}, &models.SwapSummary{}, &models.SwapSummaryLiquidity{},
+		&models.ExchangeCollect{}, &models.ExchangeRevert{}, &models.ExchangeSummary{}, &models.FileExchangeCollect{}, &models.FileExchangeRevert{}, &models.FileExchangeSummary{},
+		&models.BoxCollect{}, &models.BoxCollectAddress{}, &models.BoxRevert{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range infoModels {
+		if err := dbc.DB.AutoMigrate(model); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := dbc.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a zero primary key is taken as unset by a create
+	if err := dbc.DB.Exec("INSERT INTO block (block_number, block_hash) VALUES (0, ?)", c.blocks[0].Hash).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tick := range []string{"AAAA", "BBBB"} {
+		err := dbc.DB.Create(&models.Drc20Collect{Tick: tick, AmtSum: models.NewNumber(0), Max: models.NewNumber(1e15), Lim: models.NewNumber(1e15), Dec: 8, HolderAddress: testHolder0}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, holder := range []string{testHolder0, testHolder1} {
+			if err := dbc.MintDrc20(dbc.DB, tick, holder, models.NewNumber(1e13).Int(), testHash(tick+holder), 0, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	verify := verifys.NewVerifys(dbc, activation, utils.SwapConfig{FeeTiers: []int{30, 100}})
+	return NewExplorer(context.Background(), &sync.WaitGroup{}, c.serve(t), dbc, nil, verify, 1)
+}
+
+func TestVerifyBlockExecuted(t *testing.T) {
+	c := newTestChain()
+	create := c.inscribe(t, "swap-create", testHolder0, map[string]interface{}{"p": "pair-v1", "op": "create", "tick0": "AAAA", "tick1": "BBBB", "amt0": "1000000000000", "amt1": "2000000000000", "amt0_min": "0", "amt1_min": "0", "fee": 100})
+	c.mine(create)
+	swap := c.inscribe(t, "swap-exec", testHolder1, map[string]interface{}{"p": "pair-v1", "op": "swap", "tick0": "AAAA", "tick1": "BBBB", "amt0": "10000000000", "amt1": "1", "amt1_min": "1"})
+	c.mine(swap)
+	order := c.inscribe(t, "order-create", testHolder0, map[string]interface{}{"p": "order-v1", "op": "create", "tick0": "AAAA", "tick1": "BBBB", "amt0": "1000", "amt1": "2000"})
+	c.mine(order)
+	trade := c.inscribe(t, "order-trade", testHolder1, map[string]interface{}{"p": "order-v1", "op": "trade", "exid": order, "amt1": "1000"})
+	c.mine(trade)
+
+	e := newTestExplorer(t, c, utils.ActivationConfig{SwapFee: 1, ExchangeFill: 1})
+	if err := e.scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	executed := &models.SwapInfo{}
+	if err := e.dbc.DB.Where("tx_hash = ?", swap).First(executed).Error; err != nil {
+		t.Fatal(err)
+	}
+	if executed.OrderStatus != 0 || executed.Amt1Out.Int().Sign() <= 0 {
+		t.Fatalf("swap not executed: status %d amt1_out %v err %s", executed.OrderStatus, executed.Amt1Out, executed.ErrInfo)
+	}
+
+	traded := &models.ExchangeInfo{}
+	if err := e.dbc.DB.Where("tx_hash = ?", trade).First(traded).Error; err != nil {
+		t.Fatal(err)
+	}
+	if traded.OrderStatus != 0 || traded.Tick0 != "AAAA" {
+		t.Fatalf("trade not executed: status %d tick0 %s err %s", traded.OrderStatus, traded.Tick0, traded.ErrInfo)
+	}
+
+	for height := int64(1); height < int64(len(c.blocks)); height++ {
+		diffs, err := e.VerifyBlock(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, diff := range diffs {
+			t.Errorf("block %d: %s", height, diff)
+		}
+	}
+}
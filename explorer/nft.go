@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/chaincfg/chainhash"
-	"github.com/dogecoinw/go-dogecoin/log"
 	"github.com/google/uuid"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/utils"
@@ -82,7 +81,7 @@ func (e *Explorer) nftDecode(tx *btcjson.TxRawResult, number int64) (*models.Nft
 	txHash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
 	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txHash0)
 	if err != nil {
-		return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
+		return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %w", err)
 	}
 
 	if nft.Op == "transfer" {
@@ -90,7 +89,7 @@ func (e *Explorer) nftDecode(tx *btcjson.TxRawResult, number int64) (*models.Nft
 		txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
 		txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
 		if err != nil {
-			return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
+			return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %w", err)
 		}
 
 		nft.HolderAddress = txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0]
@@ -116,7 +115,7 @@ func (e *Explorer) nftDecode(tx *btcjson.TxRawResult, number int64) (*models.Nft
 }
 
 func (e *Explorer) nftDeploy(nft *models.NftInfo) error {
-	log.Info("explorer", "p", "nft/ai", "op", "deploy", "tx_hash", nft.TxHash)
+	e.logger.Info("explorer", "p", "nft/ai", "op", "deploy", "tx_hash", nft.TxHash)
 
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.NftDeploy(tx, nft)
@@ -141,7 +140,7 @@ func (e *Explorer) nftDeploy(nft *models.NftInfo) error {
 
 func (e *Explorer) nftMint(nft *models.NftInfo) error {
 
-	log.Info("explorer", "p", "nft/ai", "op", "mint", "tx_hash", nft.TxHash)
+	e.logger.Info("explorer", "p", "nft/ai", "op", "mint", "tx_hash", nft.TxHash)
 	tx := e.dbc.DB.Begin()
 
 	err := e.dbc.NftMint(tx, nft)
@@ -166,7 +165,7 @@ func (e *Explorer) nftMint(nft *models.NftInfo) error {
 
 func (e *Explorer) nftTransfer(nft *models.NftInfo) error {
 
-	log.Info("explorer", "p", "nft/ai", "op", "transfer", "tx_hash", nft.TxHash)
+	e.logger.Info("explorer", "p", "nft/ai", "op", "transfer", "tx_hash", nft.TxHash)
 
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.NftTransfer(tx, nft)
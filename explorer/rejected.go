@@ -0,0 +1,201 @@
+package explorer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/unielon-org/unielon-indexer/chain"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/storage"
+)
+
+// ReprocessResult compares the stored rejection of a transaction with the outcome of decoding it again.
+// AppliedAt is the height the transaction was indexed at with apply.
+type ReprocessResult struct {
+	TxHash      string      `json:"tx_hash"`
+	BlockNumber int64       `json:"block_number"`
+	Before      string      `json:"before"`
+	After       string      `json:"after"`
+	Inscription interface{} `json:"inscription,omitempty"`
+	Applied     bool        `json:"applied"`
+	AppliedAt   int64       `json:"applied_at,omitempty"`
+}
+
+func (r *ReprocessResult) String() string {
+	data, _ := json.Marshal(r.Inscription)
+	return fmt.Sprintf("%s %d\n  before: %s\n  after:  %s\n  decode: %s\n  applied: %t at %d", r.TxHash, r.BlockNumber, r.Before, r.After, data, r.Applied, r.AppliedAt)
+}
+
+func looksLikeJson(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
+
+// reject keeps a transaction that looked like an inscription but could not be decoded.
+func (e *Explorer) reject(txv *btcjson.TxRawResult, height int64, decode *models.BaseInscription, raw []byte, class string, cause error) {
+	r := &models.RejectedInscription{
+		TxHash:      txv.Txid,
+		BlockHash:   txv.BlockHash,
+		BlockNumber: height,
+		Raw:         string(raw),
+		ErrClass:    class,
+		ErrInfo:     cause.Error(),
+		Status:      models.RejectStatusRejected,
+	}
+
+	if decode != nil {
+		r.P = decode.P
+		r.Op = decode.Op
+	}
+
+	if err := e.dbc.SaveRejectedInscription(r); err != nil {
+		e.logger.Error("scanning", "reject", err, "txhash", txv.Txid)
+	}
+}
+
+// Reprocess decodes and verifies the rejected transactions matching filter again in a transaction that is rolled back.
+// With apply, every transaction that now decodes is indexed like the scanner would, in block order. It runs
+// against the current state, so it is decoded, verified and recorded at the last indexed height rather than
+// its own block: a fork below that height reverts it with the rest of the state it was applied to. The
+// results are returned either way so the change can be reviewed.
+func (e *Explorer) Reprocess(filter *storage.RejectedFilter, apply bool) ([]*ReprocessResult, error) {
+	rows, _, err := e.dbc.FindRejectedInscriptions(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	tip := int64(0)
+	if apply {
+		err = e.dbc.DB.Model(&models.Block{}).Select("COALESCE(max(block_number), 0)").Scan(&tip).Error
+		if err != nil {
+			return nil, fmt.Errorf("Reprocess find max block err: %s", err.Error())
+		}
+	}
+
+	defer func() { e.logger = log.New() }()
+
+	results := make([]*ReprocessResult, 0, len(rows))
+	for _, row := range rows {
+		result := &ReprocessResult{
+			TxHash:      row.TxHash,
+			BlockNumber: row.BlockNumber,
+			Before:      fmt.Sprintf("[%s] %s", row.ErrClass, row.ErrInfo),
+		}
+		results = append(results, result)
+
+		txhash, err := chainhash.NewHashFromStr(row.TxHash)
+		if err != nil {
+			result.After = "invalid tx hash"
+			continue
+		}
+
+		txv, err := e.node.GetRawTransactionVerboseBool(txhash)
+		if err != nil {
+			return results, fmt.Errorf("Reprocess GetRawtxvBool %s err: %w", row.TxHash, err)
+		}
+
+		height := row.BlockNumber
+		if apply {
+			height = tip
+		}
+
+		inscription, class, err := e.dryDecode(txv, height)
+		if err != nil {
+			if chain.IsNetworkErr(err) {
+				return results, err
+			}
+			result.After = fmt.Sprintf("[%s] %s", class, err.Error())
+			continue
+		}
+
+		result.Inscription = inscription
+		result.After = "ok"
+		if verr := e.verifyTx(inscription); verr != nil {
+			result.After = "decoded, verify failed: " + verr.Error()
+		}
+
+		if !apply {
+			continue
+		}
+
+		decode, pushedData, err := e.reDecode(txv.Vin[0])
+		if err != nil {
+			return results, fmt.Errorf("Reprocess reDecode %s err: %s", row.TxHash, err.Error())
+		}
+
+		e.logger = log.New("height", height, "tx", row.TxHash, "protocol", decode.P, "op", decode.Op)
+		inscription, err = e.decodeTx(decode.P, txv, pushedData, height)
+		if err != nil {
+			return results, fmt.Errorf("Reprocess decodeTx %s err: %w", row.TxHash, err)
+		}
+
+		if err := e.executeTx(row.TxHash, inscription); err != nil {
+			e.logger.Warn("explorer", "Reprocess execute", err, "txhash", row.TxHash)
+		}
+
+		err = e.dbc.DB.Model(&models.RejectedInscription{}).Where("tx_hash = ?", row.TxHash).Update("status", models.RejectStatusReprocessed).Error
+		if err != nil {
+			return results, fmt.Errorf("Reprocess UpdateStatus %s err: %s", row.TxHash, err.Error())
+		}
+		result.Applied = true
+		result.AppliedAt = height
+	}
+	return results, nil
+}
+
+// dryDecode decodes txv without keeping the order the decoders save.
+func (e *Explorer) dryDecode(txv *btcjson.TxRawResult, height int64) (interface{}, string, error) {
+	decode, pushedData, err := e.reDecode(txv.Vin[0])
+	if err != nil {
+		return nil, models.RejectJson, err
+	}
+
+	tx := e.dbc.DB.Begin()
+	defer tx.Rollback()
+
+	dry := *e
+	dry.dbc = e.dbc.WithDB(tx)
+	inscription, err := dry.decodeTx(decode.P, txv, pushedData, height)
+	if err != nil {
+		if errors.Is(err, errUnknownProtocol) {
+			return nil, models.RejectProtocol, err
+		}
+		return nil, models.RejectDecode, err
+	}
+	return inscription, "", nil
+}
+
+// verifyTx checks a decoded inscription against the current state without applying it.
+// Only the first swap of a pair-v1 transaction can be checked, the others depend on it.
+func (e *Explorer) verifyTx(inscription interface{}) error {
+	v := e.verify
+	switch m := inscription.(type) {
+	case *models.Drc20Info:
+		return v.VerifyDrc20(m)
+	case []*models.SwapInfo:
+		if len(m) == 0 {
+			return nil
+		}
+		return v.VerifySwap(e.dbc.DB, m[0])
+	case *models.WDogeInfo:
+		return v.VerifyWDoge(m)
+	case *models.FileInfo:
+		return v.VerifyFile(m)
+	case *models.StakeInfo:
+		return v.VerifyStake(m)
+	case *models.ExchangeInfo:
+		return v.VerifyExchange(m)
+	case *models.FileExchangeInfo:
+		return v.VerifyFileExchange(m)
+	case *models.BoxInfo:
+		return v.VerifyBox(m)
+	case *models.CrossInfo:
+		return v.VerifyCross(m)
+	default:
+		return fmt.Errorf("verifyTx unknown inscription %T", inscription)
+	}
+}
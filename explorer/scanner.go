@@ -4,15 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/chaincfg/chainhash"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/dogecoinw/go-dogecoin/log"
 	"github.com/google/uuid"
 	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/config"
 	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/oracle"
 	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/utils"
 	"github.com/unielon-org/unielon-indexer/verifys"
+	"gorm.io/gorm"
 	"math/big"
 	"sync"
 	"time"
@@ -21,34 +25,54 @@ import (
 const (
 	startInterval = 3 * time.Second
 
-	wdogeFeeAddress  = "D86Dc4n49LZDiXvB41ds2XaDAP1BFjP1qy"
-	wdogeCoolAddress = "DKMyk8cfSTGfnCVXfmo8gXta9F6gziu7Z5"
-	nftFeeAddress    = "DBFQmJ5oGCgtnDVxUU7xEraztpEyqJHdxz"
+	wdogeFeeAddress  = utils.WDogeFeeAddress
+	wdogeCoolAddress = utils.WDogeCoolAddress
+	nftFeeAddress    = utils.NftFeeAddress
 )
 
 var (
-	CHAIN_NETWORK_ERR = errors.New("chain network error")
+	CHAIN_NETWORK_ERR = chain.ErrNetwork
+
+	errUnknownProtocol = errors.New("unknown protocol")
+	errFenced          = errors.New("the fence of the block commit failed")
 )
 
 type Explorer struct {
 	config        *config.Config
-	node          *rpcclient.Client
+	node          *chain.Client
 	dbc           *storage.DBClient
 	ipfs          *shell.Shell
 	verify        *verifys.Verifys
 	currentHeight int64
 
+	statusLock *sync.RWMutex
+	lastScan   time.Time
+	lastErr    error
+
+	// logger carries the height, and while a transaction is indexed its hash, protocol and op
+	logger log.Logger
+
+	blockHooks []func(height int64)
+	// prices is the DOGE/USD price recorded on the candles, nil records none
+	prices oracle.PriceOracle
+	// fence is checked in the transaction that commits a block, it fails once the leader lease is lost
+	fence   func(tx *gorm.DB) error
+	leading bool
+	terms   int
+
 	ctx context.Context
 	wg  *sync.WaitGroup
 }
 
-func NewExplorer(ctx context.Context, wg *sync.WaitGroup, rpcClient *rpcclient.Client, dbc *storage.DBClient, ipfs *shell.Shell, currentHeight int64) *Explorer {
+func NewExplorer(ctx context.Context, wg *sync.WaitGroup, rpcClient *chain.Client, dbc *storage.DBClient, ipfs *shell.Shell, verify *verifys.Verifys, currentHeight int64) *Explorer {
 	exp := &Explorer{
 		node:          rpcClient,
 		dbc:           dbc,
 		ipfs:          ipfs,
-		verify:        verifys.NewVerifys(dbc),
+		verify:        verify,
 		currentHeight: currentHeight,
+		statusLock:    &sync.RWMutex{},
+		logger:        log.New(),
 		ctx:           ctx,
 		wg:            wg,
 	}
@@ -56,8 +80,40 @@ func NewExplorer(ctx context.Context, wg *sync.WaitGroup, rpcClient *rpcclient.C
 }
 
 func (e *Explorer) Start() {
-
 	defer e.wg.Done()
+	e.setLeading(true)
+	e.run()
+}
+
+// Lead scans until ctx is done, the caller holds the leader lease meanwhile.
+// A term resumes from the last block in the database, another replica may have indexed it, and first rolls
+// back what a replica that lost the lease in the middle of the next block left of it.
+func (e *Explorer) Lead(ctx context.Context) {
+	if e.terms > 0 {
+		e.currentHeight = 0
+	}
+	e.terms++
+
+	e.ctx = ctx
+	e.setLeading(true)
+	defer e.setLeading(false)
+
+	var maxHeight int64
+	err := e.dbc.DB.Model(&models.Block{}).Select("COALESCE(max(block_number), 0)").Scan(&maxHeight).Error
+	if err == nil && maxHeight > 0 {
+		err = e.dbc.DB.Transaction(func(tx *gorm.DB) error {
+			return e.fork(tx, maxHeight)
+		})
+	}
+	if err != nil {
+		e.logger.Error("explorer", "Lead", err)
+		return
+	}
+
+	e.run()
+}
+
+func (e *Explorer) run() {
 	if e.currentHeight == 0 {
 		maxHeight := e.currentHeight
 		err := e.dbc.DB.Model(&models.Block{}).Select("max(block_number)").Scan(&maxHeight).Error
@@ -69,21 +125,74 @@ func (e *Explorer) Start() {
 	}
 
 	startTicker := time.NewTicker(startInterval)
+	defer startTicker.Stop()
 out:
 	for {
 		select {
 		case <-startTicker.C:
-			if err := e.scan(); err != nil {
-				log.Error("explorer", "Start", err.Error())
+			err := e.scan()
+			if err != nil {
+				e.logger.Error("explorer", "Start", err.Error())
+			}
+			e.setStatus(err)
+			if errors.Is(err, errFenced) {
+				break out
 			}
 		case <-e.ctx.Done():
-			log.Warn("explorer", "Stop", "Done")
+			e.logger.Warn("explorer", "Stop", "Done")
 			break out
 		}
 	}
 }
 
+// OnBlock registers fn to be called after every block is committed.
+// It must be called before Start.
+func (e *Explorer) OnBlock(fn func(height int64)) {
+	e.blockHooks = append(e.blockHooks, fn)
+}
+
+// SetFence makes the transaction that commits a block call fence first, a block is not committed when it fails.
+// It must be called before Start.
+func (e *Explorer) SetFence(fence func(tx *gorm.DB) error) {
+	e.fence = fence
+}
+
+// SetPriceOracle records the DOGE/USD price of prices on the candles.
+// It must be called before Start.
+func (e *Explorer) SetPriceOracle(prices oracle.PriceOracle) {
+	e.prices = prices
+}
+
+func (e *Explorer) setStatus(err error) {
+	e.statusLock.Lock()
+	defer e.statusLock.Unlock()
+	e.lastScan = time.Now()
+	e.lastErr = err
+}
+
+func (e *Explorer) setLeading(leading bool) {
+	e.statusLock.Lock()
+	defer e.statusLock.Unlock()
+	e.leading = leading
+	e.lastErr = nil
+}
+
+// Leading reports whether the explorer is scanning, it is always false on replicas without the leader lease.
+func (e *Explorer) Leading() bool {
+	e.statusLock.RLock()
+	defer e.statusLock.RUnlock()
+	return e.leading
+}
+
+// LastScan returns the time of the last finished scan and the error it returned, if any.
+func (e *Explorer) LastScan() (time.Time, error) {
+	e.statusLock.RLock()
+	defer e.statusLock.RUnlock()
+	return e.lastScan, e.lastErr
+}
+
 func (e *Explorer) scan() error {
+	defer func() { e.logger = log.New() }()
 
 	blockCount, err := e.node.GetBlockCount()
 	if err != nil {
@@ -100,6 +209,13 @@ func (e *Explorer) scan() error {
 	blockCount = e.currentHeight + temp
 
 	for ; e.currentHeight < blockCount; e.currentHeight++ {
+		// a stop between blocks leaves nothing to roll back
+		select {
+		case <-e.ctx.Done():
+			return nil
+		default:
+		}
+
 		err := e.forkBack()
 		if err != nil {
 			return fmt.Errorf("scan forkBack err: %s", err.Error())
@@ -115,165 +231,205 @@ func (e *Explorer) scan() error {
 			return fmt.Errorf("scan GetBlockVerboseBool err: %s", err.Error())
 		}
 
-		log.Info("explorer", "scanning start ", e.currentHeight, "txs", len(block.Tx))
+		blockLog := log.New("height", e.currentHeight)
+		e.logger = blockLog
+		e.logger.Info("explorer", "scanning start ", e.currentHeight, "txs", len(block.Tx))
 
-		err = e.dbc.ScheduledTasks(e.currentHeight)
+		err = e.dbc.ScheduledTasks(e.currentHeight, e.verify.ExchangeExpireActive(e.currentHeight))
 		if err != nil {
 			return fmt.Errorf("scan ScheduledTasks err: %s", err.Error())
 		}
 
 		for _, tx := range block.Tx {
+			e.logger = blockLog
+
+			// a stop in the middle of a block rolls the part already applied back
+			select {
+			case <-e.ctx.Done():
+				return e.abortBlock(e.ctx.Err())
+			default:
+			}
 
 			txhash, _ := chainhash.NewHashFromStr(tx)
 			txv, err := e.node.GetRawTransactionVerboseBool(txhash)
 			if err != nil {
-				return fmt.Errorf("scan GetRawtxvBool err: %s", err.Error())
+				return e.abortBlock(fmt.Errorf("scan GetRawtxvBool err: %w", err))
 			}
 
 			decode, pushedData, err := e.reDecode(txv.Vin[0])
 			if err != nil {
-				log.Trace("scanning", "verifyReDecode", err, "txhash", txv.Txid)
+				if errors.Is(err, ErrInscriptionJson) && looksLikeJson(pushedData) {
+					e.reject(txv, e.currentHeight, nil, pushedData, models.RejectJson, err)
+				}
+				e.logger.Trace("scanning", "verifyReDecode", err, "txhash", txv.Txid)
 				continue
 			}
 
-			switch decode.P {
-			case "drc-20":
-				drc20, err := e.drc20Decode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "drc20Decode", err, "txhash", txv.Txid)
-					continue
-				}
-
-				err = e.executeDrc20(drc20)
-				if err != nil {
-					e.dbc.DB.Model(&models.Drc20Info{}).Where("tx_hash = ?", drc20.TxHash).Update("err_info", err.Error())
-					continue
-				}
-
-			case "pair-v1":
-
-				swaps, err := e.swapRouterDecode(txv, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "swapRouterDecode", err, "txhash", tx)
-					continue
-				}
-
-				err = e.executePairV1(swaps)
-				if err != nil {
-					e.dbc.DB.Model(&models.SwapInfo{}).Where("tx_hash = ?", tx).Update("err_info", err.Error())
-					continue
-				}
-
-			case "wdoge":
-				wdoge, err := e.wdogeDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "wdogeDecode", err, "txhash", txv.Txid)
-					continue
+			e.logger = blockLog.New("tx", txv.Txid, "protocol", decode.P, "op", decode.Op)
+			inscription, err := e.decodeTx(decode.P, txv, pushedData, e.currentHeight)
+			if err != nil {
+				if chain.IsNetworkErr(err) {
+					return e.abortBlock(err)
 				}
 
-				err = e.executeWdoge(wdoge)
-				if err != nil {
-					e.dbc.DB.Model(&models.WDogeInfo{}).Where("tx_hash = ?", wdoge.TxHash).Update("err_info", err.Error())
-					continue
+				class := models.RejectDecode
+				if errors.Is(err, errUnknownProtocol) {
+					class = models.RejectProtocol
 				}
 
-			case "file":
-				file, err := e.fileDecode(txv, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "nftDecode", err, "txhash", txv.Txid)
-					continue
-				}
+				e.logger.Error("scanning", "decode", err, "p", decode.P, "txhash", txv.Txid)
+				e.reject(txv, e.currentHeight, decode, pushedData, class, err)
+				continue
+			}
 
-				err = e.executeFile(file)
-				if err != nil {
-					e.dbc.DB.Model(&models.FileInfo{}).Where("tx_hash = ?", file.TxHash).Update("err_info", err.Error())
-					continue
-				}
+			if err := e.executeTx(tx, inscription); err != nil {
+				e.logger.Debug("explorer", "execute", err)
+			}
+		}
+		e.logger = blockLog
 
-			case "stake-v1":
-				stake, err := e.stakeDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "stakeDecode", err, "txhash", txv.Txid)
-					continue
-				}
+		block1 := &models.Block{
+			BlockHash:   blockHash.String(),
+			BlockNumber: e.currentHeight,
+			BlockTime:   block.Time,
+		}
 
-				err = e.executeStakeV1(stake)
-				if err != nil {
-					e.dbc.DB.Model(&models.StakeInfo{}).Where("tx_hash = ?", stake.TxHash).Update("err_info", err.Error())
-					continue
+		fenced := false
+		err = e.dbc.DB.Transaction(func(tx *gorm.DB) error {
+			if e.fence != nil {
+				if err := e.fence(tx); err != nil {
+					fenced = true
+					return err
 				}
+			}
 
-			case "order-v1":
-				ex, err := e.exchangeDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "exchangeDecode", err, "txhash", txv.Txid)
-					continue
-				}
+			err := e.dbc.CandleBlock(tx, e.currentHeight, block.Time, e.prices)
+			if err != nil {
+				return err
+			}
 
-				err = e.executeOrderV1(ex)
-				if err != nil {
-					e.dbc.DB.Model(&models.ExchangeInfo{}).Where("tx_hash = ?", ex.TxHash).Update("err_info", err.Error())
-					continue
-				}
+			err = tx.Save(block1).Error
+			if err != nil {
+				return fmt.Errorf("scan SetBlockHash err: %s", err.Error())
+			}
+			return nil
+		})
+		if fenced {
+			// another replica may be indexing this block already, it rolls back what is left of ours
+			return fmt.Errorf("scan block %d not committed: %w, %s", e.currentHeight, errFenced, err.Error())
+		}
+		if err != nil {
+			return e.abortBlock(err)
+		}
 
-			case "order-v2":
-				ex, err := e.fileExchangeDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "fileExchangeDecode", err, "txhash", txv.Txid)
-					continue
-				}
+		for _, hook := range e.blockHooks {
+			hook(e.currentHeight)
+		}
 
-				err = e.executeOrderV2(ex)
-				if err != nil {
-					e.dbc.DB.Model(&models.FileExchangeInfo{}).Where("tx_hash = ?", ex.TxHash).Update("err_info", err.Error())
-					continue
-				}
+		e.logger.Info("explorer", "scanning end ", e.currentHeight)
+	}
+	return nil
+}
 
-			case "box-v1":
-				box, err := e.boxDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "boxDecode", err, "txhash", txv.Txid)
-					continue
-				}
+// abortBlock undoes the part of the current block that was already applied, so that a node
+// failure never leaves a block half indexed or an inscription skipped. The next scan starts the block again.
+func (e *Explorer) abortBlock(cause error) error {
+	e.logger.Warn("explorer", "abort block", e.currentHeight, "cause", cause)
 
-				err = e.executeBoxV1(box)
-				if err != nil {
-					e.dbc.DB.Model(&models.BoxInfo{}).Where("tx_hash = ?", box.TxHash).Update("err_info", err.Error())
-					continue
-				}
+	tx := e.dbc.DB.Begin()
+	err := e.fork(tx, e.currentHeight-1)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("scan abortBlock %d err: %s, cause: %s", e.currentHeight, err.Error(), cause.Error())
+	}
 
-			case "cross":
+	err = tx.Commit().Error
+	if err != nil {
+		return fmt.Errorf("scan abortBlock %d commit err: %s, cause: %s", e.currentHeight, err.Error(), cause.Error())
+	}
 
-				cross, err := e.crossDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "crossDecode", err, "txhash", txv.Txid)
-					continue
-				}
+	return fmt.Errorf("scan block %d aborted: %w", e.currentHeight, cause)
+}
 
-				err = e.executeCross(cross)
-				if err != nil {
-					e.dbc.DB.Model(&models.CrossInfo{}).Where("tx_hash = ?", cross.TxHash).Update("err_info", err.Error())
-					continue
-				}
+// decodeTx runs the decoder of protocol p, the decoded inscription is saved as a pending order.
+func (e *Explorer) decodeTx(p string, txv *btcjson.TxRawResult, pushedData []byte, height int64) (interface{}, error) {
+	switch p {
+	case "drc-20":
+		return e.drc20Decode(txv, pushedData, height)
+	case "pair-v1":
+		return e.swapRouterDecode(txv, height)
+	case "wdoge":
+		return e.wdogeDecode(txv, pushedData, height)
+	case "file":
+		return e.fileDecode(txv, height)
+	case "stake-v1":
+		return e.stakeDecode(txv, pushedData, height)
+	case "order-v1":
+		return e.exchangeDecode(txv, pushedData, height)
+	case "order-v2":
+		return e.fileExchangeDecode(txv, pushedData, height)
+	case "box-v1":
+		return e.boxDecode(txv, pushedData, height)
+	case "cross":
+		return e.crossDecode(txv, pushedData, height)
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownProtocol, p)
+	}
+}
 
-			default:
-				log.Error("scanning", "op", "not found", "txhash", txv.Txid)
-			}
+// executeTx verifies and applies a decoded inscription, a failure is stored in the err_info of its order.
+func (e *Explorer) executeTx(txid string, inscription interface{}) error {
+	var err error
+	switch m := inscription.(type) {
+	case *models.Drc20Info:
+		err = e.executeDrc20(m)
+		if err != nil {
+			e.dbc.DB.Model(&models.Drc20Info{}).Where("tx_hash = ?", m.TxHash).Update("err_info", err.Error())
 		}
-
-		block1 := &models.Block{
-			BlockHash:   blockHash.String(),
-			BlockNumber: e.currentHeight,
+	case []*models.SwapInfo:
+		err = e.executePairV1(m)
+		if err != nil {
+			e.dbc.DB.Model(&models.SwapInfo{}).Where("tx_hash = ?", txid).Update("err_info", err.Error())
 		}
-
-		err = e.dbc.DB.Save(block1).Error
+	case *models.WDogeInfo:
+		err = e.executeWdoge(m)
 		if err != nil {
-			return fmt.Errorf("scan SetBlockHash err: %s", err.Error())
+			e.dbc.DB.Model(&models.WDogeInfo{}).Where("tx_hash = ?", m.TxHash).Update("err_info", err.Error())
 		}
-
-		log.Info("explorer", "scanning end ", e.currentHeight)
+	case *models.FileInfo:
+		err = e.executeFile(m)
+		if err != nil {
+			e.dbc.DB.Model(&models.FileInfo{}).Where("tx_hash = ?", m.TxHash).Update("err_info", err.Error())
+		}
+	case *models.StakeInfo:
+		err = e.executeStakeV1(m)
+		if err != nil {
+			e.dbc.DB.Model(&models.StakeInfo{}).Where("tx_hash = ?", m.TxHash).Update("err_info", err.Error())
+		}
+	case *models.ExchangeInfo:
+		err = e.executeOrderV1(m)
+		if err != nil {
+			e.dbc.DB.Model(&models.ExchangeInfo{}).Where("tx_hash = ?", m.TxHash).Update("err_info", err.Error())
+		}
+	case *models.FileExchangeInfo:
+		err = e.executeOrderV2(m)
+		if err != nil {
+			e.dbc.DB.Model(&models.FileExchangeInfo{}).Where("tx_hash = ?", m.TxHash).Update("err_info", err.Error())
+		}
+	case *models.BoxInfo:
+		err = e.executeBoxV1(m)
+		if err != nil {
+			e.dbc.DB.Model(&models.BoxInfo{}).Where("tx_hash = ?", m.TxHash).Update("err_info", err.Error())
+		}
+	case *models.CrossInfo:
+		err = e.executeCross(m)
+		if err != nil {
+			e.dbc.DB.Model(&models.CrossInfo{}).Where("tx_hash = ?", m.TxHash).Update("err_info", err.Error())
+		}
+	default:
+		err = fmt.Errorf("executeTx unknown inscription %T", inscription)
 	}
-	return nil
+	return err
 }
 
 func (e *Explorer) executeDrc20(drc20 *models.Drc20Info) error {
@@ -304,6 +460,13 @@ func (e *Explorer) executeDrc20(drc20 *models.Drc20Info) error {
 		}
 	}
 
+	if drc20.Op == "burn" {
+		err = e.drc20Burn(drc20)
+		if err != nil {
+			return fmt.Errorf("drc20Burn err: %s", err.Error())
+		}
+	}
+
 	return nil
 }
 
@@ -640,6 +803,11 @@ func (e *Explorer) executePairV1(swaps []*models.SwapInfo) error {
 
 func (e *Explorer) executeCross(cross *models.CrossInfo) error {
 
+	// the deposit id of a mint only counts from the activation, older mints are minted at once
+	if cross.Op == "mint" && !e.verify.CrossQueueActive(cross.BlockNumber) {
+		cross.DepositId = ""
+	}
+
 	err := e.verify.VerifyCross(cross)
 	if err != nil {
 		return fmt.Errorf("VerifyCross err: %s", err.Error())
@@ -666,5 +834,19 @@ func (e *Explorer) executeCross(cross *models.CrossInfo) error {
 		}
 	}
 
+	if cross.Op == "release" {
+		err = e.crossRelease(cross)
+		if err != nil {
+			return fmt.Errorf("crossRelease err: %s", err.Error())
+		}
+	}
+
+	if cross.Op == "set-admin" || cross.Op == "set-threshold" {
+		err = e.crossSetAdmin(cross)
+		if err != nil {
+			return fmt.Errorf("crossSetAdmin err: %s", err.Error())
+		}
+	}
+
 	return nil
 }
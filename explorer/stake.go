@@ -1,16 +1,13 @@
 package explorer
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/btcutil"
 	"github.com/dogecoinw/doged/chaincfg"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/google/uuid"
 	"github.com/unielon-org/unielon-indexer/models"
-	"github.com/unielon-org/unielon-indexer/utils"
 	"gorm.io/gorm"
 )
 
@@ -21,19 +18,10 @@ func (e Explorer) stakeDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 		return nil, fmt.Errorf("stake already exist or err %s", tx.Txid)
 	}
 
-	param := &models.StakeInscription{}
-	err = json.Unmarshal(pushedData, param)
+	reveal, _ := e.reveal(tx)
+	stake, err := DecodeStake(pushedData, reveal)
 	if err != nil {
-		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
-	}
-
-	stake, err := utils.ConvertStake(param)
-	if err != nil {
-		return nil, fmt.Errorf("ConvertWDoge err: %s", err.Error())
-	}
-
-	if len(tx.Vout) < 1 {
-		return nil, fmt.Errorf("op error, vout length is not 0")
+		return nil, err
 	}
 
 	stake.OrderId = uuid.New().String()
@@ -43,24 +31,6 @@ func (e Explorer) stakeDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 	stake.BlockNumber = number
 	stake.OrderStatus = 1
 
-	stake.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
-
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
-	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
-	}
-
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
-	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
-	}
-
-	if stake.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
-		return nil, fmt.Errorf("The address is not the same as the previous transaction")
-	}
-
 	err = e.dbc.DB.Save(stake).Error
 	if err != nil {
 		return nil, fmt.Errorf("SaveStake err: %s", err.Error())
@@ -0,0 +1,107 @@
+package explorer
+
+import (
+	"testing"
+
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/utils"
+)
+
+func TestSwapFeeActivation(t *testing.T) {
+	cases := []struct {
+		name       string
+		activation int64
+		fee        interface{}
+		want       int
+		decoded    bool
+	}{
+		{"legacy float", 0, 1.5, 30, true},
+		{"legacy string", 5, "tier", 30, true},
+		{"string", 1, "100", 100, true},
+		{"number", 1, 100, 100, true},
+		{"missing", 1, nil, 30, true},
+		{"float", 1, 1.5, 0, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tc := newTestChain()
+			create := tc.inscribe(t, "swap-create", testHolder0, map[string]interface{}{"p": "pair-v1", "op": "create", "tick0": "AAAA", "tick1": "BBBB", "amt0": "1000000000000", "amt1": "2000000000000", "amt0_min": "0", "amt1_min": "0", "fee": c.fee})
+			tc.mine(create)
+
+			e := newTestExplorer(t, tc, utils.ActivationConfig{SwapFee: c.activation})
+			if err := e.scan(); err != nil {
+				t.Fatal(err)
+			}
+
+			pool := &models.SwapLiquidity{}
+			err := e.dbc.DB.Where("tick0 = ? and tick1 = ?", "AAAA", "BBBB").First(pool).Error
+			if !c.decoded {
+				if err == nil {
+					t.Fatalf("pool created with fee %d", pool.Fee)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pool.Fee != c.want {
+				t.Fatalf("pool fee %d, want %d", pool.Fee, c.want)
+			}
+		})
+	}
+}
+
+// TestSwapAddLiquidity checks that an add records the liquidity it minted, that verify-block agrees with
+// the stored row and that indexing the chain again stores the same liquidity.
+func TestSwapAddLiquidity(t *testing.T) {
+	tc := newTestChain()
+	create := tc.inscribe(t, "swap-create", testHolder0, map[string]interface{}{"p": "pair-v1", "op": "create", "tick0": "AAAA", "tick1": "BBBB", "amt0": "1000000000000", "amt1": "2000000000000", "amt0_min": "0", "amt1_min": "0"})
+	tc.mine(create)
+	add := tc.inscribe(t, "swap-add", testHolder1, map[string]interface{}{"p": "pair-v1", "op": "add", "tick0": "AAAA", "tick1": "BBBB", "amt0": "100000000000", "amt1": "200000000000", "amt0_min": "0", "amt1_min": "0"})
+	tc.mine(add)
+
+	e := newTestExplorer(t, tc, utils.ActivationConfig{})
+	if err := e.scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	liquidity := func(e *Explorer) string {
+		t.Helper()
+		info := &models.SwapInfo{}
+		if err := e.dbc.DB.Where("tx_hash = ?", add).First(info).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		minted := &models.Drc20Revert{}
+		if err := e.dbc.DB.Where("tx_hash = ? AND from_address = '' AND to_address = ?", add, testHolder1).First(minted).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		if info.OrderStatus != 0 || info.Liquidity.String() != minted.Amt.String() {
+			t.Fatalf("add liquidity %s, minted %s, status %d err %s", info.Liquidity, minted.Amt, info.OrderStatus, info.ErrInfo)
+		}
+		return info.Liquidity.String()
+	}
+	before := liquidity(e)
+
+	for height := int64(1); height < int64(len(tc.blocks)); height++ {
+		diffs, err := e.VerifyBlock(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, diff := range diffs {
+			t.Errorf("block %d: %s", height, diff)
+		}
+	}
+
+	// the rollback of a reindex runs statements sqlite does not take, a fresh index executes the blocks the same way
+	again := newTestExplorer(t, tc, utils.ActivationConfig{})
+	if err := again.scan(); err != nil {
+		t.Fatal(err)
+	}
+	if after := liquidity(again); after != before {
+		t.Fatalf("reindexed add liquidity %s, was %s", after, before)
+	}
+}
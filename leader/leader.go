@@ -0,0 +1,199 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"gorm.io/gorm"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrNotLeader is returned by Fence when the lease is held by another replica or expired.
+var ErrNotLeader = errors.New("the leader lease is not held")
+
+const (
+	DefaultName = "explorer"
+	DefaultTtl  = 15 * time.Second
+)
+
+// Elector keeps a database lease so that only one replica runs the explorer.
+// The lease times are taken from the database clock. The leader renews the lease every ttl/3 and steps down
+// on its own when it could not renew for ttl/2, counted from before the last renewal was sent, so it has
+// stopped well before the lease expires. Fence checks the lease again in the transaction that commits a block.
+type Elector struct {
+	dbc  *storage.DBClient
+	name string
+	id   string
+	ttl  time.Duration
+
+	lock    *sync.RWMutex
+	leading bool
+}
+
+func NewElector(dbc *storage.DBClient, name, id string, ttl time.Duration) *Elector {
+	if name == "" {
+		name = DefaultName
+	}
+
+	if id == "" {
+		id = DefaultId()
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultTtl
+	}
+
+	return &Elector{
+		dbc:  dbc,
+		name: name,
+		id:   id,
+		ttl:  ttl,
+		lock: &sync.RWMutex{},
+	}
+}
+
+// DefaultId identifies this process as hostname-pid.
+func DefaultId() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func (el *Elector) Id() string {
+	return el.id
+}
+
+// IsLeader reports whether this replica holds the lease.
+func (el *Elector) IsLeader() bool {
+	el.lock.RLock()
+	defer el.lock.RUnlock()
+	return el.leading
+}
+
+func (el *Elector) setLeading(leading bool) {
+	el.lock.Lock()
+	defer el.lock.Unlock()
+	el.leading = leading
+}
+
+// Run competes for the lease until ctx is done. lead is started every time the lease is won and
+// its context is cancelled when the lease is lost, Run waits for it to return before competing again.
+func (el *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	ticker := time.NewTicker(el.ttl / 3)
+	defer ticker.Stop()
+
+	deadline := time.NewTimer(el.ttl)
+	deadline.Stop()
+	defer deadline.Stop()
+
+	var current *term
+
+	stepDown := func(reason string) {
+		if current == nil {
+			return
+		}
+
+		deadline.Stop()
+		log.Warn("leader", "step down", el.name, "id", el.id, "reason", reason)
+		current.stop()
+		current = nil
+		el.setLeading(false)
+	}
+
+	for {
+		sent := time.Now()
+		ok, err := el.dbc.AcquireLease(el.name, el.id, el.ttl)
+		switch {
+		case err != nil:
+			log.Error("leader", "AcquireLease", err)
+
+		case ok:
+			// the lease runs until at least sent + ttl, leadership ends at sent + ttl/2
+			deadline.Stop()
+			select {
+			case <-deadline.C:
+			default:
+			}
+			deadline.Reset(time.Until(sent.Add(el.ttl / 2)))
+
+			if current == nil {
+				log.Info("leader", "elected", el.name, "id", el.id)
+				el.setLeading(true)
+				current = startTerm(ctx, lead)
+			}
+
+		default:
+			stepDown("lease taken by another replica")
+		}
+
+		var done chan struct{}
+		if current != nil {
+			done = current.done
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			stepDown("lease not renewed in time")
+		case <-done:
+			// lead returned on its own, give the lease up so another replica can try
+			stepDown("lead returned")
+			el.release()
+		case <-ctx.Done():
+			stepDown("shutdown")
+			el.release()
+			return
+		}
+	}
+}
+
+// Fence fails when this replica does not hold the lease, it is called in the transaction that commits a
+// block so that a replica that lost the lease cannot commit one.
+func (el *Elector) Fence(tx *gorm.DB) error {
+	ok, err := el.dbc.HoldsLease(tx, el.name, el.id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+// term is one period of leadership.
+type term struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startTerm(ctx context.Context, lead func(ctx context.Context)) *term {
+	leadCtx, cancel := context.WithCancel(ctx)
+	t := &term{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(t.done)
+		lead(leadCtx)
+	}()
+	return t
+}
+
+// stop cancels the term and waits until lead has returned.
+func (t *term) stop() {
+	t.cancel()
+	<-t.done
+}
+
+func (el *Elector) release() {
+	if err := el.dbc.ReleaseLease(el.name, el.id); err != nil {
+		log.Error("leader", "ReleaseLease", err)
+	}
+}
@@ -0,0 +1,108 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultTimeout = 30 * time.Second
+)
+
+type step struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Manager stops the registered components in registration order when the process is asked to exit.
+type Manager struct {
+	timeout time.Duration
+	steps   []*step
+	once    *sync.Once
+}
+
+func NewManager(timeout time.Duration) *Manager {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Manager{
+		timeout: timeout,
+		once:    &sync.Once{},
+	}
+}
+
+// Add registers a component, stop must return once the component is stopped or ctx is done.
+func (m *Manager) Add(name string, stop func(ctx context.Context) error) {
+	m.steps = append(m.steps, &step{name: name, stop: stop})
+}
+
+// Wait blocks until SIGINT or SIGTERM is received or a component reports a fatal error on errc.
+func (m *Manager) Wait(errc <-chan error) error {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(c)
+
+	select {
+	case sig := <-c:
+		log.Warn("lifecycle", "signal", sig.String())
+		return nil
+	case err := <-errc:
+		log.Error("lifecycle", "fatal", err)
+		return err
+	}
+}
+
+// Shutdown stops every component in order within the timeout and returns the first error.
+// Components after a failed one are still stopped.
+func (m *Manager) Shutdown() error {
+	var first error
+	m.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		defer cancel()
+
+		start := time.Now()
+		for i, s := range m.steps {
+			log.Info("lifecycle", "stopping", s.name, "step", fmt.Sprintf("%d/%d", i+1, len(m.steps)))
+
+			begin := time.Now()
+			err := s.stop(ctx)
+			if err != nil {
+				log.Error("lifecycle", "stop", s.name, "err", err)
+				if first == nil {
+					first = fmt.Errorf("stop %s err: %s", s.name, err.Error())
+				}
+				continue
+			}
+
+			log.Info("lifecycle", "stopped", s.name, "elapsed", time.Since(begin))
+		}
+
+		log.Info("lifecycle", "shutdown", "done", "elapsed", time.Since(start))
+	})
+	return first
+}
+
+// WaitGroup adapts a sync.WaitGroup to a stop function that honours the shutdown timeout.
+func WaitGroup(wg *sync.WaitGroup) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatTerminal = "terminal"
+	FormatJson     = "json"
+)
+
+// Setup sends every record to stderr in cfg.Format. A record is kept when its level is within the
+// level of its module, the first word of the message like "explorer" or "chain", or within level
+// for modules cfg.Modules does not name.
+func Setup(cfg utils.LogConfig, level int) error {
+	modules, err := ParseModules(cfg.Modules)
+	if err != nil {
+		return err
+	}
+
+	var format log.Format
+	switch cfg.Format {
+	case FormatJson:
+		format = log.JSONFormat()
+	case FormatTerminal, "":
+		format = log.TerminalFormat(true)
+	default:
+		return fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	def := log.Lvl(level)
+	handler := log.FilterHandler(func(r *log.Record) bool {
+		if lvl, ok := modules[Module(r.Msg)]; ok {
+			return r.Lvl <= lvl
+		}
+		return r.Lvl <= def
+	}, log.StreamHandler(os.Stderr, format))
+
+	log.Root().SetHandler(handler)
+	return nil
+}
+
+// Module is the module a record belongs to, by convention the first word of its message.
+func Module(msg string) string {
+	if i := strings.IndexByte(msg, ' '); i >= 0 {
+		return msg[:i]
+	}
+	return msg
+}
+
+// ParseModules reads levels like "explorer=debug,chain=2", a level is a name or a number from 0 (crit) to 5 (trace).
+func ParseModules(spec string) (map[string]log.Lvl, error) {
+	modules := make(map[string]log.Lvl)
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("log module rule %q must be module=level", rule)
+		}
+
+		lvl, err := ParseLevel(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		modules[strings.TrimSpace(parts[0])] = lvl
+	}
+	return modules, nil
+}
+
+func ParseLevel(raw string) (log.Lvl, error) {
+	raw = strings.TrimSpace(raw)
+	if n, err := strconv.Atoi(raw); err == nil {
+		if n < int(log.LvlCrit) || n > int(log.LvlTrace) {
+			return 0, fmt.Errorf("log level %d must be between %d and %d", n, log.LvlCrit, log.LvlTrace)
+		}
+		return log.Lvl(n), nil
+	}
+
+	lvl, err := log.LvlFromString(strings.ToLower(raw))
+	if err != nil {
+		return 0, fmt.Errorf("unknown log level %q", raw)
+	}
+	return lvl, nil
+}
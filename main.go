@@ -2,23 +2,27 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/dogecoinw/go-dogecoin/log"
 	"github.com/gin-gonic/gin"
 	shell "github.com/ipfs/go-ipfs-api"
 	"github.com/unielon-org/unielon-indexer/config"
 	"github.com/unielon-org/unielon-indexer/explorer"
+	"github.com/unielon-org/unielon-indexer/leader"
+	"github.com/unielon-org/unielon-indexer/lifecycle"
+	"github.com/unielon-org/unielon-indexer/oracle"
 	"github.com/unielon-org/unielon-indexer/router"
 	"github.com/unielon-org/unielon-indexer/router_v3"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/storage_v3"
 	"github.com/unielon-org/unielon-indexer/verifys"
+	"net/http"
 	_ "net/http/pprof"
 	"os"
-	"os/signal"
 	"sync"
-	"syscall"
+	"time"
 )
 
 var (
@@ -27,67 +31,89 @@ var (
 
 func main() {
 
-	// Load configuration file
-	config.LoadConfig(&cfg, "")
+	if len(os.Args) > 1 {
+		if _, ok := commands[os.Args[1]]; ok {
+			os.Exit(runCommand(os.Args[1], os.Args[2:]))
+		}
+	}
 
-	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(true)))
-	glogger.Verbosity(log.Lvl(cfg.DebugLevel))
-	log.Root().SetHandler(glogger)
+	// Load configuration: defaults, file, environment, then flags
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	loader := config.NewLoader(fs)
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	if code, ok := loadConfig(loader, fs.Arg(0)); !ok {
+		os.Exit(code)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	wg := &sync.WaitGroup{}
 
-	mysqlClient := storage_v3.NewSqliteClient(cfg.Sqlite)
-
-	var dbClient *storage.DBClient
-	if cfg.Sqlite.Switch {
-		dbClient = storage.NewSqliteClient(cfg.Sqlite)
-	} else {
-		dbClient = storage.NewMysqlClient(cfg.Mysql)
+	mysqlClient, err := storage_v3.NewSqliteClient(cfg.Sqlite)
+	if err != nil {
+		log.Error("main", "database", err)
+		os.Exit(1)
 	}
 
-	connCfg := &rpcclient.ConnConfig{
-		Host:         cfg.Chain.Rpc,
-		Endpoint:     "ws",
-		User:         cfg.Chain.UserName,
-		Pass:         cfg.Chain.PassWord,
-		HTTPPostMode: true, // Bitcoin core only supports HTTP POST mode
-		DisableTLS:   true, // Bitcoin core does not provide TLS by default
+	dbClient, err := newDBClient()
+	if err != nil {
+		log.Error("main", "database", err)
+		os.Exit(1)
 	}
 
-	// Notice the notification parameter is nil since notifications are
-	// not supported in HTTP POST mode.
-	rpcClient, _ := rpcclient.New(connCfg, nil)
+	rpcClient, err := newRpcClient()
+	if err != nil {
+		log.Error("main", "rpc", err)
+		os.Exit(1)
+	}
+	rpcClient.SetContext(ctx)
 
-	verify := verifys.NewVerifys(dbClient)
+	verify := verifys.NewVerifys(dbClient, cfg.Activation, cfg.Swap)
 
 	ipfs := shell.NewShell(cfg.Ipfs)
 
+	prices, err := oracle.New(cfg.PriceOracle)
+	if err != nil {
+		log.Error("main", "price oracle", err)
+		os.Exit(1)
+	}
+
+	var exp *explorer.Explorer
 	if cfg.Explorer.Switch {
-		exp := explorer.NewExplorer(ctx, wg, rpcClient, dbClient, ipfs, cfg.Explorer.FromBlock)
-		wg.Add(1)
-		go exp.Start()
+		exp = explorer.NewExplorer(ctx, wg, rpcClient, dbClient, ipfs, verify, cfg.Explorer.FromBlock)
+		exp.SetPriceOracle(prices)
 	}
 
+	var srv *http.Server
+	var levelClient *storage.LevelDB
+	fail := make(chan error, 1)
 	if cfg.HttpServer.Switch {
 
-		levelClient := storage.NewLevelDB(cfg.LevelDB)
+		levelClient = storage.NewLevelDB(cfg.LevelDB)
 
-		grt := gin.Default()
-		grt.Use(func(c *gin.Context) {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")                                               // 允许所有来源访问
-			c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, DELETE")                // 允许的请求方法
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept") // 允许的请求头部信息
-			c.Writer.Header().Set("Access-Control-Max-Age", "3600")                                                 // 预检请求的有效期，单位为秒
+		gin.SetMode(gin.ReleaseMode)
+		grt := gin.New()
+		grt.Use(router.RequestLog(), gin.Recovery())
+		grt.Use(router.Cors(cfg.HttpServer.Cors, cfg.HttpServer.Auth.Header))
+		grt.Use(router.NewGuard(dbClient, cfg.HttpServer).Handler())
 
-			if c.Request.Method == "OPTIONS" {
-				c.AbortWithStatus(200)
-				return
+		var cache *router.Cache
+		if cfg.HttpServer.Cache.Switch {
+			cache = router.NewCache(dbClient, levelClient, cfg.HttpServer.Cache)
+			if exp != nil {
+				exp.OnBlock(cache.OnBlock)
 			}
-			c.Next()
-		})
+			grt.Use(cache.Handler())
+			grt.POST("/v4/info/cache", cache.Stats)
+		}
+
+		healthRouter := router.NewHealthRouter(dbClient, rpcClient, exp, cfg.HttpServer.ReadyMaxLag)
+		grt.GET("/healthz", healthRouter.Healthz)
+		grt.GET("/readyz", healthRouter.Readyz)
 
-		rt := router_v3.NewRouter(mysqlClient, dbClient, levelClient, rpcClient, ipfs)
+		rt := router_v3.NewRouter(mysqlClient, dbClient, levelClient, rpcClient, ipfs, verify)
 
 		grt.POST("/v3/info/lastnumber", rt.LastNumber)
 
@@ -162,26 +188,36 @@ func main() {
 
 		// v4
 		v4 := grt.Group("/v4")
+		v4.Use(router.NewDecimal(dbClient).Handler())
 		{
 
 			infoRouter := router.NewInfoRouter(dbClient, rpcClient, levelClient, ipfs, verify)
 			v4.POST("/info/lastnumber", infoRouter.LastNumber)
+			v4.POST("/info/blocknumber", infoRouter.BlockNumber)
 
 			drc20Router := router.NewDrc20Router(dbClient, rpcClient, levelClient, ipfs, verify)
+			drc20Router.SetCache(cache)
 			v4.POST("/drc20/order", drc20Router.Order)
 			v4.POST("/drc20/collect", drc20Router.Collect)
 			v4.POST("/drc20/collect-address", drc20Router.CollectAddress)
+			v4.POST("/drc20/burns", drc20Router.Burns)
+			v4.POST("/drc20/meta", drc20Router.Meta)
+			v4.POST("/drc20/meta/pending", drc20Router.MetaPending)
+			v4.POST("/drc20/meta/review", drc20Router.MetaReview)
+			v4.POST("/drc20/meta/history", drc20Router.MetaHistory)
 
 			swapRouter := router.NewSwapRouter(dbClient, rpcClient, verify)
 			v4.POST("/swap/order", swapRouter.Order)
 			v4.POST("/swap/liquidity", swapRouter.SwapLiquidity)
 			v4.POST("/swap/liquidity/address", swapRouter.SwapLiquidityHolder)
+			v4.POST("/swap/position", swapRouter.Position)
 			v4.POST("/swap/price", swapRouter.SwapPrice)
 			v4.POST("/swap/k", swapRouter.SwapK)
 			v4.POST("/swap/tvl", swapRouter.SwapTvl)
 			v4.POST("/swap/tvl/total", swapRouter.SwapSummaryTvlTotal)
 			v4.POST("/swap/summary", swapRouter.SwapSummary)
 			v4.POST("/swap/pair", swapRouter.SwapPair)
+			v4.POST("/swap/quote", swapRouter.Quote)
 
 			// exchange
 			exchangeRouter := router.NewExchangeRouter(dbClient, rpcClient, verify)
@@ -191,6 +227,8 @@ func main() {
 			v4.POST("/exchange/summary", exchangeRouter.Summary)
 			v4.POST("/exchange/summary/total", exchangeRouter.SummaryTotal)
 			v4.POST("/exchange/k", exchangeRouter.SummaryK)
+			v4.POST("/exchange/orderbook", exchangeRouter.OrderBook)
+			v4.POST("/exchange/best", exchangeRouter.Best)
 
 			// box
 			boxRouter := router.NewBoxRouter(dbClient, rpcClient, verify)
@@ -240,20 +278,90 @@ func main() {
 			crossRouter := router.NewCrossRouter(dbClient, rpcClient, verify)
 			v4.POST("/cross/order", crossRouter.Order)
 			v4.POST("/cross/collect", crossRouter.Collect)
+			v4.POST("/cross/withdrawals", crossRouter.Withdrawals)
+			v4.POST("/cross/deposits", crossRouter.Deposits)
+			v4.POST("/cross/admin", crossRouter.Admin)
+			v4.POST("/cross/approvals", crossRouter.Approvals)
+
+			// rejected inscriptions
+			rejectedRouter := router.NewRejectedRouter(dbClient)
+			v4.POST("/rejected/order", rejectedRouter.Order)
+
+			// tx
+			txRouter := router.NewTxRouter(dbClient, rpcClient, verify)
+			v4.POST("/tx/validate", txRouter.Validate)
+			v4.POST("/tx/broadcast", txRouter.Broadcast)
+			v4.POST("/tx/build", txRouter.Build)
 		}
 
-		err := grt.Run(cfg.HttpServer.Server)
-		if err != nil {
-			panic(err)
+		srv = &http.Server{
+			Addr:    cfg.HttpServer.Server,
+			Handler: grt,
 		}
+
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fail <- fmt.Errorf("http ListenAndServe err: %s", err.Error())
+			}
+		}()
+	}
+
+	if exp != nil {
+		wg.Add(1)
+		if cfg.Explorer.Leader.Switch {
+			// replicas sharing the database only scan while they hold the lease
+			leaderCfg := cfg.Explorer.Leader
+			elector := leader.NewElector(dbClient, leaderCfg.Name, leaderCfg.Id, time.Duration(leaderCfg.Ttl)*time.Second)
+			log.Info("main", "leader election", leaderCfg.Name, "id", elector.Id())
+			exp.SetFence(elector.Fence)
+			go func() {
+				defer wg.Done()
+				elector.Run(ctx, exp.Lead)
+			}()
+		} else {
+			go exp.Start()
+		}
+	}
+
+	// Components stop in this order: no new requests, the explorer finishes or rolls back its current block,
+	// then the storage and the node connection are closed. The storage stays open while the explorer has not
+	// returned, it may still be writing.
+	lm := lifecycle.NewManager(time.Duration(cfg.ShutdownTimeout) * time.Second)
+	if srv != nil {
+		lm.Add("http", srv.Shutdown)
 	}
 
-	c := make(chan os.Signal)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-c
-		fmt.Println("\nReceived an interrupt, stopping services...")
+	explorerStopped := false
+	lm.Add("explorer", func(ctx context.Context) error {
 		cancel()
-	}()
-	wg.Wait()
+		err := lifecycle.WaitGroup(wg)(ctx)
+		explorerStopped = err == nil
+		return err
+	})
+
+	lm.Add("database", func(ctx context.Context) error {
+		if !explorerStopped {
+			return fmt.Errorf("the explorer is still running, the database is left open")
+		}
+		dbClient.Stop()
+		mysqlClient.Stop()
+		return nil
+	})
+
+	if levelClient != nil {
+		lm.Add("leveldb", func(ctx context.Context) error {
+			levelClient.Stop()
+			return nil
+		})
+	}
+
+	lm.Add("rpc", func(ctx context.Context) error {
+		rpcClient.Shutdown()
+		return nil
+	})
+
+	waitErr := lm.Wait(fail)
+	if err := lm.Shutdown(); err != nil || waitErr != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,22 @@
+package models
+
+const (
+	ApiKeyStatusActive  = 0
+	ApiKeyStatusRevoked = 1
+)
+
+// ApiKey identifies a caller of the http api, Tier selects its rate limits and Admin allows moderation.
+type ApiKey struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	Key        string    `gorm:"size:128;uniqueIndex" json:"key"`
+	Name       string    `gorm:"size:64" json:"name"`
+	Tier       string    `gorm:"size:32" json:"tier"`
+	Admin      bool      `json:"admin"`
+	Status     int64     `json:"status"`
+	UpdateDate LocalTime `gorm:"type:datetime" json:"update_date"`
+	CreateDate LocalTime `gorm:"type:datetime" json:"create_date"`
+}
+
+func (ApiKey) TableName() string {
+	return "api_keys"
+}
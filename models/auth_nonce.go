@@ -0,0 +1,15 @@
+package models
+
+// AuthNonce is a nonce already used by a signed request of Address, kept until Expiry to reject replays.
+type AuthNonce struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	Address    string    `gorm:"size:64;uniqueIndex:idx_auth_nonce" json:"address"`
+	Nonce      string    `gorm:"size:64;uniqueIndex:idx_auth_nonce" json:"nonce"`
+	Action     string    `gorm:"size:32" json:"action"`
+	Expiry     int64     `gorm:"index" json:"expiry"`
+	CreateDate LocalTime `gorm:"type:datetime" json:"create_date"`
+}
+
+func (AuthNonce) TableName() string {
+	return "auth_nonce"
+}
@@ -3,6 +3,7 @@ package models
 type Block struct {
 	BlockNumber int64  `gorm:"primarykey" json:"block_number"`
 	BlockHash   string `json:"block_hash"`
+	BlockTime   int64  `json:"block_time"`
 }
 
 func (Block) TableName() string {
@@ -11,6 +11,11 @@ type CrossInfo struct {
 	AdminAddress  string    `json:"admin_address"`
 	HolderAddress string    `json:"holder_address"`
 	ToAddress     string    `json:"to_address"`
+	DepositId     string    `json:"deposit_id"`
+	BurnTxHash    string    `json:"burn_tx_hash"`
+	ExtTxId       string    `json:"ext_tx_id"`
+	Admins        string    `json:"admins"`
+	Threshold     int       `json:"threshold"`
 	FeeAddress    string    `json:"fee_address"`
 	FeeTxHash     string    `json:"fee_tx_hash"`
 	TxHash        string    `json:"tx_hash"`
@@ -26,10 +31,16 @@ func (CrossInfo) TableName() string {
 	return "cross_info"
 }
 
+// CrossCollect is a wrapped token, admins is the comma separated admin set, only admin_address when it is
+// empty, and threshold the number of them that approve a mint or a change of the set. Nonce counts the
+// changes, approvals of a change are for the current nonce.
 type CrossCollect struct {
 	ID            uint      `gorm:"primarykey" json:"id"`
 	Tick          string    `json:"tick"`
 	AdminAddress  string    `json:"admin_address"`
+	Admins        string    `json:"admins"`
+	Threshold     int       `gorm:"default:1" json:"threshold"`
+	Nonce         int64     `gorm:"default:0" json:"nonce"`
 	HolderAddress string    `json:"holder_address"`
 	UpdateDate    LocalTime `json:"update_date"`
 	CreateDate    LocalTime `json:"create_date"`
@@ -39,19 +50,94 @@ func (CrossCollect) TableName() string {
 	return "cross_collect"
 }
 
+// CrossRevert undoes a cross op on a fork, an "admin" revert holds the admin set, threshold and nonce
+// of the token before the change.
 type CrossRevert struct {
-	ID          uint      `gorm:"primarykey" json:"id"`
-	Op          string    `json:"op"`
-	Tick        string    `json:"tick"`
-	BlockNumber int64     `json:"block_number"`
-	UpdateDate  LocalTime `json:"update_date"`
-	CreateDate  LocalTime `json:"create_date"`
+	ID           uint      `gorm:"primarykey" json:"id"`
+	Op           string    `json:"op"`
+	Tick         string    `json:"tick"`
+	TxHash       string    `json:"tx_hash"`
+	AdminAddress string    `json:"admin_address"`
+	Admins       string    `json:"admins"`
+	Threshold    int       `json:"threshold"`
+	Nonce        int64     `json:"nonce"`
+	BlockNumber  int64     `json:"block_number"`
+	UpdateDate   LocalTime `json:"update_date"`
+	CreateDate   LocalTime `json:"create_date"`
 }
 
 func (CrossRevert) TableName() string {
 	return "cross_revert"
 }
 
+// CrossDeposit is a mint of a deposit on another chain, deposit id is the id of the deposit there and
+// can be minted once per tick.
+type CrossDeposit struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	Tick         string    `gorm:"size:64;uniqueIndex:idx_cross_deposit" json:"tick"`
+	DepositId    string    `gorm:"size:128;uniqueIndex:idx_cross_deposit" json:"deposit_id"`
+	Chain        string    `json:"chain"`
+	Amt          *Number   `json:"amt"`
+	ToAddress    string    `json:"to_address"`
+	AdminAddress string    `json:"admin_address"`
+	TxHash       string    `gorm:"size:64;index" json:"tx_hash"`
+	BlockNumber  int64     `json:"block_number"`
+	UpdateDate   LocalTime `json:"update_date"`
+	CreateDate   LocalTime `json:"create_date"`
+}
+
+func (CrossDeposit) TableName() string {
+	return "cross_deposit"
+}
+
+const (
+	CrossWithdrawalPending  = "pending"
+	CrossWithdrawalReleased = "released"
+)
+
+// CrossWithdrawal is a burn to be released on chain to to_address, the admin acknowledges the release
+// with the id of the transaction there.
+type CrossWithdrawal struct {
+	ID                 uint      `gorm:"primarykey" json:"id"`
+	Tick               string    `gorm:"size:64;index" json:"tick"`
+	Chain              string    `json:"chain"`
+	Amt                *Number   `json:"amt"`
+	HolderAddress      string    `json:"holder_address"`
+	ToAddress          string    `json:"to_address"`
+	BurnTxHash         string    `gorm:"size:64;uniqueIndex" json:"burn_tx_hash"`
+	BlockNumber        int64     `json:"block_number"`
+	Status             string    `gorm:"size:16;default:pending" json:"status"`
+	ExtTxId            string    `json:"ext_tx_id"`
+	ReleaseAddress     string    `json:"release_address"`
+	ReleaseTxHash      string    `gorm:"size:64;index" json:"release_tx_hash"`
+	ReleaseBlockNumber int64     `json:"release_block_number"`
+	UpdateDate         LocalTime `json:"update_date"`
+	CreateDate         LocalTime `json:"create_date"`
+}
+
+func (CrossWithdrawal) TableName() string {
+	return "cross_withdrawal"
+}
+
+// CrossApproval is the approval of an admin for a mint of deposit id ref, or for a change of the admin set
+// or threshold at nonce ref. Content is what is approved, the op runs once threshold admins approved the same.
+type CrossApproval struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	Tick         string    `gorm:"size:64;index:idx_cross_approval" json:"tick"`
+	Op           string    `gorm:"size:16;index:idx_cross_approval" json:"op"`
+	Ref          string    `gorm:"size:128;index:idx_cross_approval" json:"ref"`
+	Content      string    `json:"content"`
+	AdminAddress string    `json:"admin_address"`
+	TxHash       string    `gorm:"size:64;index" json:"tx_hash"`
+	BlockNumber  int64     `json:"block_number"`
+	UpdateDate   LocalTime `json:"update_date"`
+	CreateDate   LocalTime `json:"create_date"`
+}
+
+func (CrossApproval) TableName() string {
+	return "cross_approval"
+}
+
 type CrossBotInfo struct {
 	ID              uint      `gorm:"primarykey" json:"id"`
 	Amt             *Number   `json:"amt"`
@@ -156,3 +156,18 @@ type Drc20CollectCache struct {
 	Total       int64
 	CacheNumber int64
 }
+
+// Drc20Burn is a burn op of a holder, the burned amount no longer counts towards max.
+type Drc20Burn struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	Tick          string    `gorm:"size:64;index" json:"tick"`
+	HolderAddress string    `gorm:"size:64;index" json:"holder_address"`
+	Amt           *Number   `gorm:"size:64" json:"amt"`
+	TxHash        string    `gorm:"size:64;uniqueIndex" json:"tx_hash"`
+	BlockNumber   int64     `gorm:"index" json:"block_number"`
+	CreateDate    LocalTime `gorm:"type:datetime" json:"create_date"`
+}
+
+func (Drc20Burn) TableName() string {
+	return "drc20_burn"
+}
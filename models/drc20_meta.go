@@ -0,0 +1,57 @@
+package models
+
+const (
+	MetaStatusPending    = 0
+	MetaStatusApproved   = 1
+	MetaStatusRejected   = 2
+	MetaStatusSuperseded = 3
+)
+
+const (
+	MetaActionSubmit  = "submit"
+	MetaActionApprove = "approve"
+	MetaActionReject  = "reject"
+	MetaActionRevoke  = "revoke"
+)
+
+// Drc20Meta is token metadata submitted by the deployer, it is copied to drc20_collect once approved.
+type Drc20Meta struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	Tick          string    `gorm:"size:64;index" json:"tick"`
+	HolderAddress string    `gorm:"size:64" json:"holder_address"`
+	Logo          string    `gorm:"type:text" json:"logo"`
+	Introduction  string    `gorm:"type:text" json:"introduction"`
+	WhitePaper    string    `gorm:"size:255" json:"white_paper"`
+	Official      string    `gorm:"size:255" json:"official"`
+	Telegram      string    `gorm:"size:255" json:"telegram"`
+	Discorad      string    `gorm:"size:255" json:"discorad"`
+	Twitter       string    `gorm:"size:255" json:"twitter"`
+	Facebook      string    `gorm:"size:255" json:"facebook"`
+	Github        string    `gorm:"size:255" json:"github"`
+	Status        int64     `gorm:"index" json:"status"`
+	Reviewer      string    `gorm:"size:64" json:"reviewer"`
+	Reason        string    `gorm:"size:255" json:"reason"`
+	UpdateDate    LocalTime `gorm:"type:datetime" json:"update_date"`
+	CreateDate    LocalTime `gorm:"type:datetime" json:"create_date"`
+}
+
+func (Drc20Meta) TableName() string {
+	return "drc20_meta"
+}
+
+// Drc20MetaHistory records every submission and review of token metadata, Before and After are json.
+type Drc20MetaHistory struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	Tick       string    `gorm:"size:64;index" json:"tick"`
+	MetaId     uint      `json:"meta_id"`
+	Action     string    `gorm:"size:16" json:"action"`
+	Actor      string    `gorm:"size:64" json:"actor"`
+	Before     string    `gorm:"type:text" json:"before"`
+	After      string    `gorm:"type:text" json:"after"`
+	Reason     string    `gorm:"size:255" json:"reason"`
+	CreateDate LocalTime `gorm:"type:datetime" json:"create_date"`
+}
+
+func (Drc20MetaHistory) TableName() string {
+	return "drc20_meta_history"
+}
@@ -1,5 +1,14 @@
 package models
 
+// The status of an order-v1 order, an order is open until it is traded, then partial until nothing remains.
+const (
+	ExchangeStatusOpen      = "open"
+	ExchangeStatusPartial   = "partial"
+	ExchangeStatusFilled    = "filled"
+	ExchangeStatusCancelled = "cancelled"
+	ExchangeStatusExpired   = "expired"
+)
+
 type ExchangeInfo struct {
 	ID            uint      `gorm:"primarykey" json:"id"`
 	OrderId       string    `json:"order_id"`
@@ -9,6 +18,7 @@ type ExchangeInfo struct {
 	Tick1         string    `json:"tick1"`
 	Amt0          *Number   `json:"amt0"`
 	Amt1          *Number   `json:"amt1"`
+	Expire        int64     `json:"expire"`
 	FeeAddress    string    `json:"fee_address"`
 	FeeTxHash     string    `json:"fee_tx_hash"`
 	TxHash        string    `json:"tx_hash"`
@@ -34,6 +44,8 @@ type ExchangeCollect struct {
 	Amt1            *Number   `json:"amt1"`
 	Amt0Finish      *Number   `json:"amt0_finish"`
 	Amt1Finish      *Number   `json:"amt1_finish"`
+	Expire          int64     `json:"expire"`
+	Status          string    `gorm:"size:16;default:open" json:"status"`
 	HolderAddress   string    `json:"holder_address"`
 	ReservesAddress string    `json:"reserves_address"`
 	CreateDate      LocalTime `json:"create_date"`
@@ -51,6 +63,7 @@ type ExchangeRevert struct {
 	ExId        string    `json:"ex_id"`
 	Amt0        *Number   `json:"amt0"`
 	Amt1        *Number   `json:"amt1"`
+	Status      string    `gorm:"size:16" json:"status"`
 	BlockNumber int64     `json:"block_number"`
 	TxHash      string    `json:"tx_hash"`
 	UpdateDate  LocalTime `json:"update_date"`
@@ -68,6 +68,7 @@ func (FileExchangeRevert) TableName() string {
 
 type FileExchangeSummary struct {
 	ID           uint      `gorm:"primarykey" json:"id"`
+	MetaId       string    `json:"meta_id"`
 	MetaName     string    `json:"meta_name"`
 	LowestAsk    float64   `json:"lowest_ask"`
 	HighestBid   float64   `json:"highest_bid"`
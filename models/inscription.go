@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 type BaseInscription struct {
 	P  string `json:"p"`
 	Op string `json:"op"`
@@ -27,7 +29,9 @@ type SwapInscription struct {
 	Amt0Min   string `json:"amt0_min"`
 	Amt1Min   string `json:"amt1_min"`
 	Liquidity string `json:"liquidity"`
-	Doge      int    `json:"doge"`
+	// Fee is the fee tier of a create, it is parsed from the activation on
+	Fee  json.RawMessage `json:"fee"`
+	Doge int             `json:"doge"`
 }
 
 type WDogeInscription struct {
@@ -47,6 +51,12 @@ type CrossInscription struct {
 	AdminAddress  string `json:"admin_address"`
 	ToAddress     string `json:"to_address"`
 	HolderAddress string `json:"holder_address"`
+	DepositId     string `json:"deposit_id"`
+	BurnTxHash    string `json:"burn_tx_hash"`
+	ExtTxId       string `json:"ext_tx_id"`
+	// Admins and Threshold are parsed for the admin ops from the activation on
+	Admins    json.RawMessage `json:"admins"`
+	Threshold json.RawMessage `json:"threshold"`
 }
 
 type NftInscription struct {
@@ -95,6 +105,8 @@ type ExchangeInscription struct {
 	Tick1 string `json:"tick1"`
 	Amt0  string `json:"amt0"`
 	Amt1  string `json:"amt1"`
+	// Expire is the block height the order is refunded at, 0 never expires. It is parsed from the activation on.
+	Expire json.RawMessage `json:"expire"`
 }
 
 type FileExchangeInscription struct {
@@ -0,0 +1,12 @@
+package models
+
+// LeaderLease is held by the replica that runs the explorer, it must be renewed before ExpireAt.
+type LeaderLease struct {
+	Name     string `gorm:"primarykey;size:64" json:"name"`
+	Holder   string `gorm:"size:128" json:"holder"`
+	ExpireAt int64  `json:"expire_at"`
+}
+
+func (LeaderLease) TableName() string {
+	return "leader_lease"
+}
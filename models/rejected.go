@@ -0,0 +1,35 @@
+package models
+
+const (
+	// RejectJson the reveal input carries an inscription envelope whose json does not parse.
+	RejectJson = "json"
+	// RejectProtocol the json parses but names a protocol the indexer does not know.
+	RejectProtocol = "protocol"
+	// RejectDecode the protocol decoder refused the inscription.
+	RejectDecode = "decode"
+)
+
+const (
+	RejectStatusRejected    = 0
+	RejectStatusReprocessed = 1
+)
+
+// RejectedInscription is a transaction that looked like an inscription but was not indexed.
+type RejectedInscription struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	TxHash      string    `gorm:"size:64;uniqueIndex" json:"tx_hash"`
+	BlockHash   string    `gorm:"size:64" json:"block_hash"`
+	BlockNumber int64     `gorm:"index" json:"block_number"`
+	P           string    `gorm:"size:32" json:"p"`
+	Op          string    `gorm:"size:32" json:"op"`
+	Raw         string    `gorm:"type:text" json:"raw"`
+	ErrClass    string    `gorm:"size:16;index" json:"err_class"`
+	ErrInfo     string    `gorm:"type:text" json:"err_info"`
+	Status      int64     `json:"status"`
+	UpdateDate  LocalTime `gorm:"type:datetime" json:"update_date"`
+	CreateDate  LocalTime `gorm:"type:datetime" json:"create_date"`
+}
+
+func (RejectedInscription) TableName() string {
+	return "rejected_inscriptions"
+}
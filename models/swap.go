@@ -16,6 +16,7 @@ type SwapInfo struct {
 	Amt0Out       *Number   `gorm:"default:'0'" json:"amt0_out"`
 	Amt1Out       *Number   `gorm:"default:'0'" json:"amt1_out"`
 	Liquidity     *Number   `json:"liquidity"`
+	Fee           int       `gorm:"default:0" json:"fee"`
 	Doge          int       `json:"doge"`
 	HolderAddress string    `json:"holder_address"`
 	FeeAddress    string    `json:"fee_address"`
@@ -45,6 +46,10 @@ type SwapLiquidity struct {
 	ClosePrice      float64 `json:"close_price"`
 	ReservesAddress string  `json:"reserves_address"`
 	HolderAddress   string  `json:"holder_address"`
+	// Fee is the input fee of a swap in basis points, KLast the reserves product after the last add or remove
+	// while the protocol fee is on
+	Fee   int     `gorm:"default:30" json:"fee"`
+	KLast *Number `gorm:"default:'0'" json:"k_last"`
 }
 
 func (SwapLiquidity) TableName() string {
@@ -73,6 +78,22 @@ func (SwapRevert) TableName() string {
 	return "swap_revert"
 }
 
+// SwapLiquidityRevert undoes what the balances of a pool do not give back on a fork, a create deletes
+// the pool and a k_last sets the k_last the pool had before.
+type SwapLiquidityRevert struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	Op          string    `json:"op"`
+	Tick        string    `json:"tick"`
+	KLast       *Number   `json:"k_last"`
+	TxHash      string    `json:"tx_hash"`
+	BlockNumber int64     `json:"block_number"`
+	CreateDate  LocalTime `json:"create_date"`
+}
+
+func (SwapLiquidityRevert) TableName() string {
+	return "swap_liquidity_revert"
+}
+
 type SwapSummary struct {
 	ID           uint    `gorm:"primarykey" json:"id"`
 	Tick         string  `json:"tick"`
@@ -0,0 +1,119 @@
+package oracle
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// History gives the prices of a file, the price at a time is the one of the last point not after it.
+// A .json file holds a list of {"time": ..., "price": ...} objects, any other file is read as csv lines of
+// time,price with an optional header. Times are unix seconds, RFC 3339 or local dates.
+type History struct {
+	times  []int64
+	prices []float64
+}
+
+type historyPoint struct {
+	Time  json.RawMessage `json:"time"`
+	Price json.Number     `json:"price"`
+}
+
+func NewHistory(path string) (*History, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("NewHistory err: %s", err.Error())
+	}
+
+	var rows [][2]string
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		rows, err = historyJson(data)
+	} else {
+		rows, err = historyCsv(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("NewHistory %s err: %s", path, err.Error())
+	}
+
+	h := &History{}
+	type point struct {
+		time  int64
+		price float64
+	}
+	points := make([]point, 0, len(rows))
+	for i, row := range rows {
+		t, err := parseTime(row[0])
+		if err != nil {
+			return nil, fmt.Errorf("NewHistory %s point %d: %s", path, i, err.Error())
+		}
+
+		price, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+		if err != nil || price <= 0 {
+			return nil, fmt.Errorf("NewHistory %s point %d: price %q is not positive", path, i, row[1])
+		}
+		points = append(points, point{time: t.Unix(), price: price})
+	}
+
+	if len(points) == 0 {
+		return nil, fmt.Errorf("NewHistory %s has no prices", path)
+	}
+
+	sort.SliceStable(points, func(i, j int) bool { return points[i].time < points[j].time })
+	for _, p := range points {
+		h.times = append(h.times, p.time)
+		h.prices = append(h.prices, p.price)
+	}
+	return h, nil
+}
+
+func historyJson(data []byte) ([][2]string, error) {
+	points := make([]historyPoint, 0)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&points); err != nil {
+		return nil, err
+	}
+
+	rows := make([][2]string, 0, len(points))
+	for _, p := range points {
+		t := strings.Trim(string(p.Time), `"`)
+		rows = append(rows, [2]string{t, p.Price.String()})
+	}
+	return rows, nil
+}
+
+func historyCsv(data []byte) ([][2]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][2]string, 0, len(records))
+	for i, record := range records {
+		if i == 0 {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64); err != nil {
+				continue
+			}
+		}
+		rows = append(rows, [2]string{record[0], record[1]})
+	}
+	return rows, nil
+}
+
+func (h *History) DogeUsd(at time.Time) (float64, error) {
+	i := sort.Search(len(h.times), func(i int) bool { return h.times[i] > at.Unix() })
+	if i == 0 {
+		return 0, ErrNoPrice
+	}
+	return h.prices[i-1], nil
+}
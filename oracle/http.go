@@ -0,0 +1,152 @@
+package oracle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTimeout = 10 * time.Second
+	DefaultTtl     = time.Minute
+
+	// TimeParam in the url is replaced by the unix seconds of the requested time
+	TimeParam = "{time}"
+)
+
+// Http reads the price at a time from the JSON of a url holding {time}, field is the dotted path of the
+// price in it. A current price is not taken: the candles of a block must get the same price whenever
+// the block is indexed. Prices are fetched in the background so a caller never waits for the url: a
+// price that is not fetched yet is ErrNoPrice, and a failed fetch is not tried again before ttl.
+type Http struct {
+	url    string
+	field  []string
+	ttl    time.Duration
+	client *http.Client
+
+	lock    *sync.Mutex
+	cached  map[int64]float64
+	pending map[int64]bool
+	failed  map[int64]time.Time
+}
+
+func NewHttp(url, field string, timeout, ttl time.Duration) *Http {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultTtl
+	}
+
+	var path []string
+	if field != "" {
+		path = strings.Split(field, ".")
+	}
+
+	return &Http{
+		url:     url,
+		field:   path,
+		ttl:     ttl,
+		client:  &http.Client{Timeout: timeout},
+		lock:    &sync.Mutex{},
+		cached:  make(map[int64]float64),
+		pending: make(map[int64]bool),
+		failed:  make(map[int64]time.Time),
+	}
+}
+
+func (h *Http) DogeUsd(at time.Time) (float64, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if price, ok := h.cached[at.Unix()]; ok {
+		return price, nil
+	}
+
+	h.refresh(at.Unix(), strings.ReplaceAll(h.url, TimeParam, strconv.FormatInt(at.Unix(), 10)))
+	return 0, ErrNoPrice
+}
+
+// refresh fetches url in the background unless the price of key is already being fetched or failed
+// less than ttl ago, key is the unix time of the price. It must be called with the lock held.
+func (h *Http) refresh(key int64, url string) {
+	if h.pending[key] {
+		return
+	}
+
+	if failed, ok := h.failed[key]; ok && time.Since(failed) < h.ttl {
+		return
+	}
+
+	h.pending[key] = true
+	go func() {
+		price, err := h.fetch(url)
+
+		h.lock.Lock()
+		defer h.lock.Unlock()
+
+		delete(h.pending, key)
+		if err != nil {
+			log.Warn("oracle", "price", url, "err", err)
+			h.failed[key] = time.Now()
+			return
+		}
+
+		delete(h.failed, key)
+		h.cached[key] = price
+	}()
+}
+
+func (h *Http) fetch(url string) (float64, error) {
+	resp, err := h.client.Get(url)
+	if err != nil {
+		return 0, fmt.Errorf("price oracle get err: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("price oracle get status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, fmt.Errorf("price oracle read err: %s", err.Error())
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return 0, fmt.Errorf("price oracle json err: %s", err.Error())
+	}
+
+	for _, key := range h.field {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("price oracle field %s: not an object", key)
+		}
+		v = m[key]
+	}
+
+	var price float64
+	switch p := v.(type) {
+	case json.Number:
+		price, err = p.Float64()
+	case string:
+		price, err = strconv.ParseFloat(p, 64)
+	default:
+		return 0, fmt.Errorf("price oracle field %s is not a number", strings.Join(h.field, "."))
+	}
+	if err != nil || price <= 0 {
+		return 0, ErrNoPrice
+	}
+	return price, nil
+}
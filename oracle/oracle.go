@@ -0,0 +1,78 @@
+package oracle
+
+import (
+	"errors"
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SourceStatic = "static"
+	SourceFile   = "file"
+	SourceHttp   = "http"
+)
+
+// ErrNoPrice is returned for a time the source has no price for.
+var ErrNoPrice = errors.New("no doge price")
+
+// PriceOracle gives the DOGE price in USD at a time, it is recorded as doge_usdt on the candles.
+type PriceOracle interface {
+	DogeUsd(at time.Time) (float64, error)
+}
+
+// New returns the oracle of cfg.source, nil when no source is set.
+func New(cfg utils.PriceOracleConfig) (PriceOracle, error) {
+	switch cfg.Source {
+	case "":
+		return nil, nil
+	case SourceStatic:
+		return NewStatic(cfg.Price), nil
+	case SourceFile:
+		return NewHistory(cfg.File)
+	case SourceHttp:
+		if !strings.Contains(cfg.Url, TimeParam) {
+			return nil, fmt.Errorf("price oracle url %q has no %s", cfg.Url, TimeParam)
+		}
+		return NewHttp(cfg.Url, cfg.Field, time.Duration(cfg.Timeout)*time.Second, time.Duration(cfg.Ttl)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("price oracle source %q is not supported", cfg.Source)
+	}
+}
+
+// Static always gives the same price.
+type Static struct {
+	price float64
+}
+
+func NewStatic(price float64) *Static {
+	return &Static{price: price}
+}
+
+func (s *Static) DogeUsd(at time.Time) (float64, error) {
+	if s.price <= 0 {
+		return 0, ErrNoPrice
+	}
+	return s.price, nil
+}
+
+// parseTime reads unix seconds, RFC 3339 or a local "2006-01-02 15:04:05" or "2006-01-02" date.
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("time %q is not unix seconds or a date", s)
+}
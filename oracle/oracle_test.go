@@ -0,0 +1,104 @@
+package oracle
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/unielon-org/unielon-indexer/utils"
+)
+
+func writeFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHistory(t *testing.T) {
+	files := map[string]string{
+		"doge.csv":  "time,price\n1700000100,0.2\n1700000000,0.1\n",
+		"doge.json": `[{"time": 1700000000, "price": 0.1}, {"time": "1700000100", "price": "0.2"}]`,
+	}
+
+	for name, data := range files {
+		h, err := NewHistory(writeFile(t, name, data))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if _, err := h.DogeUsd(time.Unix(1699999999, 0)); !errors.Is(err, ErrNoPrice) {
+			t.Fatalf("%s: price before the first point, err %v", name, err)
+		}
+
+		cases := map[int64]float64{1700000000: 0.1, 1700000099: 0.1, 1700000100: 0.2, 1800000000: 0.2}
+		for at, want := range cases {
+			price, err := h.DogeUsd(time.Unix(at, 0))
+			if err != nil || price != want {
+				t.Fatalf("%s: price at %d = %v, %v want %v", name, at, price, err, want)
+			}
+		}
+	}
+
+	if _, err := NewHistory(writeFile(t, "bad.csv", "1700000000,-1\n")); err == nil {
+		t.Fatal("a negative price must be rejected")
+	}
+}
+
+func TestHttp(t *testing.T) {
+	calls := new(int64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(calls, 1)
+		at := r.URL.Query().Get("at")
+		fmt.Fprintf(w, `{"data": {"usd": "0.%s"}}`, at[len(at)-1:])
+	}))
+	defer srv.Close()
+
+	if _, err := New(utils.PriceOracleConfig{Source: SourceHttp, Url: srv.URL}); err == nil {
+		t.Fatal("a url without {time} must be rejected")
+	}
+
+	h := NewHttp(srv.URL+"?at={time}", "data.usd", time.Second, time.Hour)
+	if _, err := h.DogeUsd(time.Unix(1700000003, 0)); !errors.Is(err, ErrNoPrice) {
+		t.Fatalf("the first call must not wait for the url, err %v", err)
+	}
+	waitPrice(t, h, time.Unix(1700000003, 0), 0.3)
+	if atomic.LoadInt64(calls) != 1 {
+		t.Fatalf("the price of a time must be kept, %d calls", *calls)
+	}
+
+	atomic.StoreInt64(calls, 0)
+	h = NewHttp(srv.URL+"?at={time}", "data.eur", time.Second, time.Hour)
+	for i := 0; i < 3; i++ {
+		if _, err := h.DogeUsd(time.Unix(1700000003, 0)); err == nil {
+			t.Fatal("a missing field must be an error")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if atomic.LoadInt64(calls) != 1 {
+		t.Fatalf("a failed fetch must not be tried again before ttl, %d calls", *calls)
+	}
+}
+
+// waitPrice asks h for the price at until the background fetch has it.
+func waitPrice(t *testing.T, h *Http, at time.Time, want float64) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		price, err := h.DogeUsd(at)
+		if err == nil {
+			if price != want {
+				t.Fatalf("price = %v want %v", price, want)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("no price at %s", at)
+}
@@ -0,0 +1,393 @@
+package router
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultCacheSize     = 10000
+	cacheHeightRefresh   = time.Second
+	cacheStoreLevelDB    = "leveldb"
+	cacheStoreMemory     = "memory"
+	cacheContentTypeJSON = "application/json; charset=utf-8"
+)
+
+// cacheSkipPaths are never cached, they either change state or must always be live.
+var cacheSkipPaths = []string{
+	"/healthz",
+	"/readyz",
+	"/v4/info/cache",
+	"/v4/tx/",
+	"/v4/file/upload/",
+	"/v4/drc20/meta",
+}
+
+type cacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Clear()
+	Len() int
+}
+
+// Cache caches successful read responses keyed by route and normalized body.
+// Every entry belongs to the block height it was computed at and is dropped once a new block is committed.
+type Cache struct {
+	dbc   *storage.DBClient
+	store cacheStore
+
+	lock      *sync.Mutex
+	height    int64
+	checkedAt time.Time
+	// cleared counts the Clear calls, a response computed before one is not stored
+	cleared uint64
+
+	hits   uint64
+	misses uint64
+}
+
+func NewCache(dbc *storage.DBClient, level *storage.LevelDB, cfg utils.CacheConfig) *Cache {
+	size := cfg.Size
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+
+	var store cacheStore
+	if cfg.Store == cacheStoreLevelDB && level != nil {
+		store = newLevelCacheStore(level, size)
+	} else {
+		store = newMemoryCacheStore(size)
+	}
+
+	return &Cache{
+		dbc:    dbc,
+		store:  store,
+		lock:   &sync.Mutex{},
+		height: -1,
+	}
+}
+
+// OnBlock drops every entry once a block at a new height is committed.
+func (ca *Cache) OnBlock(height int64) {
+	ca.lock.Lock()
+	defer ca.lock.Unlock()
+	ca.setHeight(height)
+	ca.checkedAt = time.Now()
+}
+
+// Clear drops every entry, for changes that are not made by a block.
+func (ca *Cache) Clear() {
+	ca.lock.Lock()
+	defer ca.lock.Unlock()
+	ca.store.Clear()
+	ca.cleared++
+}
+
+func (ca *Cache) setHeight(height int64) {
+	if height != ca.height {
+		ca.store.Clear()
+		ca.height = height
+	}
+}
+
+// currentHeight reads the indexed height at most once per cacheHeightRefresh, so
+// blocks committed by an explorer in another process are also picked up.
+func (ca *Cache) currentHeight() (int64, error) {
+	ca.lock.Lock()
+	defer ca.lock.Unlock()
+
+	if time.Since(ca.checkedAt) < cacheHeightRefresh {
+		return ca.height, nil
+	}
+
+	maxHeight := int64(0)
+	err := ca.dbc.DB.Model(&models.Block{}).Select("max(block_number)").Scan(&maxHeight).Error
+	if err != nil {
+		return 0, err
+	}
+
+	ca.setHeight(maxHeight)
+	ca.checkedAt = time.Now()
+	return maxHeight, nil
+}
+
+func (ca *Cache) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, path := range cacheSkipPaths {
+			if strings.HasPrefix(c.Request.URL.Path, path) {
+				c.Next()
+				return
+			}
+		}
+
+		if c.Request.Method != http.MethodPost && c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Next()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		height, err := ca.currentHeight()
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		ca.lock.Lock()
+		cleared := ca.cleared
+		ca.lock.Unlock()
+
+		key := cacheKey(c.Request.Method, c.Request.URL.Path, c.Request.URL.RawQuery, body)
+		if value, ok := ca.store.Get(key); ok {
+			atomic.AddUint64(&ca.hits, 1)
+			c.Header("X-Cache", "HIT")
+			c.Data(http.StatusOK, cacheContentTypeJSON, value)
+			c.Abort()
+			return
+		}
+
+		atomic.AddUint64(&ca.misses, 1)
+		writer := &cacheWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Header("X-Cache", "MISS")
+		c.Next()
+
+		if writer.Status() != http.StatusOK {
+			return
+		}
+
+		result := &struct {
+			Code int `json:"code"`
+		}{}
+		if err := json.Unmarshal(writer.body.Bytes(), result); err != nil || result.Code != 200 {
+			return
+		}
+
+		ca.lock.Lock()
+		if ca.height == height && ca.cleared == cleared {
+			ca.store.Set(key, writer.body.Bytes())
+		}
+		ca.lock.Unlock()
+	}
+}
+
+// Stats reports cache hits and misses since start.
+func (ca *Cache) Stats(c *gin.Context) {
+	hits := atomic.LoadUint64(&ca.hits)
+	misses := atomic.LoadUint64(&ca.misses)
+
+	ca.lock.Lock()
+	data := make(map[string]interface{})
+	data["height"] = ca.height
+	data["entries"] = ca.store.Len()
+	ca.lock.Unlock()
+
+	data["hits"] = hits
+	data["misses"] = misses
+	if hits+misses > 0 {
+		data["hit_rate"] = float64(hits) / float64(hits+misses)
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = data
+	c.JSON(http.StatusOK, result)
+}
+
+// cacheKey normalizes the json body so that key order and whitespace do not matter.
+func cacheKey(method, path, query string, body []byte) string {
+	normalized := body
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err == nil {
+		if data, err := json.Marshal(v); err == nil {
+			normalized = data
+		}
+	}
+
+	hash := sha256.Sum256(append([]byte(method+" "+path+"?"+query+"\n"), normalized...))
+	return hex.EncodeToString(hash[:])
+}
+
+type cacheWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// memoryCacheStore is an LRU bounded by the number of entries.
+type memoryCacheStore struct {
+	lock    *sync.Mutex
+	size    int
+	items   map[string]*list.Element
+	entries *list.List
+}
+
+type memoryCacheEntry struct {
+	key   string
+	value []byte
+}
+
+func newMemoryCacheStore(size int) *memoryCacheStore {
+	return &memoryCacheStore{
+		lock:    &sync.Mutex{},
+		size:    size,
+		items:   make(map[string]*list.Element),
+		entries: list.New(),
+	}
+}
+
+func (s *memoryCacheStore) Get(key string) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	s.entries.MoveToFront(elem)
+	return elem.Value.(*memoryCacheEntry).value, true
+}
+
+func (s *memoryCacheStore) Set(key string, value []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		elem.Value.(*memoryCacheEntry).value = value
+		s.entries.MoveToFront(elem)
+		return
+	}
+
+	s.items[key] = s.entries.PushFront(&memoryCacheEntry{key: key, value: value})
+	for s.entries.Len() > s.size {
+		oldest := s.entries.Back()
+		s.entries.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+func (s *memoryCacheStore) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.items = make(map[string]*list.Element)
+	s.entries.Init()
+}
+
+func (s *memoryCacheStore) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.entries.Len()
+}
+
+// levelCacheStore keeps the entries in leveldb and their keys in memory, the oldest entries are deleted
+// beyond size. Only the keys set since the last Clear are served, so entries a failed delete left behind
+// are never read.
+type levelCacheStore struct {
+	level *storage.LevelDB
+	size  int
+
+	lock    *sync.Mutex
+	items   map[string]*list.Element
+	entries *list.List
+}
+
+func newLevelCacheStore(level *storage.LevelDB, size int) *levelCacheStore {
+	store := &levelCacheStore{
+		level:   level,
+		size:    size,
+		lock:    &sync.Mutex{},
+		items:   make(map[string]*list.Element),
+		entries: list.New(),
+	}
+	store.Clear()
+	return store
+}
+
+func (s *levelCacheStore) Get(key string) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	value, err := s.level.GetCache(key)
+	if err != nil {
+		s.entries.Remove(elem)
+		delete(s.items, key)
+		return nil, false
+	}
+
+	s.entries.MoveToFront(elem)
+	return value, true
+}
+
+func (s *levelCacheStore) Set(key string, value []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.level.SetCache(key, value); err != nil {
+		return
+	}
+
+	if elem, ok := s.items[key]; ok {
+		s.entries.MoveToFront(elem)
+		return
+	}
+
+	s.items[key] = s.entries.PushFront(key)
+	for s.entries.Len() > s.size {
+		oldest := s.entries.Back()
+		s.entries.Remove(oldest)
+		delete(s.items, oldest.Value.(string))
+		_ = s.level.DeleteCache(oldest.Value.(string))
+	}
+}
+
+func (s *levelCacheStore) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.items = make(map[string]*list.Element)
+	s.entries.Init()
+	_ = s.level.ClearCache()
+}
+
+func (s *levelCacheStore) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.entries.Len()
+}
@@ -0,0 +1,47 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Cors answers preflight requests and allows the configured origins, "*" allows any origin.
+func Cors(cfg utils.CorsConfig, keyHeader string) gin.HandlerFunc {
+	allowAll := false
+	origins := make(map[string]bool)
+	for _, origin := range cfg.AllowOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		origins[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	headers := "Origin, X-Requested-With, Content-Type, Accept"
+	if keyHeader != "" {
+		headers += ", " + keyHeader
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		switch {
+		case allowAll:
+			c.Header("Access-Control-Allow-Origin", "*")
+		case origin != "" && origins[origin]:
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Vary", "Origin")
+		}
+
+		c.Header("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, DELETE")
+		c.Header("Access-Control-Allow-Headers", headers)
+		c.Header("Access-Control-Max-Age", strconv.FormatInt(cfg.MaxAge, 10))
+
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusOK)
+			return
+		}
+		c.Next()
+	}
+}
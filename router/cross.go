@@ -1,23 +1,24 @@
 package router
 
 import (
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/utils"
 	"github.com/unielon-org/unielon-indexer/verifys"
 	"net/http"
+	"strings"
 )
 
 type CrossRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node *chain.Client
 
 	verify *verifys.Verifys
 }
 
-func NewCrossRouter(dbc *storage.DBClient, node *rpcclient.Client, verify *verifys.Verifys) *CrossRouter {
+func NewCrossRouter(dbc *storage.DBClient, node *chain.Client, verify *verifys.Verifys) *CrossRouter {
 	return &CrossRouter{
 		dbc:    dbc,
 		node:   node,
@@ -108,3 +109,202 @@ func (r *CrossRouter) Collect(c *gin.Context) {
 	result.Data = info
 	c.JSON(http.StatusOK, result)
 }
+
+// Withdrawals lists the burns queued for a release on another chain, status is pending or released.
+func (r *CrossRouter) Withdrawals(c *gin.Context) {
+	type params struct {
+		Tick          string `json:"tick"`
+		Chain         string `json:"chain"`
+		Status        string `json:"status"`
+		HolderAddress string `json:"holder_address"`
+		ToAddress     string `json:"to_address"`
+		BurnTxHash    string `json:"burn_tx_hash"`
+		Limit         int    `json:"limit"`
+		OffSet        int    `json:"offset"`
+	}
+
+	p := &params{
+		Limit:  10,
+		OffSet: 0,
+	}
+
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	if p.Status != "" && p.Status != models.CrossWithdrawalPending && p.Status != models.CrossWithdrawalReleased {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = "status must be pending or released"
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	filter := &models.CrossWithdrawal{
+		Tick:          strings.ToUpper(p.Tick),
+		Chain:         p.Chain,
+		Status:        p.Status,
+		HolderAddress: p.HolderAddress,
+		ToAddress:     p.ToAddress,
+		BurnTxHash:    p.BurnTxHash,
+	}
+
+	withdrawals := make([]*models.CrossWithdrawal, 0)
+	total := int64(0)
+	err := r.dbc.DB.Where(filter).Order("id desc").Count(&total).Limit(p.Limit).Offset(p.OffSet).Find(&withdrawals).Error
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Data = withdrawals
+	result.Total = total
+	c.JSON(http.StatusOK, result)
+}
+
+// Deposits lists the deposits on other chains that were minted, by their deposit id.
+func (r *CrossRouter) Deposits(c *gin.Context) {
+	type params struct {
+		Tick      string `json:"tick"`
+		Chain     string `json:"chain"`
+		DepositId string `json:"deposit_id"`
+		ToAddress string `json:"to_address"`
+		Limit     int    `json:"limit"`
+		OffSet    int    `json:"offset"`
+	}
+
+	p := &params{
+		Limit:  10,
+		OffSet: 0,
+	}
+
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	filter := &models.CrossDeposit{
+		Tick:      strings.ToUpper(p.Tick),
+		Chain:     p.Chain,
+		DepositId: p.DepositId,
+		ToAddress: p.ToAddress,
+	}
+
+	deposits := make([]*models.CrossDeposit, 0)
+	total := int64(0)
+	err := r.dbc.DB.Where(filter).Order("id desc").Count(&total).Limit(p.Limit).Offset(p.OffSet).Find(&deposits).Error
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Data = deposits
+	result.Total = total
+	c.JSON(http.StatusOK, result)
+}
+
+// Admin returns the admin set, threshold and nonce of a wrapped token.
+func (r *CrossRouter) Admin(c *gin.Context) {
+	type params struct {
+		Tick string `json:"tick"`
+	}
+
+	p := &params{}
+
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	cc := &models.CrossCollect{}
+	err := r.dbc.DB.Where("tick = ?", strings.ToUpper(p.Tick)).First(cc).Error
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["tick"] = cc.Tick
+	data["admin_address"] = cc.AdminAddress
+	data["admins"] = storage.CrossAdmins(cc)
+	data["threshold"] = cc.Threshold
+	data["nonce"] = cc.Nonce
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Data = data
+	c.JSON(http.StatusOK, result)
+}
+
+// Approvals lists the approvals of the admins, ref is the deposit id of a mint or the nonce of a set-admin
+// or set-threshold.
+func (r *CrossRouter) Approvals(c *gin.Context) {
+	type params struct {
+		Tick         string `json:"tick"`
+		Op           string `json:"op"`
+		Ref          string `json:"ref"`
+		AdminAddress string `json:"admin_address"`
+		Limit        int    `json:"limit"`
+		OffSet       int    `json:"offset"`
+	}
+
+	p := &params{
+		Limit:  10,
+		OffSet: 0,
+	}
+
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	filter := &models.CrossApproval{
+		Tick:         strings.ToUpper(p.Tick),
+		Op:           p.Op,
+		Ref:          p.Ref,
+		AdminAddress: p.AdminAddress,
+	}
+
+	approvals := make([]*models.CrossApproval, 0)
+	total := int64(0)
+	err := r.dbc.DB.Where(filter).Order("id desc").Count(&total).Limit(p.Limit).Offset(p.OffSet).Find(&approvals).Error
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Data = approvals
+	result.Total = total
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,245 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"math/big"
+	"strings"
+)
+
+const formatDecimal = "decimal"
+
+// decimalField is an amount key of a response and the keys of the tick it is counted in, the first one
+// present in the same object, or else in the closest enclosing object, wins. Numeric also converts json numbers, for the fields kept in a *big.Int,
+// the other keys only hold integer strings and may be floats elsewhere.
+type decimalField struct {
+	ticks   []string
+	numeric bool
+}
+
+var decimalFields = map[string]decimalField{
+	"amt":               {ticks: []string{"tick"}, numeric: true},
+	"amt_sum":           {ticks: []string{"tick"}},
+	"amt_finish":        {ticks: []string{"tick"}},
+	"real_sum":          {ticks: []string{"tick"}},
+	"lock_amt":          {ticks: []string{"tick"}},
+	"max":               {ticks: []string{"tick", "tick0"}},
+	"lim":               {ticks: []string{"tick"}, numeric: true},
+	"max_amt":           {ticks: []string{"tick"}, numeric: true},
+	"mint_amt":          {ticks: []string{"tick"}, numeric: true},
+	"cardi_amt":         {ticks: []string{"tick"}, numeric: true},
+	"liquidity":         {ticks: []string{"tick"}},
+	"liquidity_total":   {ticks: []string{"tick"}},
+	"liquidity_change":  {ticks: []string{"tick"}},
+	"untracked":         {ticks: []string{"tick"}},
+	"amt0":              {ticks: []string{"tick0"}},
+	"amt0_min":          {ticks: []string{"tick0"}},
+	"amt0_out":          {ticks: []string{"tick0"}},
+	"amt0_finish":       {ticks: []string{"tick0"}},
+	"amt1":              {ticks: []string{"tick1"}},
+	"amt1_min":          {ticks: []string{"tick1"}},
+	"amt1_out":          {ticks: []string{"tick1"}},
+	"amt1_finish":       {ticks: []string{"tick1"}},
+	"deposit0":          {ticks: []string{"tick0"}},
+	"deposit1":          {ticks: []string{"tick1"}},
+	"fee0":              {ticks: []string{"tick0"}},
+	"fee1":              {ticks: []string{"tick1"}},
+	"value_lp":          {ticks: []string{"tick1"}},
+	"value_hold":        {ticks: []string{"tick1"}},
+	"depth0":            {ticks: []string{"tick0"}},
+	"depth1":            {ticks: []string{"tick1"}},
+	"liqamt":            {ticks: []string{"tick1"}},
+	"liqamt_finish":     {ticks: []string{"tick1"}},
+	"base_volume":       {ticks: []string{"tick0"}},
+	"quote_volume":      {ticks: []string{"tick1"}},
+	"reward":            {ticks: []string{"reward_tick", "tick1", "tick"}, numeric: true},
+	"received_reward":   {ticks: []string{"tick"}, numeric: true},
+	"total_reward_pool": {ticks: []string{"tick"}, numeric: true},
+	"reward_debt":       {ticks: []string{"tick"}},
+	"pending_reward":    {ticks: []string{"tick"}},
+	"each_reward":       {ticks: []string{"tick1"}},
+	"reward_finish":     {ticks: []string{"tick1"}},
+	"total_staked":      {ticks: []string{"tick1"}},
+	"amt_in":            {ticks: []string{"tick_in"}},
+	"amt_in_max":        {ticks: []string{"tick_in"}},
+	"reserve_in":        {ticks: []string{"tick_in"}},
+	"amt_out":           {ticks: []string{"tick_out"}},
+	"amt_out_min":       {ticks: []string{"tick_out"}},
+	"reserve_out":       {ticks: []string{"tick_out"}},
+}
+
+// IsDecimal reports whether the request asked for amounts scaled by the decimals of their tick.
+func IsDecimal(c *gin.Context) bool {
+	return c.Query("format") == formatDecimal
+}
+
+// Decimal renders the amounts of a response as decimal strings when the request has format=decimal.
+// Amounts of ticks that are not deployed are left as they are.
+type Decimal struct {
+	dbc *storage.DBClient
+}
+
+func NewDecimal(dbc *storage.DBClient) *Decimal {
+	return &Decimal{dbc: dbc}
+}
+
+func (d *Decimal) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsDecimal(c) {
+			c.Next()
+			return
+		}
+
+		writer := &decimalWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		body := writer.body.Bytes()
+		if len(body) == 0 {
+			return
+		}
+
+		if rendered, err := d.render(body); err == nil {
+			body = rendered
+		} else {
+			log.Warn("router", "decimal", err, "path", c.Request.URL.Path, "request_id", RequestId(c))
+		}
+
+		if _, err := c.Writer.Write(body); err != nil {
+			log.Warn("router", "decimal write", err, "request_id", RequestId(c))
+		}
+	}
+}
+
+func (d *Decimal) render(body []byte) ([]byte, error) {
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	ticks := make(map[string]bool)
+	walkDecimal(v, nil, func(obj map[string]interface{}, key, tick string) {
+		ticks[tick] = true
+	})
+
+	if len(ticks) == 0 {
+		return body, nil
+	}
+
+	list := make([]string, 0, len(ticks))
+	for tick := range ticks {
+		list = append(list, tick)
+	}
+
+	decs, err := d.dbc.FindDrc20Decimals(list)
+	if err != nil {
+		return nil, err
+	}
+
+	walkDecimal(v, nil, func(obj map[string]interface{}, key, tick string) {
+		dec, ok := decs[tick]
+		if !ok {
+			return
+		}
+
+		n, _ := decimalAmount(obj[key], decimalFields[key].numeric)
+		obj[key] = utils.FormatDecimal(n, dec)
+	})
+
+	return json.Marshal(v)
+}
+
+// walkDecimal calls fn for every amount of v that has a tick next to it or in an enclosing object.
+func walkDecimal(v interface{}, outer map[string]string, fn func(obj map[string]interface{}, key, tick string)) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			walkDecimal(item, outer, fn)
+		}
+	case map[string]interface{}:
+		ticks := make(map[string]string, len(outer))
+		for k, tick := range outer {
+			ticks[k] = tick
+		}
+		for k, value := range v {
+			if tick, ok := value.(string); ok && tick != "" && (strings.HasPrefix(k, "tick") || strings.HasSuffix(k, "_tick")) {
+				ticks[k] = tick
+			}
+		}
+
+		for key, value := range v {
+			field, ok := decimalFields[key]
+			if !ok {
+				walkDecimal(value, ticks, fn)
+				continue
+			}
+
+			if _, ok := decimalAmount(value, field.numeric); !ok {
+				continue
+			}
+
+			for _, tickKey := range field.ticks {
+				if tick, ok := ticks[tickKey]; ok {
+					fn(v, key, tick)
+					break
+				}
+			}
+		}
+	}
+}
+
+func decimalAmount(v interface{}, numeric bool) (*big.Int, bool) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case json.Number:
+		if !numeric {
+			return nil, false
+		}
+		s = v.String()
+	default:
+		return nil, false
+	}
+
+	return new(big.Int).SetString(s, 10)
+}
+
+// parseAmount reads an amount of tick from a request, in decimal mode it is scaled by the decimals of the tick.
+func parseAmount(c *gin.Context, dbc *storage.DBClient, tick, amt string) (*big.Int, error) {
+	if !IsDecimal(c) {
+		return utils.ConvetStr(amt)
+	}
+
+	decs, err := dbc.FindDrc20Decimals([]string{tick})
+	if err != nil {
+		return nil, err
+	}
+
+	dec, ok := decs[tick]
+	if !ok {
+		return nil, fmt.Errorf("the contract does not exist")
+	}
+	return utils.ParseDecimal(amt, dec)
+}
+
+type decimalWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *decimalWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *decimalWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
@@ -1,39 +1,50 @@
 package router
 
 import (
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/unielon-org/unielon-indexer/auth"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/utils"
 	"github.com/unielon-org/unielon-indexer/verifys"
 	"net/http"
+	"sync/atomic"
 )
 
 var (
-	cacheDrc20CollectAll *models.Drc20CollectCache
+	// cacheDrc20CollectAll is read and replaced by concurrent requests
+	cacheDrc20CollectAll atomic.Pointer[models.Drc20CollectCache]
 )
 
 type Drc20Router struct {
 	dbc   *storage.DBClient
-	node  *rpcclient.Client
+	node  *chain.Client
 	ipfs  *shell.Shell
 	level *storage.LevelDB
+	auth  *auth.Verifier
+	cache *Cache
 
 	verify *verifys.Verifys
 }
 
-func NewDrc20Router(db *storage.DBClient, node *rpcclient.Client, level *storage.LevelDB, ipfs *shell.Shell, verify *verifys.Verifys) *Drc20Router {
+func NewDrc20Router(db *storage.DBClient, node *chain.Client, level *storage.LevelDB, ipfs *shell.Shell, verify *verifys.Verifys) *Drc20Router {
 	return &Drc20Router{
 		dbc:    db,
 		node:   node,
 		level:  level,
 		ipfs:   ipfs,
+		auth:   auth.NewVerifier(db),
 		verify: verify,
 	}
 }
 
+// SetCache makes the metadata reviews drop the cached responses, it must be called before the router serves.
+func (r *Drc20Router) SetCache(cache *Cache) {
+	r.cache = cache
+}
+
 func (r *Drc20Router) Order(c *gin.Context) {
 	params := &struct {
 		OrderId       string `json:"order_id"`
@@ -158,12 +169,12 @@ func (r *Drc20Router) Collect(c *gin.Context) {
 	maxHeight := 0
 	err := r.dbc.DB.Model(&models.Block{}).Select("max(block_number)").Scan(&maxHeight).Error
 	if params.Tick == "" && params.HolderAddress == "" {
-		if cacheDrc20CollectAll != nil && cacheDrc20CollectAll.CacheNumber == int64(maxHeight) {
+		if cached := cacheDrc20CollectAll.Load(); cached != nil && cached.CacheNumber == int64(maxHeight) {
 			result := &utils.HttpResult{}
 			result.Code = 200
 			result.Msg = "success"
-			result.Data = cacheDrc20CollectAll.Results
-			result.Total = cacheDrc20CollectAll.Total
+			result.Data = cached.Results
+			result.Total = cached.Total
 			c.JSON(http.StatusOK, result)
 			return
 		}
@@ -214,9 +225,53 @@ func (r *Drc20Router) Collect(c *gin.Context) {
 		}
 	}
 
-	cacheDrc20CollectAll = &models.Drc20CollectCache{
+	cacheDrc20CollectAll.Store(&models.Drc20CollectCache{
 		CacheNumber: int64(maxHeight),
 		Results:     results,
+	})
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = results
+	result.Total = total
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (r *Drc20Router) Burns(c *gin.Context) {
+	params := &struct {
+		Tick          string `json:"tick"`
+		HolderAddress string `json:"holder_address"`
+		Limit         int    `json:"limit"`
+		OffSet        int    `json:"offset"`
+	}{
+		Limit:  10,
+		OffSet: 0,
+	}
+
+	if err := c.ShouldBindJSON(&params); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	filter := &models.Drc20Burn{
+		Tick:          params.Tick,
+		HolderAddress: params.HolderAddress,
+	}
+
+	var results []*models.Drc20Burn
+	var total int64
+	err := r.dbc.DB.Where(filter).Order("block_number desc").Limit(params.Limit).Offset(params.OffSet).Find(&results).Limit(-1).Offset(-1).Count(&total).Error
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = "server error"
+		c.JSON(http.StatusInternalServerError, result)
+		return
 	}
 
 	result := &utils.HttpResult{}
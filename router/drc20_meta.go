@@ -0,0 +1,249 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/auth"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+const (
+	metaMaxLink = 255
+	metaMaxText = 64 * 1024
+)
+
+func metaResult(c *gin.Context, status, code int, msg string) {
+	result := &utils.HttpResult{}
+	result.Code = code
+	result.Msg = msg
+	c.JSON(status, result)
+}
+
+// Meta queues token metadata signed by the deployer of the tick with action drc20-meta, it is shown once an admin approves it.
+func (r *Drc20Router) Meta(c *gin.Context) {
+	params := &struct {
+		auth.Signed
+		Tick         string `json:"tick"`
+		Logo         string `json:"logo"`
+		Introduction string `json:"introduction"`
+		WhitePaper   string `json:"white_paper"`
+		Official     string `json:"official"`
+		Telegram     string `json:"telegram"`
+		Discorad     string `json:"discorad"`
+		Twitter      string `json:"twitter"`
+		Facebook     string `json:"facebook"`
+		Github       string `json:"github"`
+	}{}
+
+	body, err := c.GetRawData()
+	if err == nil {
+		err = json.Unmarshal(body, params)
+	}
+	if err != nil {
+		metaResult(c, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+
+	if params.Tick == "" {
+		metaResult(c, http.StatusBadRequest, 400, "tick is required")
+		return
+	}
+
+	for _, link := range []string{params.WhitePaper, params.Official, params.Telegram, params.Discorad, params.Twitter, params.Facebook, params.Github} {
+		if len(link) > metaMaxLink {
+			metaResult(c, http.StatusBadRequest, 400, "link is too long")
+			return
+		}
+	}
+
+	if len(params.Logo) > metaMaxText || len(params.Introduction) > metaMaxText {
+		metaResult(c, http.StatusBadRequest, 400, "logo or introduction is too long")
+		return
+	}
+
+	inAddress := params.Address
+	if err := r.auth.Verify("drc20-meta", body, &params.Signed); err != nil {
+		metaResult(c, http.StatusOK, 500, err.Error())
+		return
+	}
+
+	collect := &models.Drc20Collect{}
+	err = r.dbc.DB.Where("tick = ?", params.Tick).First(collect).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			metaResult(c, http.StatusOK, 500, "tick not found")
+			return
+		}
+		metaResult(c, http.StatusInternalServerError, 500, "server error")
+		return
+	}
+
+	if collect.HolderAddress != inAddress {
+		metaResult(c, http.StatusOK, 500, "address not match")
+		return
+	}
+
+	meta := &models.Drc20Meta{
+		Tick:          params.Tick,
+		HolderAddress: inAddress,
+		Logo:          params.Logo,
+		Introduction:  params.Introduction,
+		WhitePaper:    params.WhitePaper,
+		Official:      params.Official,
+		Telegram:      params.Telegram,
+		Discorad:      params.Discorad,
+		Twitter:       params.Twitter,
+		Facebook:      params.Facebook,
+		Github:        params.Github,
+	}
+
+	if err := r.dbc.SubmitDrc20Meta(meta); err != nil {
+		metaResult(c, http.StatusInternalServerError, 500, "server error")
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = meta
+	c.JSON(http.StatusOK, result)
+}
+
+// MetaPending lists submissions for admins, the pending ones unless another status is asked for.
+func (r *Drc20Router) MetaPending(c *gin.Context) {
+	if !isAdmin(c) {
+		metaResult(c, http.StatusForbidden, 403, "admin api key required")
+		return
+	}
+
+	status := int64(models.MetaStatusPending)
+	params := &struct {
+		Tick   string `json:"tick"`
+		Status *int64 `json:"status"`
+		Limit  int    `json:"limit"`
+		OffSet int    `json:"offset"`
+	}{
+		Status: &status,
+		Limit:  10,
+		OffSet: 0,
+	}
+
+	if err := c.ShouldBindJSON(&params); err != nil {
+		metaResult(c, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+
+	metas, total, err := r.dbc.FindDrc20Metas(params.Tick, params.Status, params.Limit, params.OffSet)
+	if err != nil {
+		metaResult(c, http.StatusInternalServerError, 500, "server error")
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = metas
+	result.Total = total
+	c.JSON(http.StatusOK, result)
+}
+
+// MetaReview approves or rejects a submission, or with action revoke clears is_check of a tick.
+func (r *Drc20Router) MetaReview(c *gin.Context) {
+	if !isAdmin(c) {
+		metaResult(c, http.StatusForbidden, 403, "admin api key required")
+		return
+	}
+
+	params := &struct {
+		Id     uint   `json:"id"`
+		Tick   string `json:"tick"`
+		Action string `json:"action"`
+		Reason string `json:"reason"`
+	}{}
+
+	if err := c.ShouldBindJSON(&params); err != nil {
+		metaResult(c, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+
+	reviewer := c.GetString(ctxKeyName)
+
+	var data interface{}
+	switch params.Action {
+	case models.MetaActionApprove, models.MetaActionReject:
+		meta, err := r.dbc.ReviewDrc20Meta(params.Id, params.Action == models.MetaActionApprove, reviewer, params.Reason)
+		if err != nil {
+			if errors.Is(err, storage.ErrMetaNotFound) || errors.Is(err, storage.ErrMetaNotPending) {
+				metaResult(c, http.StatusOK, 500, err.Error())
+				return
+			}
+			metaResult(c, http.StatusInternalServerError, 500, "server error")
+			return
+		}
+		data = meta
+
+	case models.MetaActionRevoke:
+		if params.Tick == "" {
+			metaResult(c, http.StatusBadRequest, 400, "tick is required")
+			return
+		}
+		if err := r.dbc.RevokeDrc20Meta(params.Tick, reviewer, params.Reason); err != nil {
+			metaResult(c, http.StatusInternalServerError, 500, "server error")
+			return
+		}
+
+	default:
+		metaResult(c, http.StatusBadRequest, 400, "action must be approve, reject or revoke")
+		return
+	}
+
+	log.Info("router", "meta review", params.Action, "id", params.Id, "tick", params.Tick, "reviewer", reviewer, "request_id", RequestId(c))
+
+	// the collect list and the responses are cached per height, the new metadata must show before the next block
+	cacheDrc20CollectAll.Store(nil)
+	if r.cache != nil {
+		r.cache.Clear()
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = data
+	c.JSON(http.StatusOK, result)
+}
+
+// MetaHistory lists every submission and review of the metadata of a tick, latest first.
+func (r *Drc20Router) MetaHistory(c *gin.Context) {
+	params := &struct {
+		Tick   string `json:"tick"`
+		Limit  int    `json:"limit"`
+		OffSet int    `json:"offset"`
+	}{
+		Limit:  10,
+		OffSet: 0,
+	}
+
+	if err := c.ShouldBindJSON(&params); err != nil {
+		metaResult(c, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+
+	history, total, err := r.dbc.FindDrc20MetaHistory(params.Tick, params.Limit, params.OffSet)
+	if err != nil {
+		metaResult(c, http.StatusInternalServerError, 500, "server error")
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = history
+	result.Total = total
+	c.JSON(http.StatusOK, result)
+}
@@ -1,8 +1,8 @@
 package router
 
 import (
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/utils"
@@ -13,12 +13,12 @@ import (
 
 type ExchangeRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node *chain.Client
 
 	verify *verifys.Verifys
 }
 
-func NewExchangeRouter(db *storage.DBClient, node *rpcclient.Client, verify *verifys.Verifys) *ExchangeRouter {
+func NewExchangeRouter(db *storage.DBClient, node *chain.Client, verify *verifys.Verifys) *ExchangeRouter {
 	return &ExchangeRouter{
 		dbc:    db,
 		node:   node,
@@ -34,6 +34,7 @@ func (r *ExchangeRouter) Collect(c *gin.Context) {
 		Tick1         string `json:"tick1"`
 		HolderAddress string `json:"holder_address"`
 		NotDone       bool   `json:"not_done"`
+		Status        string `json:"status"`
 		Limit         int    `json:"limit"`
 		OffSet        int    `json:"offset"`
 	}
@@ -51,6 +52,16 @@ func (r *ExchangeRouter) Collect(c *gin.Context) {
 		return
 	}
 
+	switch p.Status {
+	case "", models.ExchangeStatusOpen, models.ExchangeStatusPartial, models.ExchangeStatusFilled, models.ExchangeStatusCancelled, models.ExchangeStatusExpired:
+	default:
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = "status must be open, partial, filled, cancelled or expired"
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
 	exc := make([]*models.ExchangeCollect, 0)
 	total := int64(0)
 
@@ -59,6 +70,7 @@ func (r *ExchangeRouter) Collect(c *gin.Context) {
 		Tick0:         p.Tick0,
 		Tick1:         p.Tick1,
 		HolderAddress: p.HolderAddress,
+		Status:        p.Status,
 	}
 
 	subQuery := r.dbc.DB.Model(&models.ExchangeCollect{}).
@@ -323,3 +335,103 @@ func (r *ExchangeRouter) SummaryK(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 
 }
+
+// OrderBook aggregates the open orders of a pair into price levels with cumulative depth.
+// Asks sell tick0 for tick1, bids pay tick1 for tick0, prices are in tick1 per tick0.
+func (r *ExchangeRouter) OrderBook(c *gin.Context) {
+	type params struct {
+		Tick0  string `json:"tick0"`
+		Tick1  string `json:"tick1"`
+		Levels int    `json:"levels"`
+	}
+
+	p := &params{
+		Levels: 20,
+	}
+
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	p.Tick0 = strings.ToUpper(p.Tick0)
+	p.Tick1 = strings.ToUpper(p.Tick1)
+	if p.Tick0 == "" || p.Tick1 == "" || p.Tick0 == p.Tick1 {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = "tick0 and tick1 must be two different ticks"
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	book, err := r.dbc.ExchangeOrderBook(p.Tick0, p.Tick1, p.Levels)
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = "server error"
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = book
+	c.JSON(http.StatusOK, result)
+}
+
+// Best returns the cheapest orders to trade for amt_out of tick_out paying tick_in, one trade inscription per fill.
+func (r *ExchangeRouter) Best(c *gin.Context) {
+	type params struct {
+		TickIn        string `json:"tick_in"`
+		TickOut       string `json:"tick_out"`
+		AmtOut        string `json:"amt_out"`
+		HolderAddress string `json:"holder_address"`
+	}
+
+	p := &params{}
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	p.TickIn = strings.ToUpper(p.TickIn)
+	p.TickOut = strings.ToUpper(p.TickOut)
+	if p.TickIn == "" || p.TickOut == "" || p.TickIn == p.TickOut {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = "tick_in and tick_out must be two different ticks"
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	amtOut, err := parseAmount(c, r.dbc, p.TickOut, p.AmtOut)
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = "amt_out " + err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	best, err := r.dbc.ExchangeBestFill(p.TickIn, p.TickOut, amtOut, p.HolderAddress)
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = best
+	c.JSON(http.StatusOK, result)
+}
@@ -1,10 +1,12 @@
 package router
 
 import (
-	"github.com/dogecoinw/doged/rpcclient"
+	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/unielon-org/unielon-indexer/auth"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/utils"
@@ -14,17 +16,19 @@ import (
 
 type FileRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node *chain.Client
 	ipfs *shell.Shell
+	auth *auth.Verifier
 
 	verify *verifys.Verifys
 }
 
-func NewFileRouter(db *storage.DBClient, node *rpcclient.Client, ipfs *shell.Shell, verify *verifys.Verifys) *FileRouter {
+func NewFileRouter(db *storage.DBClient, node *chain.Client, ipfs *shell.Shell, verify *verifys.Verifys) *FileRouter {
 	return &FileRouter{
 		dbc:    db,
 		node:   node,
 		ipfs:   ipfs,
+		auth:   auth.NewVerifier(db),
 		verify: verify,
 	}
 }
@@ -362,11 +366,12 @@ func (r *FileRouter) CollectionsAttributes(c *gin.Context) {
 
 }
 
+// UploadMeta creates or updates collection metadata, signed by its holder with action file-meta.
 func (r *FileRouter) UploadMeta(c *gin.Context) {
 	params := &struct {
+		auth.Signed
 		MetaId          string `json:"meta_id"`
 		InscriptionIcon string `json:"inscription_icon"`
-		SigMsg          string `json:"sig_msg"`
 		Description     string `json:"description"`
 		DiscordLink     string `json:"discord_link"`
 		Icon            string `json:"icon"`
@@ -376,12 +381,17 @@ func (r *FileRouter) UploadMeta(c *gin.Context) {
 		WebsiteLink     string `json:"website_link"`
 	}{}
 
-	if err := c.ShouldBindJSON(&params); err != nil {
+	body, err := c.GetRawData()
+	if err == nil {
+		err = json.Unmarshal(body, params)
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	inAddress, err := utils.GetAddressFromSig(params.Name, params.SigMsg)
+	inAddress := params.Address
+	err = r.auth.Verify("file-meta", body, &params.Signed)
 	if err != nil {
 		result := &utils.HttpResult{}
 		result.Code = 500
@@ -459,9 +469,10 @@ func (r *FileRouter) UploadMeta(c *gin.Context) {
 	return
 }
 
+// UploadInscriptionsMeta describes the inscriptions of a collection, signed by its holder with action file-inscriptions-meta.
 func (r *FileRouter) UploadInscriptionsMeta(c *gin.Context) {
 	params := &struct {
-		SigMsg   string `json:"sig_msg"`
+		auth.Signed
 		MetaId   string `json:"meta_id"`
 		MetaName string `json:"meta_name"`
 		Metas    []struct {
@@ -476,12 +487,17 @@ func (r *FileRouter) UploadInscriptionsMeta(c *gin.Context) {
 		} `json:"metas"`
 	}{}
 
-	if err := c.ShouldBindJSON(&params); err != nil {
+	body, err := c.GetRawData()
+	if err == nil {
+		err = json.Unmarshal(body, params)
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	inAddress, err := utils.GetAddressFromSig(params.MetaName, params.SigMsg)
+	inAddress := params.Address
+	err = r.auth.Verify("file-inscriptions-meta", body, &params.Signed)
 	if err != nil {
 		result := &utils.HttpResult{}
 		result.Code = 500
@@ -1,9 +1,9 @@
 package router
 
 import (
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/utils"
@@ -11,17 +11,18 @@ import (
 	"gorm.io/gorm"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type FileExchangeRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node *chain.Client
 	ipfs *shell.Shell
 
 	verify *verifys.Verifys
 }
 
-func NewFileExchangeRouter(db *storage.DBClient, node *rpcclient.Client, ipfs *shell.Shell, verify *verifys.Verifys) *FileExchangeRouter {
+func NewFileExchangeRouter(db *storage.DBClient, node *chain.Client, ipfs *shell.Shell, verify *verifys.Verifys) *FileExchangeRouter {
 	return &FileExchangeRouter{
 		dbc:    db,
 		node:   node,
@@ -260,8 +261,8 @@ func (r *FileExchangeRouter) SummaryAll(c *gin.Context) {
 	subQuery3 := `SELECT COUNT(DISTINCT fca.holder_address) FROM file_meta_inscription left join file_collect_address fca on file_meta_inscription.file_id = fca.file_id where file_meta_inscription.meta_id = fm.meta_id`
 
 	subQuery := r.dbc.DB.Table("file_meta fm").
-		Select("fm.name,fm.meta_id, fm.description, fm.icon, fes.lowest_ask, fes.base_volume, fes.doge_usdt, (" + subQuery1 + ") AS total, (" + subQuery2 + ") AS count, (" + subQuery3 + ") AS holder_count, fm.is_check").
-		Joins("left join file_exchange_summary fes on fm.meta_id = fes.meta_id")
+		Select("fm.name,fm.meta_id, fm.description, fm.icon, fes.lowest_ask, fes.base_volume, fes.doge_usdt, ("+subQuery1+") AS total, ("+subQuery2+") AS count, ("+subQuery3+") AS holder_count, fm.is_check").
+		Joins("left join file_exchange_summary fes on fm.meta_id = fes.meta_id AND fes.date_interval = '1d' AND fes.last_date = ?", storage.CandleDate("1d", time.Now()))
 
 	//subQuery.Where("fm.is_check = 1")
 
@@ -330,8 +331,8 @@ func (r *FileExchangeRouter) SummaryNftAll(c *gin.Context) {
 	subQuery3 := `SELECT COUNT(fca.holder_address) FROM file_meta_inscription left join file_collect_address fca on file_meta_inscription.file_id = fca.file_id where file_meta_inscription.meta_name = fm.name`
 
 	subQuery := r.dbc.DB.Table("file_meta fm").
-		Select("fm.name, fm.description, fm.icon, fes.lowest_ask, fes.base_volume, fes.doge_usdt, (" + subQuery1 + ") AS total, (" + subQuery2 + ") AS count, (" + subQuery3 + ") AS holder_count").
-		Joins("left join file_exchange_summary fes on fm.name = fes.meta_name")
+		Select("fm.name, fm.description, fm.icon, fes.lowest_ask, fes.base_volume, fes.doge_usdt, ("+subQuery1+") AS total, ("+subQuery2+") AS count, ("+subQuery3+") AS holder_count").
+		Joins("left join file_exchange_summary fes on fm.name = fes.meta_name AND fes.date_interval = '1d' AND fes.last_date = ?", storage.CandleDate("1d", time.Now()))
 
 	if p.MetaName != "" {
 		subQuery = subQuery.Where("fm.name = ?", p.MetaName)
@@ -0,0 +1,240 @@
+package router
+
+import (
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	anonymousTier = "anonymous"
+	defaultTier   = "default"
+
+	ctxKeyName  = "api_key_name"
+	ctxKeyAdmin = "api_key_admin"
+
+	guardKeyRefresh  = time.Minute
+	guardBucketSweep = 5 * time.Minute
+	guardBucketIdle  = 10 * time.Minute
+)
+
+// guardSkipPaths are probes that must answer whatever the limits are.
+var guardSkipPaths = []string{
+	"/healthz",
+	"/readyz",
+}
+
+type guardKey struct {
+	name  string
+	tier  string
+	admin bool
+}
+
+// bucket is a token bucket, it holds up to burst tokens and gains rate tokens per second.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take removes one token, or tells how long to wait until one is available.
+func (b *bucket) take(now time.Time, rate float64, burst int) (bool, time.Duration) {
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// Guard identifies callers by API key and limits their requests with the rate of their tier.
+// Callers without a key are limited per client IP with the anonymous tier, unless a key is required.
+type Guard struct {
+	dbc       *storage.DBClient
+	auth      utils.AuthConfig
+	limit     bool
+	tiers     map[string]utils.RateTierConfig
+	expensive []string
+
+	lock     *sync.Mutex
+	keys     map[string]*guardKey
+	loadedAt time.Time
+	buckets  map[string]*bucket
+	sweptAt  time.Time
+}
+
+func NewGuard(dbc *storage.DBClient, cfg utils.HttpConfig) *Guard {
+	tiers := make(map[string]utils.RateTierConfig)
+	for _, t := range cfg.RateLimit.Tiers {
+		tiers[t.Name] = t
+	}
+
+	g := &Guard{
+		dbc:       dbc,
+		auth:      cfg.Auth,
+		limit:     cfg.RateLimit.Switch,
+		tiers:     tiers,
+		expensive: cfg.RateLimit.Expensive,
+		lock:      &sync.Mutex{},
+		buckets:   make(map[string]*bucket),
+		sweptAt:   time.Now(),
+	}
+	g.loadKeys()
+	return g
+}
+
+// loadKeys reads the keys of the api_keys table and the config, a config key wins over a stored one.
+// When the table cannot be read the keys loaded before are kept.
+func (g *Guard) loadKeys() {
+	g.loadedAt = time.Now()
+
+	keys := make(map[string]*guardKey)
+	if g.dbc != nil {
+		stored, err := g.dbc.FindActiveApiKeys()
+		if err != nil {
+			log.Error("router", "FindActiveApiKeys", err)
+			if g.keys != nil {
+				return
+			}
+		}
+
+		for _, k := range stored {
+			keys[k.Key] = &guardKey{name: k.Name, tier: k.Tier, admin: k.Admin}
+		}
+	}
+
+	for _, k := range g.auth.Keys {
+		keys[k.Key] = &guardKey{name: k.Name, tier: k.Tier, admin: k.Admin}
+	}
+	g.keys = keys
+}
+
+func (g *Guard) lookup(key string) (*guardKey, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if time.Since(g.loadedAt) > guardKeyRefresh {
+		g.loadKeys()
+	}
+
+	k, ok := g.keys[key]
+	return k, ok
+}
+
+// tier falls back to the default tier for keys without a known one, and to the anonymous tier after that.
+// The config is validated to define the anonymous tier with positive rates whenever limits are on.
+func (g *Guard) tier(name string) utils.RateTierConfig {
+	if t, ok := g.tiers[name]; ok {
+		return t
+	}
+	if t, ok := g.tiers[defaultTier]; ok && name != anonymousTier {
+		return t
+	}
+	return g.tiers[anonymousTier]
+}
+
+func (g *Guard) isExpensive(path string) bool {
+	for _, p := range g.expensive {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Guard) take(id string, rate float64, burst int) (bool, time.Duration) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(g.sweptAt) > guardBucketSweep {
+		for k, b := range g.buckets {
+			if now.Sub(b.last) > guardBucketIdle {
+				delete(g.buckets, k)
+			}
+		}
+		g.sweptAt = now
+	}
+
+	b, ok := g.buckets[id]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now}
+		g.buckets[id] = b
+	}
+	return b.take(now, rate, burst)
+}
+
+func (g *Guard) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, path := range guardSkipPaths {
+			if c.Request.URL.Path == path {
+				c.Next()
+				return
+			}
+		}
+
+		key := c.GetHeader(g.auth.Header)
+		if key == "" {
+			key = c.Query("api_key")
+		}
+
+		id := "ip:" + c.ClientIP()
+		tierName := anonymousTier
+		if key != "" {
+			k, ok := g.lookup(key)
+			if !ok {
+				g.abort(c, http.StatusUnauthorized, "invalid api key")
+				return
+			}
+
+			id = "key:" + key
+			tierName = k.tier
+			if tierName == "" {
+				tierName = defaultTier
+			}
+			c.Set(ctxKeyName, k.name)
+			c.Set(ctxKeyAdmin, k.admin)
+		} else if g.auth.Switch {
+			g.abort(c, http.StatusUnauthorized, "api key required")
+			return
+		}
+
+		if !g.limit {
+			c.Next()
+			return
+		}
+
+		tier := g.tier(tierName)
+		rate, burst := tier.Rate, tier.Burst
+		if g.isExpensive(c.Request.URL.Path) {
+			id += ":expensive"
+			rate, burst = tier.ExpensiveRate, tier.ExpensiveBurst
+		}
+
+		ok, wait := g.take(id, rate, burst)
+		if !ok {
+			c.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+			g.abort(c, http.StatusTooManyRequests, "too many requests")
+			return
+		}
+		c.Next()
+	}
+}
+
+// isAdmin reports whether the request was made with an admin key.
+func isAdmin(c *gin.Context) bool {
+	return c.GetBool(ctxKeyAdmin)
+}
+
+func (g *Guard) abort(c *gin.Context, status int, msg string) {
+	result := &utils.HttpResult{}
+	result.Code = status
+	result.Msg = msg
+	c.AbortWithStatusJSON(status, result)
+}
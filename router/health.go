@@ -0,0 +1,126 @@
+package router
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/chain"
+	"github.com/unielon-org/unielon-indexer/explorer"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultReadyMaxLag = 10
+	healthPingTimeout  = 2 * time.Second
+)
+
+type HealthRouter struct {
+	dbc    *storage.DBClient
+	node   *chain.Client
+	exp    *explorer.Explorer
+	maxLag int64
+}
+
+// NewHealthRouter exp may be nil when the explorer is not running in this process.
+func NewHealthRouter(db *storage.DBClient, node *chain.Client, exp *explorer.Explorer, maxLag int64) *HealthRouter {
+	if maxLag <= 0 {
+		maxLag = defaultReadyMaxLag
+	}
+
+	return &HealthRouter{
+		dbc:    db,
+		node:   node,
+		exp:    exp,
+		maxLag: maxLag,
+	}
+}
+
+// Healthz reports that the process is alive and able to serve requests, which needs the database.
+func (r *HealthRouter) Healthz(c *gin.Context) {
+	result := &utils.HttpResult{}
+
+	err := r.pingDB(c.Request.Context())
+	if err != nil {
+		result.Code = 503
+		result.Msg = "database unreachable: " + err.Error()
+		c.JSON(http.StatusServiceUnavailable, result)
+		return
+	}
+
+	result.Code = 200
+	result.Msg = "success"
+	c.JSON(http.StatusOK, result)
+}
+
+// pingDB checks the database answers within healthPingTimeout.
+func (r *HealthRouter) pingDB(ctx context.Context) error {
+	sqlDB, err := r.dbc.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// Readyz reports whether the indexer is close enough to the chain tip to serve fresh data.
+func (r *HealthRouter) Readyz(c *gin.Context) {
+
+	data := make(map[string]interface{})
+	reasons := make([]string, 0)
+
+	maxHeight := int64(0)
+	err := r.dbc.DB.Model(&models.Block{}).Select("COALESCE(max(block_number), 0)").Scan(&maxHeight).Error
+	if err != nil {
+		reasons = append(reasons, "database unreachable: "+err.Error())
+	}
+	data["unielon_height"] = maxHeight
+
+	chainHeight, err := r.node.GetBlockCount()
+	if err != nil {
+		reasons = append(reasons, "rpc unreachable: "+err.Error())
+	}
+	data["chain_height"] = chainHeight
+	data["nodes"] = r.node.Nodes()
+
+	if err == nil {
+		lag := chainHeight - maxHeight
+		data["lag"] = lag
+		data["max_lag"] = r.maxLag
+		if lag > r.maxLag {
+			reasons = append(reasons, "indexer lag exceeds threshold")
+		}
+	}
+
+	if r.exp != nil {
+		data["leader"] = r.exp.Leading()
+		lastScan, scanErr := r.exp.LastScan()
+		if !lastScan.IsZero() {
+			data["last_scan"] = lastScan.Unix()
+		}
+
+		if scanErr != nil {
+			data["last_error"] = scanErr.Error()
+			reasons = append(reasons, "last scan failed")
+		}
+	}
+
+	result := &utils.HttpResult{}
+	if len(reasons) > 0 {
+		data["reasons"] = reasons
+		result.Code = 503
+		result.Msg = "not ready"
+		result.Data = data
+		c.JSON(http.StatusServiceUnavailable, result)
+		return
+	}
+
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = data
+	c.JSON(http.StatusOK, result)
+}
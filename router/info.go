@@ -1,9 +1,9 @@
 package router
 
 import (
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/utils"
@@ -13,13 +13,13 @@ import (
 
 type InfoRouter struct {
 	dbc    *storage.DBClient
-	node   *rpcclient.Client
+	node   *chain.Client
 	ipfs   *shell.Shell
 	level  *storage.LevelDB
 	verify *verifys.Verifys
 }
 
-func NewInfoRouter(db *storage.DBClient, node *rpcclient.Client, level *storage.LevelDB, ipfs *shell.Shell, verify *verifys.Verifys) *InfoRouter {
+func NewInfoRouter(db *storage.DBClient, node *chain.Client, level *storage.LevelDB, ipfs *shell.Shell, verify *verifys.Verifys) *InfoRouter {
 	return &InfoRouter{
 		dbc:    db,
 		node:   node,
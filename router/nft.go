@@ -1,8 +1,8 @@
 package router
 
 import (
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/utils"
@@ -12,12 +12,12 @@ import (
 
 type NftRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node *chain.Client
 
 	verify *verifys.Verifys
 }
 
-func NewNftRouter(db *storage.DBClient, node *rpcclient.Client, verify *verifys.Verifys) *NftRouter {
+func NewNftRouter(db *storage.DBClient, node *chain.Client, verify *verifys.Verifys) *NftRouter {
 	return &NftRouter{
 		dbc:    db,
 		node:   node,
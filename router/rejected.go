@@ -0,0 +1,81 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"net/http"
+)
+
+const maxRejectedLimit = 100
+
+type RejectedRouter struct {
+	dbc *storage.DBClient
+}
+
+func NewRejectedRouter(dbc *storage.DBClient) *RejectedRouter {
+	return &RejectedRouter{
+		dbc: dbc,
+	}
+}
+
+// Order lists the transactions that looked like inscriptions but were rejected by the decoder.
+func (r *RejectedRouter) Order(c *gin.Context) {
+	type params struct {
+		TxHash    string `json:"tx_hash"`
+		P         string `json:"p"`
+		ErrClass  string `json:"err_class"`
+		Status    *int64 `json:"status"`
+		FromBlock int64  `json:"from_block"`
+		ToBlock   int64  `json:"to_block"`
+		Limit     int    `json:"limit"`
+		OffSet    int    `json:"offset"`
+	}
+
+	p := &params{
+		Limit:  10,
+		OffSet: 0,
+	}
+
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	if p.Limit <= 0 || p.Limit > maxRejectedLimit {
+		p.Limit = maxRejectedLimit
+	}
+
+	filter := &storage.RejectedFilter{
+		P:         p.P,
+		ErrClass:  p.ErrClass,
+		Status:    p.Status,
+		FromBlock: p.FromBlock,
+		ToBlock:   p.ToBlock,
+		Limit:     p.Limit,
+		Offset:    p.OffSet,
+	}
+
+	if p.TxHash != "" {
+		filter.TxHashes = []string{p.TxHash}
+	}
+
+	infos, total, err := r.dbc.FindRejectedInscriptions(filter)
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = infos
+	result.Total = total
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,56 @@
+package router
+
+import (
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	RequestIdHeader = "X-Request-Id"
+	ctxRequestId    = "request_id"
+	maxRequestId    = 64
+)
+
+// RequestLog gives every request an id, the one of the X-Request-Id header when the caller sent it,
+// returns it in the same header and logs the request once it is answered.
+func RequestLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIdHeader)
+		if id == "" || len(id) > maxRequestId {
+			id = uuid.New().String()
+		}
+		c.Set(ctxRequestId, id)
+		c.Header(RequestIdHeader, id)
+
+		begin := time.Now()
+		c.Next()
+
+		ctx := []interface{}{
+			"request_id", id,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"elapsed", time.Since(begin),
+			"ip", c.ClientIP(),
+		}
+		if name := c.GetString(ctxKeyName); name != "" {
+			ctx = append(ctx, "api_key", name)
+		}
+		if len(c.Errors) > 0 {
+			ctx = append(ctx, "err", c.Errors.String())
+		}
+
+		if c.Writer.Status() >= 500 {
+			log.Warn("http", ctx...)
+			return
+		}
+		log.Debug("http", ctx...)
+	}
+}
+
+// RequestId is the id RequestLog gave the request, handlers add it to their own logs.
+func RequestId(c *gin.Context) string {
+	return c.GetString(ctxRequestId)
+}
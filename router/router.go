@@ -1,14 +1,14 @@
 package router
 
 import (
-	"github.com/dogecoinw/doged/rpcclient"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/verifys"
 )
 
 type Router struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node *chain.Client
 
 	verify *verifys.Verifys
 }
@@ -1,8 +1,8 @@
 package router
 
 import (
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/utils"
@@ -12,11 +12,11 @@ import (
 
 type StakeRouter struct {
 	dbc    *storage.DBClient
-	node   *rpcclient.Client
+	node   *chain.Client
 	verify *verifys.Verifys
 }
 
-func NewStakeRouter(db *storage.DBClient, node *rpcclient.Client, verify *verifys.Verifys) *StakeRouter {
+func NewStakeRouter(db *storage.DBClient, node *chain.Client, verify *verifys.Verifys) *StakeRouter {
 	return &StakeRouter{
 		dbc:    db,
 		node:   node,
@@ -1,8 +1,8 @@
 package router
 
 import (
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/utils"
@@ -12,12 +12,12 @@ import (
 
 type StakeV2Router struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node *chain.Client
 
 	verify *verifys.Verifys
 }
 
-func NewStakeV2Router(dbc *storage.DBClient, node *rpcclient.Client, verify *verifys.Verifys) *StakeV2Router {
+func NewStakeV2Router(dbc *storage.DBClient, node *chain.Client, verify *verifys.Verifys) *StakeV2Router {
 	return &StakeV2Router{
 		dbc:    dbc,
 		node:   node,
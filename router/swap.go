@@ -1,8 +1,8 @@
 package router
 
 import (
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/utils"
@@ -15,12 +15,12 @@ import (
 
 type SwapRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node *chain.Client
 
 	verify *verifys.Verifys
 }
 
-func NewSwapRouter(db *storage.DBClient, node *rpcclient.Client, verify *verifys.Verifys) *SwapRouter {
+func NewSwapRouter(db *storage.DBClient, node *chain.Client, verify *verifys.Verifys) *SwapRouter {
 	return &SwapRouter{
 		dbc:    db,
 		node:   node,
@@ -593,3 +593,169 @@ func (r *SwapRouter) SwapPair(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 
 }
+
+// Quote prices a swap with the same integer math the explorer executes, routing through up to three pools.
+// Exactly one of amt_in and amt_out is set, slippage is in basis points. With format=decimal the amounts
+// are read and returned in the decimals of their tick.
+func (r *SwapRouter) Quote(c *gin.Context) {
+	params := &struct {
+		TickIn   string `json:"tick_in"`
+		TickOut  string `json:"tick_out"`
+		AmtIn    string `json:"amt_in"`
+		AmtOut   string `json:"amt_out"`
+		Slippage int64  `json:"slippage"`
+	}{
+		Slippage: 50,
+	}
+
+	if err := c.ShouldBindJSON(&params); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = err.Error()
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	if params.Slippage < 0 || params.Slippage > 10000 {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = "slippage must be between 0 and 10000"
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	if (params.AmtIn == "") == (params.AmtOut == "") {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = "one of amt_in and amt_out is required"
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	tickIn := strings.ToUpper(params.TickIn)
+	tickOut := strings.ToUpper(params.TickOut)
+
+	var quote *storage.SwapQuote
+	data := make(map[string]interface{})
+	if params.AmtIn != "" {
+		amtIn, err := parseAmount(c, r.dbc, tickIn, params.AmtIn)
+		if err != nil {
+			result := &utils.HttpResult{}
+			result.Code = 400
+			result.Msg = "amt_in " + err.Error()
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
+		quote, err = r.dbc.SwapQuoteExactIn(tickIn, tickOut, amtIn)
+		if err != nil {
+			result := &utils.HttpResult{}
+			result.Code = 400
+			result.Msg = err.Error()
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
+		amtOutMin := new(big.Int).Mul(quote.AmtOut.Int(), big.NewInt(10000-params.Slippage))
+		amtOutMin.Div(amtOutMin, big.NewInt(10000))
+		data["amt_out_min"] = (*models.Number)(amtOutMin)
+	} else {
+		amtOut, err := parseAmount(c, r.dbc, tickOut, params.AmtOut)
+		if err != nil {
+			result := &utils.HttpResult{}
+			result.Code = 400
+			result.Msg = "amt_out " + err.Error()
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
+		quote, err = r.dbc.SwapQuoteExactOut(tickIn, tickOut, amtOut)
+		if err != nil {
+			result := &utils.HttpResult{}
+			result.Code = 400
+			result.Msg = err.Error()
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
+		amtInMax := new(big.Int).Mul(quote.AmtIn.Int(), big.NewInt(10000+params.Slippage))
+		amtInMax.Add(amtInMax, big.NewInt(9999))
+		amtInMax.Div(amtInMax, big.NewInt(10000))
+		data["amt_in_max"] = (*models.Number)(amtInMax)
+	}
+
+	data["tick_in"] = quote.TickIn
+	data["tick_out"] = quote.TickOut
+	data["amt_in"] = quote.AmtIn
+	data["amt_out"] = quote.AmtOut
+	data["price_impact"] = quote.PriceImpact
+	data["route"] = quote.Route
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = data
+	c.JSON(http.StatusOK, result)
+}
+
+// Position returns the liquidity positions of a holder with their deposits, fees earned, impermanent loss
+// and the share of the pool after each add and remove.
+func (r *SwapRouter) Position(c *gin.Context) {
+	params := &struct {
+		Tick0         string `json:"tick0"`
+		Tick1         string `json:"tick1"`
+		HolderAddress string `json:"holder_address"`
+		Limit         int    `json:"limit"`
+		OffSet        int    `json:"offset"`
+	}{
+		Limit:  10,
+		OffSet: 0,
+	}
+
+	if err := c.ShouldBindJSON(&params); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = err.Error()
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	if params.HolderAddress == "" {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = "holder_address is required"
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	if (params.Tick0 == "") != (params.Tick1 == "") {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = "tick0 and tick1 are required together"
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	tick := ""
+	if params.Tick0 != "" {
+		tick0, tick1, _, _, _, _ := utils.SortTokens(strings.ToUpper(params.Tick0), strings.ToUpper(params.Tick1), nil, nil, nil, nil)
+		tick = tick0 + "-SWAP-" + tick1
+	}
+
+	positions, total, err := r.dbc.FindSwapPositions(params.HolderAddress, tick, params.Limit, params.OffSet)
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = "server error"
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = positions
+	result.Total = total
+
+	c.JSON(http.StatusOK, result)
+}
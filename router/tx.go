@@ -0,0 +1,447 @@
+package router
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/doged/chaincfg"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/doged/txscript"
+	"github.com/dogecoinw/doged/wire"
+	"github.com/gin-gonic/gin"
+	"github.com/unielon-org/unielon-indexer/builder"
+	"github.com/unielon-org/unielon-indexer/chain"
+	"github.com/unielon-org/unielon-indexer/explorer"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"github.com/unielon-org/unielon-indexer/verifys"
+	"net/http"
+)
+
+type TxRouter struct {
+	dbc    *storage.DBClient
+	node   *chain.Client
+	verify *verifys.Verifys
+}
+
+func NewTxRouter(dbc *storage.DBClient, node *chain.Client, verify *verifys.Verifys) *TxRouter {
+	return &TxRouter{
+		dbc:    dbc,
+		node:   node,
+		verify: verify,
+	}
+}
+
+// TxRejection describes why a transaction would not be indexed.
+type TxRejection struct {
+	Stage string `json:"stage"`
+	P     string `json:"p"`
+	Op    string `json:"op"`
+	Err   string `json:"err"`
+}
+
+func (t *TxRejection) Error() string {
+	if t.P == "" {
+		return fmt.Sprintf("%s: %s", t.Stage, t.Err)
+	}
+	return fmt.Sprintf("%s %s %s: %s", t.Stage, t.P, t.Op, t.Err)
+}
+
+// Validate checks a raw transaction against the indexer rules without broadcasting it.
+// When amt is set the drc-20 inscriptions must carry exactly that amount, with format=decimal it is
+// read in the decimals of their tick.
+func (r *TxRouter) Validate(c *gin.Context) {
+	type params struct {
+		TxHex         string `json:"tx_hex"`
+		HolderAddress string `json:"holder_address"`
+		Amt           string `json:"amt"`
+	}
+
+	p := &params{}
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	msgTx, infos, err := r.validateTxHex(p.TxHex, p.HolderAddress)
+	if err != nil {
+		txRejectResult(c, err)
+		return
+	}
+
+	if p.Amt != "" {
+		if err := r.checkAmount(c, infos, p.Amt); err != nil {
+			txRejectResult(c, err)
+			return
+		}
+	}
+
+	data := make(map[string]interface{})
+	data["tx_hash"] = msgTx.TxHash().String()
+	data["inscriptions"] = infos
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = data
+	c.JSON(http.StatusOK, result)
+}
+
+// Broadcast validates a raw transaction and sends it to the node only if it would be indexed.
+func (r *TxRouter) Broadcast(c *gin.Context) {
+	type params struct {
+		TxHex         string `json:"tx_hex"`
+		HolderAddress string `json:"holder_address"`
+	}
+
+	p := &params{}
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	msgTx, _, err := r.validateTxHex(p.TxHex, p.HolderAddress)
+	if err != nil {
+		txRejectResult(c, err)
+		return
+	}
+
+	txhash, err := r.node.SendRawTransaction(msgTx, true)
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = "broadcast: " + err.Error()
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = txhash.String()
+	c.JSON(http.StatusOK, result)
+}
+
+// Build returns the unsigned commit and reveal transactions for an inscription.
+func (r *TxRouter) Build(c *gin.Context) {
+	p := &builder.Request{}
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	build, err := builder.Build(p)
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = err.Error()
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = build
+	c.JSON(http.StatusOK, result)
+}
+
+func txRejectResult(c *gin.Context, err error) {
+	result := &utils.HttpResult{}
+	result.Code = 400
+	result.Msg = err.Error()
+
+	rejection := &TxRejection{}
+	if errors.As(err, &rejection) {
+		result.Data = rejection
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (r *TxRouter) validateTxHex(txHex string, holderAddress string) (*wire.MsgTx, []interface{}, error) {
+
+	bytesData, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, nil, &TxRejection{Stage: "deserialize", Err: err.Error()}
+	}
+
+	msgTx := new(wire.MsgTx)
+	err = msgTx.Deserialize(bytes.NewReader(bytesData))
+	if err != nil {
+		return nil, nil, &TxRejection{Stage: "deserialize", Err: err.Error()}
+	}
+
+	infos, err := r.validateTx(msgTx, holderAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return msgTx, infos, nil
+}
+
+// validateTx runs the decoders of the explorer on the transaction, followed by the protocol verification.
+func (r *TxRouter) validateTx(msgTx *wire.MsgTx, holderAddress string) ([]interface{}, error) {
+
+	if len(msgTx.TxIn) == 0 {
+		return nil, &TxRejection{Stage: "decode", Err: "transaction has no inputs"}
+	}
+
+	decode, pushedData, err := explorer.DecodeInscription(msgTx.TxIn[0].SignatureScript)
+	if err != nil {
+		return nil, &TxRejection{Stage: "decode", Err: "not an inscription transaction: " + err.Error()}
+	}
+
+	reveal, err := r.reveal(msgTx, holderAddress)
+	if err != nil {
+		return nil, &TxRejection{Stage: "decode", P: decode.P, Op: decode.Op, Err: err.Error()}
+	}
+
+	infos := make([]interface{}, 0)
+	reject := func(stage string, err error) ([]interface{}, error) {
+		return nil, &TxRejection{Stage: stage, P: decode.P, Op: decode.Op, Err: err.Error()}
+	}
+
+	height, err := r.nextHeight()
+	if err != nil {
+		return reject("verify", err)
+	}
+
+	switch decode.P {
+	case "drc-20":
+		card, err := explorer.DecodeDrc20(pushedData, reveal)
+		if err != nil {
+			return reject("decode", err)
+		}
+
+		card.BlockNumber = height
+		if err := r.verify.VerifyDrc20(card); err != nil {
+			return reject("verify", err)
+		}
+		infos = append(infos, card)
+
+	case "pair-v1":
+		scripts := make([][]byte, 0, len(msgTx.TxIn))
+		for _, in := range msgTx.TxIn {
+			scripts = append(scripts, in.SignatureScript)
+		}
+
+		swaps, err := explorer.DecodeSwaps(scripts, reveal, r.verify, height)
+		if err != nil {
+			return reject("decode", err)
+		}
+
+		dbtx := r.dbc.DB.Begin()
+		defer dbtx.Rollback()
+		for _, swap := range swaps {
+			swap.BlockNumber = height
+			if err := r.verify.VerifySwap(dbtx, swap); err != nil {
+				return reject("verify", err)
+			}
+			infos = append(infos, swap)
+		}
+
+	case "wdoge":
+		wdoge, err := explorer.DecodeWDoge(pushedData, reveal)
+		if err != nil {
+			return reject("decode", err)
+		}
+
+		wdoge.BlockNumber = height
+		if err := r.verify.VerifyWDoge(wdoge); err != nil {
+			return reject("verify", err)
+		}
+		infos = append(infos, wdoge)
+
+	case "file":
+		scripts := make([][]byte, 0, len(msgTx.TxIn))
+		for _, in := range msgTx.TxIn {
+			scripts = append(scripts, in.SignatureScript)
+		}
+
+		inscription, err := explorer.DecodeFileInscription(scripts)
+		if err != nil {
+			return reject("decode", err)
+		}
+
+		file, err := explorer.DecodeFile(inscription, reveal)
+		if err != nil {
+			return reject("decode", err)
+		}
+
+		file.BlockNumber = height
+		if err := r.verify.VerifyFile(file); err != nil {
+			return reject("verify", err)
+		}
+
+		file.FileData = nil
+		infos = append(infos, file)
+
+	case "stake-v1":
+		stake, err := explorer.DecodeStake(pushedData, reveal)
+		if err != nil {
+			return reject("decode", err)
+		}
+
+		stake.BlockNumber = height
+		if err := r.verify.VerifyStake(stake); err != nil {
+			return reject("verify", err)
+		}
+		infos = append(infos, stake)
+
+	case "order-v1":
+		ex, err := explorer.DecodeExchange(pushedData, reveal, r.verify, height)
+		if err != nil {
+			return reject("decode", err)
+		}
+
+		ex.BlockNumber = height
+		if err := r.verify.VerifyExchange(ex); err != nil {
+			return reject("verify", err)
+		}
+		infos = append(infos, ex)
+
+	case "order-v2":
+		ex, err := explorer.DecodeFileExchange(pushedData, reveal, r.dbc.DB)
+		if err != nil {
+			return reject("decode", err)
+		}
+
+		ex.BlockNumber = height
+		if err := r.verify.VerifyFileExchange(ex); err != nil {
+			return reject("verify", err)
+		}
+		infos = append(infos, ex)
+
+	case "box-v1":
+		box, err := explorer.DecodeBox(pushedData, reveal)
+		if err != nil {
+			return reject("decode", err)
+		}
+
+		box.BlockNumber = height
+		if err := r.verify.VerifyBox(box); err != nil {
+			return reject("verify", err)
+		}
+		infos = append(infos, box)
+
+	case "cross":
+		cross, err := explorer.DecodeCross(pushedData, reveal, r.verify, height)
+		if err != nil {
+			return reject("decode", err)
+		}
+
+		cross.BlockNumber = height
+		if err := r.verify.VerifyCross(cross); err != nil {
+			return reject("verify", err)
+		}
+		infos = append(infos, cross)
+
+	default:
+		return reject("decode", errors.New("unknown protocol"))
+	}
+
+	return infos, nil
+}
+
+// reveal reads msgTx for the decoders of the explorer, values are converted to doge as the node reports them.
+func (r *TxRouter) reveal(msgTx *wire.MsgTx, holderAddress string) (*explorer.Reveal, error) {
+	reveal := &explorer.Reveal{Hash: msgTx.TxHash().String(), Outs: make([]*explorer.TxOut, 0, len(msgTx.TxOut))}
+	for i, out := range msgTx.TxOut {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(out.PkScript, &chaincfg.MainNetParams)
+		if err != nil {
+			return nil, fmt.Errorf("output %d: %s", i, err.Error())
+		}
+
+		address := ""
+		if len(addrs) > 0 {
+			address = addrs[0].EncodeAddress()
+		}
+
+		reveal.Outs = append(reveal.Outs, &explorer.TxOut{Address: address, Value: float64(out.Value) / 1e8})
+	}
+
+	reveal.Sender = func(index int) (string, error) {
+		if index >= len(msgTx.TxIn) {
+			return "", fmt.Errorf("input %d does not exist", index)
+		}
+		return r.holderAddress(msgTx.TxIn[index], holderAddress)
+	}
+
+	return reveal, nil
+}
+
+// holderAddress returns the address that funded the commit transaction spent by in.
+// The given address is used as is, so callers can validate before the commit transaction is broadcast.
+func (r *TxRouter) holderAddress(in *wire.TxIn, holderAddress string) (string, error) {
+	if holderAddress != "" {
+		return holderAddress, nil
+	}
+
+	commitTx, err := r.node.GetRawTransactionVerboseBool(&in.PreviousOutPoint.Hash)
+	if err != nil {
+		return "", fmt.Errorf("commit transaction not found, pass holder_address to validate before broadcasting it: %s", err.Error())
+	}
+
+	if len(commitTx.Vin) < 1 {
+		return "", errors.New("commit transaction has no inputs")
+	}
+
+	fundHash, err := chainhash.NewHashFromStr(commitTx.Vin[0].Txid)
+	if err != nil {
+		return "", err
+	}
+
+	fundTx, err := r.node.GetRawTransactionVerboseBool(fundHash)
+	if err != nil {
+		return "", fmt.Errorf("funding transaction not found: %s", err.Error())
+	}
+
+	index := commitTx.Vin[0].Vout
+	if int(index) >= len(fundTx.Vout) || len(fundTx.Vout[index].ScriptPubKey.Addresses) < 1 {
+		return "", errors.New("funding output has no address")
+	}
+
+	return fundTx.Vout[index].ScriptPubKey.Addresses[0], nil
+}
+
+// checkAmount compares the amount of the drc-20 inscriptions with the amount the caller meant to inscribe.
+func (r *TxRouter) checkAmount(c *gin.Context, infos []interface{}, amt string) error {
+	for _, info := range infos {
+		card, ok := info.(*models.Drc20Info)
+		if !ok || card.Op == "deploy" {
+			continue
+		}
+
+		expected, err := parseAmount(c, r.dbc, card.Tick, amt)
+		if err != nil {
+			return &TxRejection{Stage: "amount", P: card.P, Op: card.Op, Err: "amt " + err.Error()}
+		}
+
+		if card.Amt.Int().Cmp(expected) != 0 {
+			return &TxRejection{Stage: "amount", P: card.P, Op: card.Op, Err: fmt.Sprintf("the inscription amount %s is not %s", card.Amt.String(), expected.String())}
+		}
+	}
+	return nil
+}
+
+// nextHeight is the height a transaction broadcast now is mined at the earliest.
+func (r *TxRouter) nextHeight() (int64, error) {
+	maxHeight := int64(0)
+	err := r.dbc.DB.Model(&models.Block{}).Select("COALESCE(max(block_number), 0)").Scan(&maxHeight).Error
+	if err != nil {
+		return 0, fmt.Errorf("find max block err %s", err.Error())
+	}
+	return maxHeight + 1, nil
+}
@@ -0,0 +1,280 @@
+package router
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dogecoinw/doged/btcec"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/btcutil"
+	"github.com/dogecoinw/doged/chaincfg"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/doged/txscript"
+	"github.com/dogecoinw/doged/wire"
+	"github.com/unielon-org/unielon-indexer/builder"
+	"github.com/unielon-org/unielon-indexer/chain"
+	"github.com/unielon-org/unielon-indexer/explorer"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"github.com/unielon-org/unielon-indexer/verifys"
+)
+
+// testNode serves block 0 and one block per reveal transaction, with the commit and funding transactions of each.
+type testNode struct {
+	blocks []*btcjson.GetBlockVerboseResult
+	txs    map[string]*btcjson.TxRawResult
+}
+
+func testAddress(t *testing.T) (btcutil.Address, []byte) {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pubKey := key.PubKey().SerializeCompressed()
+	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return address, pubKey
+}
+
+func testRawOut(address string, value float64) btcjson.Vout {
+	return btcjson.Vout{Value: value, ScriptPubKey: btcjson.ScriptPubKeyResult{Addresses: []string{address}}}
+}
+
+// reveal returns the reveal transaction of payload by the holder of pubKey paying outs, in koinu, and mines it.
+func (n *testNode) reveal(t *testing.T, name string, holder btcutil.Address, pubKey []byte, payload string, outs map[btcutil.Address]int64, order ...btcutil.Address) *wire.MsgTx {
+	fund := chainhash.DoubleHashH([]byte(name + "-fund"))
+	n.txs[fund.String()] = &btcjson.TxRawResult{Txid: fund.String(), Hash: fund.String(), Vout: []btcjson.Vout{testRawOut(holder.EncodeAddress(), 1)}}
+
+	commit := chainhash.DoubleHashH([]byte(name + "-commit"))
+	n.txs[commit.String()] = &btcjson.TxRawResult{Txid: commit.String(), Hash: commit.String(), Vin: []btcjson.Vin{{Txid: fund.String()}}, Vout: []btcjson.Vout{testRawOut(holder.EncodeAddress(), 0.1)}}
+
+	redeem, err := builder.InscriptionScript(pubKey, []byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := builder.SignatureScript(make([]byte, 72), redeem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&commit, 0), sig, nil))
+	for _, address := range order {
+		script, err := txscript.PayToAddrScript(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgTx.AddTxOut(wire.NewTxOut(outs[address], script))
+	}
+
+	hash := msgTx.TxHash().String()
+	txv := &btcjson.TxRawResult{
+		Txid: hash,
+		Hash: hash,
+		Vin:  []btcjson.Vin{{Txid: commit.String(), ScriptSig: &btcjson.ScriptSig{Hex: hex.EncodeToString(sig)}}},
+	}
+	for _, out := range msgTx.TxOut {
+		_, addrs, _, _ := txscript.ExtractPkScriptAddrs(out.PkScript, &chaincfg.MainNetParams)
+		txv.Vout = append(txv.Vout, testRawOut(addrs[0].EncodeAddress(), float64(out.Value)/1e8))
+	}
+
+	prev := n.blocks[len(n.blocks)-1]
+	block := &btcjson.GetBlockVerboseResult{
+		Hash:         chainhash.DoubleHashH([]byte(prev.Hash + hash)).String(),
+		PreviousHash: prev.Hash,
+		Height:       int64(len(n.blocks)),
+		Time:         1700000000 + int64(len(n.blocks))*60,
+		Tx:           []string{hash},
+	}
+	txv.BlockHash = block.Hash
+	n.txs[hash] = txv
+	n.blocks = append(n.blocks, block)
+	return msgTx
+}
+
+func (n *testNode) serve(t *testing.T) *chain.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &struct {
+			Id     interface{}       `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}{}
+		json.NewDecoder(r.Body).Decode(req)
+
+		var result interface{}
+		switch req.Method {
+		case "getblockcount":
+			result = len(n.blocks)
+		case "getblockhash":
+			var height int
+			json.Unmarshal(req.Params[0], &height)
+			result = n.blocks[height].Hash
+		case "getblock":
+			var hash string
+			json.Unmarshal(req.Params[0], &hash)
+			for _, block := range n.blocks {
+				if block.Hash == hash {
+					result = block
+				}
+			}
+		case "getrawtransaction":
+			var txid string
+			json.Unmarshal(req.Params[0], &txid)
+			result = n.txs[txid]
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": req.Id, "result": result, "error": nil})
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := chain.NewClient(utils.ChainConfig{Rpc: strings.TrimPrefix(srv.URL, "http://"), UserName: "user", PassWord: "pass", Timeout: 5, Retries: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Shutdown)
+	return client
+}
+
+// newTestDB indexes block 0 of the node and funds holder with tick.
+func newTestDB(t *testing.T, n *testNode, tick, holder string) *storage.DBClient {
+	dbc, err := storage.NewSqliteClient(utils.SqliteConfig{Database: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(dbc.Stop)
+
+	err = dbc.DB.AutoMigrate(&models.Block{}, &models.Drc20Collect{}, &models.Drc20CollectAddress{}, &models.Drc20Revert{},
+		&models.SwapLiquidity{}, &models.SwapSummary{}, &models.SwapSummaryLiquidity{},
+		&models.ExchangeCollect{}, &models.ExchangeRevert{}, &models.ExchangeSummary{}, &models.FileExchangeCollect{}, &models.FileExchangeRevert{}, &models.FileExchangeSummary{},
+		&models.BoxCollect{}, &models.BoxCollectAddress{}, &models.BoxRevert{},
+		&models.Drc20Info{}, &models.SwapInfo{}, &models.WDogeInfo{}, &models.FileInfo{}, &models.StakeInfo{},
+		&models.ExchangeInfo{}, &models.FileExchangeInfo{}, &models.BoxInfo{}, &models.CrossInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dbc.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a zero primary key is taken as unset by a create
+	if err := dbc.DB.Exec("INSERT INTO block (block_number, block_hash) VALUES (0, ?)", n.blocks[0].Hash).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	err = dbc.DB.Create(&models.Drc20Collect{Tick: tick, AmtSum: models.NewNumber(0), Max: models.NewNumber(1e15), Lim: models.NewNumber(1e15), Dec: 8, HolderAddress: holder}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dbc.MintDrc20(dbc.DB, tick, holder, models.NewNumber(1e13).Int(), "mint-"+holder, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	return dbc
+}
+
+// indexed tells how the explorer took the drc-20 transaction hash, nil when it was executed.
+func indexed(t *testing.T, dbc *storage.DBClient, hash string) error {
+	card := &models.Drc20Info{}
+	if err := dbc.DB.Where("tx_hash = ?", hash).Limit(1).Find(card).Error; err != nil {
+		t.Fatal(err)
+	}
+	if card.TxHash != "" {
+		if card.OrderStatus != 0 {
+			return errors.New(card.ErrInfo)
+		}
+		return nil
+	}
+
+	rejected := &models.RejectedInscription{}
+	if err := dbc.DB.Where("tx_hash = ?", hash).First(rejected).Error; err != nil {
+		t.Fatalf("%s neither indexed nor rejected: %s", hash, err)
+	}
+	return errors.New(rejected.ErrInfo)
+}
+
+// TestValidateTxExplorer checks that validateTx accepts what the explorer indexes and rejects what it does not.
+func TestValidateTxExplorer(t *testing.T) {
+	holder, pubKey := testAddress(t)
+	receiver, _ := testAddress(t)
+
+	n := &testNode{txs: make(map[string]*btcjson.TxRawResult)}
+	n.blocks = append(n.blocks, &btcjson.GetBlockVerboseResult{Hash: chainhash.DoubleHashH([]byte("block0")).String(), Tx: []string{}})
+
+	tests := []struct {
+		name  string
+		data  string
+		outs  map[btcutil.Address]int64
+		order []btcutil.Address
+		valid bool
+	}{
+		{
+			name:  "transfer",
+			data:  `{"p":"drc-20","op":"transfer","tick":"AAAA","amt":"100"}`,
+			outs:  map[btcutil.Address]int64{receiver: 100000, holder: 500000},
+			order: []btcutil.Address{receiver, holder},
+			valid: true,
+		},
+		{
+			name:  "burn to the holder",
+			data:  `{"p":"drc-20","op":"burn","tick":"AAAA","amt":"100"}`,
+			outs:  map[btcutil.Address]int64{holder: 100000, receiver: 500000},
+			order: []btcutil.Address{holder, receiver},
+		},
+		{
+			name:  "mint of a part of 0.001 doge",
+			data:  `{"p":"drc-20","op":"mint","tick":"AAAA","amt":"100"}`,
+			outs:  map[btcutil.Address]int64{holder: 150000},
+			order: []btcutil.Address{holder},
+		},
+	}
+
+	txs := make([]*wire.MsgTx, 0, len(tests))
+	for _, tt := range tests {
+		txs = append(txs, n.reveal(t, tt.name, holder, pubKey, tt.data, tt.outs, tt.order...))
+	}
+
+	node := n.serve(t)
+	dbc := newTestDB(t, n, "AAAA", holder.EncodeAddress())
+	verify := verifys.NewVerifys(dbc, utils.ActivationConfig{Drc20Burn: 1}, utils.SwapConfig{})
+
+	// the router validates against the state before the blocks, the explorer indexes them afterwards
+	r := NewTxRouter(dbc, node, verify)
+	routed := make([]error, len(txs))
+	for i, msgTx := range txs {
+		_, routed[i] = r.validateTx(msgTx, "")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	e := explorer.NewExplorer(ctx, &sync.WaitGroup{}, node, dbc, nil, verify, 1)
+	e.OnBlock(func(height int64) {
+		if height == int64(len(n.blocks)-1) {
+			cancel()
+		}
+	})
+	e.Lead(ctx)
+	if _, err := e.LastScan(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tt := range tests {
+		explored := indexed(t, dbc, txs[i].TxHash().String())
+		if (routed[i] == nil) != tt.valid || (explored == nil) != tt.valid {
+			t.Errorf("%s: router err %v, explorer err %v, valid %t", tt.name, routed[i], explored, tt.valid)
+		}
+	}
+}
@@ -3,11 +3,11 @@ package router_v3
 import (
 	"bytes"
 	"encoding/hex"
-	"fmt"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/dogecoinw/doged/wire"
+	"github.com/dogecoinw/go-dogecoin/log"
 	"github.com/gin-gonic/gin"
 	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/unielon-org/unielon-indexer/chain"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/storage"
 	"github.com/unielon-org/unielon-indexer/storage_v3"
@@ -20,21 +20,21 @@ import (
 type Router struct {
 	mysql *storage_v3.MysqlClient
 	dbc   *storage.DBClient
-	node  *rpcclient.Client
+	node  *chain.Client
 	level *storage.LevelDB
 	ipfs  *shell.Shell
 
 	verify *verifys.Verifys
 }
 
-func NewRouter(mysql *storage_v3.MysqlClient, dbc *storage.DBClient, level *storage.LevelDB, node *rpcclient.Client, ipfs *shell.Shell) *Router {
+func NewRouter(mysql *storage_v3.MysqlClient, dbc *storage.DBClient, level *storage.LevelDB, node *chain.Client, ipfs *shell.Shell, verify *verifys.Verifys) *Router {
 	return &Router{
 		mysql:  mysql,
 		node:   node,
 		ipfs:   ipfs,
 		level:  level,
 		dbc:    dbc,
-		verify: verifys.NewVerifys(dbc),
+		verify: verify,
 	}
 }
 
@@ -83,7 +83,7 @@ func (r *Router) TxBroadcast(c *gin.Context) {
 	msgTx := new(wire.MsgTx)
 	err = msgTx.Deserialize(bytes.NewReader(bytesData))
 	if err != nil {
-		fmt.Println(err)
+		log.Debug("router", "TxBroadcast", err)
 		result := &utils.HttpResult{}
 		result.Code = 500
 		result.Msg = err.Error()
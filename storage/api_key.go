@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+)
+
+// FindActiveApiKeys returns every key that has not been revoked.
+func (conn *DBClient) FindActiveApiKeys() ([]*models.ApiKey, error) {
+	keys := make([]*models.ApiKey, 0)
+	err := conn.DB.Where("status = ?", models.ApiKeyStatusActive).Find(&keys).Error
+	if err != nil {
+		return nil, fmt.Errorf("FindActiveApiKeys err: %s", err.Error())
+	}
+	return keys, nil
+}
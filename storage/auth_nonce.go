@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+	"gorm.io/gorm/clause"
+)
+
+// UseNonce records the nonce of a signed request, it returns false when the address already used it.
+func (conn *DBClient) UseNonce(n *models.AuthNonce) (bool, error) {
+	result := conn.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(n)
+	if result.Error != nil {
+		return false, fmt.Errorf("UseNonce err: %s", result.Error.Error())
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// PruneNonces deletes the nonces whose request expired before expiry, they can no longer be replayed.
+func (conn *DBClient) PruneNonces(expiry int64) error {
+	err := conn.DB.Where("expiry < ?", expiry).Delete(&models.AuthNonce{}).Error
+	if err != nil {
+		return fmt.Errorf("PruneNonces err: %s", err.Error())
+	}
+	return nil
+}
@@ -137,7 +137,7 @@ func (c *DBClient) BoxFinish(tx *gorm.DB, boxc *models.BoxCollect, height int64)
 	swap.Amt0Out = swap.Amt0
 	swap.Amt1Out = swap.Amt1
 
-	err := c.SwapCreate(tx, swap)
+	err := c.SwapCreate(tx, swap, "")
 	if err != nil {
 		return fmt.Errorf("swapCreate SwapCreate error: %v", err)
 	}
@@ -0,0 +1,659 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/oracle"
+	"github.com/unielon-org/unielon-indexer/utils"
+	"gorm.io/gorm"
+	"math/big"
+	"time"
+)
+
+// CandleIntervals are the candle sizes kept in swap_summary, swap_summary_liquidity, exchange_summary and
+// file_exchange_summary.
+var CandleIntervals = []string{"1m", "5m", "1h", "4h", "1d", "1w"}
+
+const (
+	candleLayout = "2006-01-02 15:04:05"
+	candleDoge   = "WDOGE(WRAPPED-DOGE)"
+)
+
+// CandleStart returns the start of the candle of interval that t falls into, days and weeks follow
+// UTC so that every indexer writes the same candles, and a week starts on monday.
+func CandleStart(interval string, t time.Time) time.Time {
+	t = t.UTC()
+	y, m, d := t.Date()
+	switch interval {
+	case "1m":
+		return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, time.UTC)
+	case "5m":
+		return time.Date(y, m, d, t.Hour(), t.Minute()/5*5, 0, 0, time.UTC)
+	case "1h":
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, time.UTC)
+	case "4h":
+		return time.Date(y, m, d, t.Hour()/4*4, 0, 0, 0, time.UTC)
+	case "1d":
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	default:
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+}
+
+// CandleDate returns the last_date of the candle of interval that t falls into.
+func CandleDate(interval string, t time.Time) string {
+	return CandleStart(interval, t).Format(candleLayout)
+}
+
+// CandleBlock adds the swaps, liquidity changes, exchange trades and file trades of the block at height to the candles.
+// It runs once the transactions of the block are executed, so the pools hold the reserves at its end.
+func (e *DBClient) CandleBlock(tx *gorm.DB, height int64, blockTime int64, prices oracle.PriceOracle) error {
+	book := newCandleBook(tx, nil, prices)
+	err := book.apply(height, height, map[int64]int64{height: blockTime})
+	if err != nil {
+		return fmt.Errorf("CandleBlock err: %s height: %d", err.Error(), height)
+	}
+	return nil
+}
+
+// CandleRevert rebuilds the candles of the blocks above height. Every candle that starts at or after the
+// earliest of those blocks is deleted and the blocks up to height that fall into it are applied again,
+// the liquidity of a rebuilt candle is taken from the reverted pools. The DOGE price of a candle is the price
+// at its start, so a rebuilt candle gets the same price again.
+func (e *DBClient) CandleRevert(tx *gorm.DB, height int64, prices oracle.PriceOracle) error {
+	first := int64(0)
+	err := tx.Model(&models.Block{}).Select("COALESCE(MIN(block_time), 0)").
+		Where("block_number > ? AND block_time > 0", height).Scan(&first).Error
+	if err != nil {
+		return fmt.Errorf("CandleRevert err: %s", err.Error())
+	}
+
+	if first == 0 {
+		return nil
+	}
+
+	starts := make(map[string]time.Time)
+	earliest := time.Unix(first, 0)
+	for _, interval := range CandleIntervals {
+		start := CandleStart(interval, time.Unix(first, 0))
+		starts[interval] = start
+		if start.Before(earliest) {
+			earliest = start
+		}
+
+		for _, model := range []interface{}{&models.SwapSummary{}, &models.SwapSummaryLiquidity{}, &models.ExchangeSummary{}, &models.FileExchangeSummary{}} {
+			err = tx.Where("date_interval = ? AND last_date >= ?", interval, start.Format(candleLayout)).Delete(model).Error
+			if err != nil {
+				return fmt.Errorf("CandleRevert delete err: %s", err.Error())
+			}
+		}
+	}
+
+	blocks := make([]*models.Block, 0)
+	err = tx.Select("block_number", "block_time").
+		Where("block_number <= ? AND block_time >= ?", height, earliest.Unix()).Find(&blocks).Error
+	if err != nil {
+		return fmt.Errorf("CandleRevert blocks err: %s", err.Error())
+	}
+
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	from := height
+	times := make(map[int64]int64)
+	for _, block := range blocks {
+		times[block.BlockNumber] = block.BlockTime
+		if block.BlockNumber < from {
+			from = block.BlockNumber
+		}
+	}
+
+	book := newCandleBook(tx, starts, prices)
+	err = book.apply(from, height, times)
+	if err != nil {
+		return fmt.Errorf("CandleRevert err: %s height: %d", err.Error(), height)
+	}
+	return nil
+}
+
+// candleBook collects the candles touched by a range of blocks until they are saved.
+type candleBook struct {
+	tx *gorm.DB
+	// after limits a rebuild to the candles of each interval that start at or after it
+	after map[string]time.Time
+	// prices is nil when no DOGE price is recorded
+	prices oracle.PriceOracle
+	usd    map[string]float64
+
+	decs      map[string]uint
+	pools     map[string]*models.SwapLiquidity
+	swaps     map[string]*models.SwapSummary
+	liquidity map[string]*models.SwapSummaryLiquidity
+	exchanges map[string]*models.ExchangeSummary
+	files     map[string]*models.FileExchangeSummary
+	order     []interface{}
+}
+
+func newCandleBook(tx *gorm.DB, after map[string]time.Time, prices oracle.PriceOracle) *candleBook {
+	return &candleBook{
+		tx:        tx,
+		after:     after,
+		prices:    prices,
+		usd:       make(map[string]float64),
+		decs:      make(map[string]uint),
+		pools:     make(map[string]*models.SwapLiquidity),
+		swaps:     make(map[string]*models.SwapSummary),
+		liquidity: make(map[string]*models.SwapSummaryLiquidity),
+		exchanges: make(map[string]*models.ExchangeSummary),
+		files:     make(map[string]*models.FileExchangeSummary),
+	}
+}
+
+// apply adds the events of the blocks from..to that have a time in times and saves the candles.
+func (b *candleBook) apply(from, to int64, times map[int64]int64) error {
+	infos := make([]*models.SwapInfo, 0)
+	err := b.tx.Where("block_number >= ? AND block_number <= ? AND order_status = 0", from, to).
+		Order("id").Find(&infos).Error
+	if err != nil {
+		return fmt.Errorf("swap info err: %s", err.Error())
+	}
+
+	trades := make([]*models.ExchangeRevert, 0)
+	err = b.tx.Where("op = 'trade' AND block_number >= ? AND block_number <= ?", from, to).
+		Order("id").Find(&trades).Error
+	if err != nil {
+		return fmt.Errorf("exchange revert err: %s", err.Error())
+	}
+
+	orders := make(map[string]*models.ExchangeCollect)
+	if len(trades) > 0 {
+		exIds := make([]string, 0, len(trades))
+		for _, trade := range trades {
+			exIds = append(exIds, trade.ExId)
+		}
+
+		collects := make([]*models.ExchangeCollect, 0)
+		err = b.tx.Select("ex_id", "tick0", "tick1").Where("ex_id in ?", exIds).Find(&collects).Error
+		if err != nil {
+			return fmt.Errorf("exchange collect err: %s", err.Error())
+		}
+
+		for _, collect := range collects {
+			orders[collect.ExId] = collect
+		}
+	}
+
+	for _, info := range infos {
+		blockTime, ok := times[info.BlockNumber]
+		if !ok {
+			continue
+		}
+
+		switch info.Op {
+		case "swap":
+			err = b.swap(time.Unix(blockTime, 0), info)
+		case "create", "add", "remove":
+			err = b.pool(time.Unix(blockTime, 0), info.Tick0, info.Tick1, nil, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, trade := range trades {
+		blockTime, ok := times[trade.BlockNumber]
+		order := orders[trade.ExId]
+		if !ok || order == nil {
+			continue
+		}
+
+		err = b.trade(time.Unix(blockTime, 0), order.Tick0, order.Tick1, trade.Amt0.Int(), trade.Amt1.Int())
+		if err != nil {
+			return err
+		}
+	}
+
+	err = b.fileTrades(from, to, times)
+	if err != nil {
+		return err
+	}
+
+	now := models.LocalTime(time.Now().Unix())
+	for _, candle := range b.order {
+		switch c := candle.(type) {
+		case *models.SwapSummaryLiquidity:
+			c.UpdateDate = now
+		case *models.ExchangeSummary:
+			c.UpdateDate = now
+		case *models.FileExchangeSummary:
+			c.UpdateDate = now
+		}
+
+		err = b.tx.Save(candle).Error
+		if err != nil {
+			return fmt.Errorf("save candle err: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// swap adds a swap of amt0 tick0 for amt1_out tick1 to its pool candles and, when the pool holds
+// WDOGE, to the candles of the token priced in WDOGE.
+func (b *candleBook) swap(at time.Time, info *models.SwapInfo) error {
+	if info.Amt0 == nil || info.Amt1Out == nil {
+		return nil
+	}
+
+	amtIn, amtOut := info.Amt0.Int(), info.Amt1Out.Int()
+	err := b.pool(at, info.Tick0, info.Tick1, amtIn, amtOut)
+	if err != nil {
+		return err
+	}
+
+	var token string
+	var dogeAmt, tokenAmt *big.Int
+	switch candleDoge {
+	case info.Tick0:
+		token, dogeAmt, tokenAmt = info.Tick1, amtIn, amtOut
+	case info.Tick1:
+		token, dogeAmt, tokenAmt = info.Tick0, amtOut, amtIn
+	default:
+		return nil
+	}
+
+	price, err := b.price(token, tokenAmt, candleDoge, dogeAmt)
+	if err != nil {
+		return err
+	}
+
+	for _, interval := range CandleIntervals {
+		date, ok := b.date(interval, at)
+		if !ok {
+			continue
+		}
+
+		key := token + "|" + interval + "|" + date
+		c := b.swaps[key]
+		if c == nil {
+			c = &models.SwapSummary{}
+			found, err := b.find(c, "tick = ? AND date_interval = ? AND last_date = ?", token, interval, date)
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				c = &models.SwapSummary{Tick: token, Tick0: candleDoge, Tick1: token, LastDate: date, DateInterval: interval}
+				candleOpen(price, &c.OpenPrice, &c.ClosePrice, &c.LowestAsk, &c.HighestBid)
+			}
+			b.swaps[key] = c
+			b.order = append(b.order, c)
+		}
+
+		if c.DogeUsdt == 0 {
+			c.DogeUsdt = b.dogeUsd(date)
+		}
+
+		candleMove(price, &c.ClosePrice, &c.LowestAsk, &c.HighestBid)
+		c.BaseVolume = candleAdd(c.BaseVolume, dogeAmt)
+	}
+	return nil
+}
+
+// pool moves the candles of the pool of tick0 and tick1 by a swap of amtIn tick0 for amtOut tick1,
+// a nil amtIn only updates the liquidity.
+func (b *candleBook) pool(at time.Time, tick0, tick1 string, amtIn, amtOut *big.Int) error {
+	t0, t1, _, _, _, _ := utils.SortTokens(tick0, tick1, nil, nil, nil, nil)
+	pool := b.pools[t0+"|"+t1]
+	if pool == nil {
+		pool = &models.SwapLiquidity{}
+		found, err := b.find(pool, "tick0 = ? AND tick1 = ?", t0, t1)
+		if err != nil || !found {
+			return err
+		}
+		b.pools[t0+"|"+t1] = pool
+	}
+
+	amt0, amt1 := pool.Amt0.Int(), pool.Amt1.Int()
+	if amtIn != nil {
+		amt0, amt1 = amtIn, amtOut
+		if tick0 != t0 {
+			amt0, amt1 = amtOut, amtIn
+		}
+	}
+
+	price, err := b.price(t0, amt0, t1, amt1)
+	if err != nil {
+		return err
+	}
+
+	liquidity := float64(0)
+	switch candleDoge {
+	case t0:
+		liquidity, err = b.amount(t0, pool.Amt0.Int())
+	case t1:
+		liquidity, err = b.amount(t1, pool.Amt1.Int())
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, interval := range CandleIntervals {
+		date, ok := b.date(interval, at)
+		if !ok {
+			continue
+		}
+
+		key := pool.Tick + "|" + interval + "|" + date
+		c := b.liquidity[key]
+		if c == nil {
+			c = &models.SwapSummaryLiquidity{}
+			found, err := b.find(c, "tick = ? AND date_interval = ? AND last_date = ?", pool.Tick, interval, date)
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				c = &models.SwapSummaryLiquidity{Tick: pool.Tick, Tick0: t0, Tick1: t1, LastDate: date, DateInterval: interval}
+				candleOpen(price, &c.OpenPrice, &c.ClosePrice, &c.LowestAsk, &c.HighestBid)
+			}
+			b.liquidity[key] = c
+			b.order = append(b.order, c)
+		}
+
+		if c.DogeUsdt == 0 {
+			c.DogeUsdt = b.dogeUsd(date)
+		}
+
+		c.Liquidity = liquidity
+		if amtIn != nil {
+			candleMove(price, &c.ClosePrice, &c.LowestAsk, &c.HighestBid)
+			c.BaseVolume = candleAdd(c.BaseVolume, amt0)
+			c.QuoteVolume = candleAdd(c.QuoteVolume, amt1)
+		} else {
+			c.BaseVolume = candleAdd(c.BaseVolume, nil)
+			c.QuoteVolume = candleAdd(c.QuoteVolume, nil)
+		}
+	}
+	return nil
+}
+
+// trade adds an exchange trade that bought amt0 tick0 of an order for amt1 tick1.
+func (b *candleBook) trade(at time.Time, tick0, tick1 string, amt0, amt1 *big.Int) error {
+	t0, t1, _, _, _, _ := utils.SortTokens(tick0, tick1, nil, nil, nil, nil)
+	if tick0 != t0 {
+		amt0, amt1 = amt1, amt0
+	}
+
+	price, err := b.price(t0, amt0, t1, amt1)
+	if err != nil {
+		return err
+	}
+
+	for _, interval := range CandleIntervals {
+		date, ok := b.date(interval, at)
+		if !ok {
+			continue
+		}
+
+		key := t0 + "|" + t1 + "|" + interval + "|" + date
+		c := b.exchanges[key]
+		if c == nil {
+			c = &models.ExchangeSummary{}
+			found, err := b.find(c, "tick0 = ? AND tick1 = ? AND date_interval = ? AND last_date = ?", t0, t1, interval, date)
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				c = &models.ExchangeSummary{Tick: t0, Tick0: t0, Tick1: t1, LastDate: date, DateInterval: interval}
+				candleOpen(price, &c.OpenPrice, &c.ClosePrice, &c.LowestAsk, &c.HighestBid)
+			}
+			b.exchanges[key] = c
+			b.order = append(b.order, c)
+		}
+
+		candleMove(price, &c.ClosePrice, &c.LowestAsk, &c.HighestBid)
+		c.BaseVolume = candleAdd(c.BaseVolume, amt0)
+		c.QuoteVolume = candleAdd(c.QuoteVolume, amt1)
+	}
+	return nil
+}
+
+// fileTrades adds the file exchange trades of the blocks from..to to the candles of the collection of each file.
+// Only the orders priced in WDOGE are counted, the candles hold the floor and the volume of a collection in WDOGE.
+func (b *candleBook) fileTrades(from, to int64, times map[int64]int64) error {
+	trades := make([]*models.FileExchangeRevert, 0)
+	err := b.tx.Where("op = 'trade' AND block_number >= ? AND block_number <= ?", from, to).
+		Order("id").Find(&trades).Error
+	if err != nil {
+		return fmt.Errorf("file exchange revert err: %s", err.Error())
+	}
+
+	if len(trades) == 0 {
+		return nil
+	}
+
+	exIds := make([]string, 0, len(trades))
+	for _, trade := range trades {
+		exIds = append(exIds, trade.ExId)
+	}
+
+	collects := make([]*models.FileExchangeCollect, 0)
+	err = b.tx.Select("ex_id", "file_id", "tick", "amt").Where("ex_id in ? AND tick = ?", exIds, candleDoge).Find(&collects).Error
+	if err != nil {
+		return fmt.Errorf("file exchange collect err: %s", err.Error())
+	}
+
+	orders := make(map[string]*models.FileExchangeCollect)
+	fileIds := make([]string, 0, len(collects))
+	for _, collect := range collects {
+		orders[collect.ExId] = collect
+		fileIds = append(fileIds, collect.FileId)
+	}
+
+	if len(fileIds) == 0 {
+		return nil
+	}
+
+	inscriptions := make([]*models.FileMetaInscription, 0)
+	err = b.tx.Where("file_id in ?", fileIds).Find(&inscriptions).Error
+	if err != nil {
+		return fmt.Errorf("file meta inscription err: %s", err.Error())
+	}
+
+	fileMetas := make(map[string]string)
+	metaIds := make([]string, 0, len(inscriptions))
+	for _, inscription := range inscriptions {
+		fileMetas[inscription.FileId] = inscription.MetaId
+		metaIds = append(metaIds, inscription.MetaId)
+	}
+
+	metas := make([]*models.FileMeta, 0)
+	err = b.tx.Select("meta_id", "name").Where("meta_id in ?", metaIds).Find(&metas).Error
+	if err != nil {
+		return fmt.Errorf("file meta err: %s", err.Error())
+	}
+
+	names := make(map[string]string)
+	for _, meta := range metas {
+		names[meta.MetaId] = meta.Name
+	}
+
+	for _, trade := range trades {
+		blockTime, ok := times[trade.BlockNumber]
+		order := orders[trade.ExId]
+		if !ok || order == nil || order.Amt == nil {
+			continue
+		}
+
+		metaId, ok := fileMetas[order.FileId]
+		if !ok {
+			continue
+		}
+
+		err = b.fileTrade(time.Unix(blockTime, 0), metaId, names[metaId], order.Amt.Int())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileTrade adds a file of the collection metaId sold for amt WDOGE.
+func (b *candleBook) fileTrade(at time.Time, metaId, metaName string, amt *big.Int) error {
+	price, err := b.amount(candleDoge, amt)
+	if err != nil {
+		return err
+	}
+
+	for _, interval := range CandleIntervals {
+		date, ok := b.date(interval, at)
+		if !ok {
+			continue
+		}
+
+		key := metaId + "|" + interval + "|" + date
+		c := b.files[key]
+		if c == nil {
+			c = &models.FileExchangeSummary{}
+			found, err := b.find(c, "meta_id = ? AND date_interval = ? AND last_date = ?", metaId, interval, date)
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				c = &models.FileExchangeSummary{MetaId: metaId, MetaName: metaName, LastDate: date, DateInterval: interval, LowestAsk: price, HighestBid: price}
+			}
+			b.files[key] = c
+			b.order = append(b.order, c)
+		}
+
+		if c.DogeUsdt == 0 {
+			c.DogeUsdt = b.dogeUsd(date)
+		}
+
+		if price < c.LowestAsk {
+			c.LowestAsk = price
+		}
+		if price > c.HighestBid {
+			c.HighestBid = price
+		}
+		c.BaseVolume = candleAdd(c.BaseVolume, amt)
+	}
+	return nil
+}
+
+// date returns the last_date of the candle of interval at t, false when a rebuild leaves that candle alone.
+func (b *candleBook) date(interval string, t time.Time) (string, bool) {
+	start := CandleStart(interval, t)
+	if after, ok := b.after[interval]; ok && start.Before(after) {
+		return "", false
+	}
+	return start.Format(candleLayout), true
+}
+
+// dogeUsd returns the DOGE price in USD at the start of the candle at date. A price the oracle cannot give
+// is left at 0 and asked again the next time the candle changes, the indexer does not wait for the feed.
+func (b *candleBook) dogeUsd(date string) float64 {
+	if b.prices == nil {
+		return 0
+	}
+
+	if price, ok := b.usd[date]; ok {
+		return price
+	}
+
+	start, err := time.ParseInLocation(candleLayout, date, time.UTC)
+	if err != nil {
+		return 0
+	}
+
+	price, err := b.prices.DogeUsd(start)
+	if err != nil {
+		if !errors.Is(err, oracle.ErrNoPrice) {
+			log.Warn("explorer", "candle doge price", date, "err", err)
+		}
+		price = 0
+	}
+	b.usd[date] = price
+	return price
+}
+
+func (b *candleBook) find(dest interface{}, query string, args ...interface{}) (bool, error) {
+	result := b.tx.Where(query, args...).Limit(1).Find(dest)
+	if result.Error != nil {
+		return false, fmt.Errorf("find candle err: %s", result.Error.Error())
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// price returns the price of base in quote adjusted by the decimals of both ticks.
+func (b *candleBook) price(base string, baseAmt *big.Int, quote string, quoteAmt *big.Int) (float64, error) {
+	if baseAmt == nil || quoteAmt == nil || baseAmt.Sign() == 0 {
+		return 0, nil
+	}
+
+	baseVal, err := b.amount(base, baseAmt)
+	if err != nil {
+		return 0, err
+	}
+
+	quoteVal, err := b.amount(quote, quoteAmt)
+	if err != nil {
+		return 0, err
+	}
+
+	if baseVal == 0 {
+		return 0, nil
+	}
+	return quoteVal / baseVal, nil
+}
+
+// amount returns amt of tick in whole units, ticks without a deploy count with 8 decimals.
+func (b *candleBook) amount(tick string, amt *big.Int) (float64, error) {
+	dec, ok := b.decs[tick]
+	if !ok {
+		decs, err := findDrc20Decimals(b.tx, []string{tick})
+		if err != nil {
+			return 0, err
+		}
+
+		dec, ok = decs[tick]
+		if !ok {
+			dec = 8
+		}
+		b.decs[tick] = dec
+	}
+
+	val, _ := new(big.Rat).SetFrac(amt, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(dec)), nil)).Float64()
+	return val, nil
+}
+
+func candleOpen(price float64, open, close, low, high *float64) {
+	*open, *close, *low, *high = price, price, price, price
+}
+
+func candleMove(price float64, close, low, high *float64) {
+	*close = price
+	if price < *low {
+		*low = price
+	}
+	if price > *high {
+		*high = price
+	}
+}
+
+func candleAdd(volume *models.Number, amt *big.Int) *models.Number {
+	sum := big.NewInt(0)
+	if volume != nil {
+		sum.Set(volume.Int())
+	}
+	if amt != nil {
+		sum.Add(sum, amt)
+	}
+	return (*models.Number)(sum)
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/oracle"
+)
+
+func TestCandleStart(t *testing.T) {
+	// a thursday
+	at := time.Date(2026, 10, 22, 13, 47, 31, 0, time.UTC)
+	cases := map[string]time.Time{
+		"1m": time.Date(2026, 10, 22, 13, 47, 0, 0, time.UTC),
+		"5m": time.Date(2026, 10, 22, 13, 45, 0, 0, time.UTC),
+		"1h": time.Date(2026, 10, 22, 13, 0, 0, 0, time.UTC),
+		"4h": time.Date(2026, 10, 22, 12, 0, 0, 0, time.UTC),
+		"1d": time.Date(2026, 10, 22, 0, 0, 0, 0, time.UTC),
+		"1w": time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+	}
+	for interval, want := range cases {
+		if start := CandleStart(interval, at); !start.Equal(want) {
+			t.Fatalf("%s candle of %s starts at %s want %s", interval, at, start, want)
+		}
+	}
+
+	sunday := time.Date(2026, 10, 25, 23, 0, 0, 0, time.UTC)
+	if start := CandleStart("1w", sunday); !start.Equal(cases["1w"]) {
+		t.Fatalf("a week starts on monday, got %s", start)
+	}
+
+	// the day of a time in another zone is the day in UTC
+	tokyo := time.FixedZone("JST", 9*3600)
+	if start := CandleStart("1d", time.Date(2026, 10, 23, 8, 0, 0, 0, tokyo)); !start.Equal(cases["1d"]) {
+		t.Fatalf("the day candle of a time in another zone starts at %s", start)
+	}
+}
+
+func TestCandleBlock(t *testing.T) {
+	conn := newTestClient(t, &models.SwapInfo{}, &models.SwapLiquidity{}, &models.Drc20Collect{}, &models.ExchangeRevert{},
+		&models.ExchangeCollect{}, &models.SwapSummary{}, &models.SwapSummaryLiquidity{}, &models.ExchangeSummary{},
+		&models.FileExchangeRevert{}, &models.FileExchangeSummary{})
+
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	blocks := []*models.Block{
+		{BlockNumber: 100, BlockHash: "a", BlockTime: start.Add(5 * time.Second).Unix()},
+		{BlockNumber: 101, BlockHash: "b", BlockTime: start.Add(25 * time.Second).Unix()},
+	}
+
+	// 100 WDOGE for 50 WOW at 2, then 10 WOW for 30 WDOGE at 3
+	swaps := []*models.SwapInfo{
+		{Op: "swap", Tick0: candleDoge, Tick1: "WOW", Amt0: models.NewNumber(100e8), Amt1Out: models.NewNumber(50e8), TxHash: "s0", BlockNumber: 100},
+		{Op: "swap", Tick0: "WOW", Tick1: candleDoge, Amt0: models.NewNumber(10e8), Amt1Out: models.NewNumber(30e8), TxHash: "s1", BlockNumber: 101},
+	}
+
+	prices := oracle.NewStatic(0.2)
+	for i, block := range blocks {
+		if err := conn.DB.Create(block).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.DB.Create(swaps[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.DB.Model(swaps[i]).Update("order_status", 0).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		if err := conn.CandleBlock(conn.DB, block.BlockNumber, block.BlockTime, prices); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(open, close, low, high float64, volume int64) {
+		t.Helper()
+		c := &models.SwapSummary{}
+		err := conn.DB.Where("tick = ? AND date_interval = ? AND last_date = ?", "WOW", "1m", start.Format(candleLayout)).First(c).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.OpenPrice != open || c.ClosePrice != close || c.LowestAsk != low || c.HighestBid != high || c.BaseVolume.Int().Int64() != volume || c.DogeUsdt != 0.2 {
+			t.Fatalf("candle %v %v %v %v %s %v", c.OpenPrice, c.ClosePrice, c.LowestAsk, c.HighestBid, c.BaseVolume.String(), c.DogeUsdt)
+		}
+	}
+	check(2, 3, 2, 3, 130e8)
+
+	// reverting block 101 rebuilds the candle from block 100 alone
+	if err := conn.CandleRevert(conn.DB, 100, prices); err != nil {
+		t.Fatal(err)
+	}
+	check(2, 2, 2, 2, 100e8)
+}
+
+func TestCandleFileTrade(t *testing.T) {
+	conn := newTestClient(t, &models.SwapInfo{}, &models.ExchangeRevert{}, &models.Drc20Collect{}, &models.FileExchangeRevert{},
+		&models.FileExchangeCollect{}, &models.FileMetaInscription{}, &models.FileMeta{}, &models.FileExchangeSummary{})
+
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	block := &models.Block{BlockNumber: 100, BlockHash: "a", BlockTime: start.Add(5 * time.Second).Unix()}
+
+	// two files of the collection sold for 3 and 2 WDOGE, and one priced in another tick
+	rows := []interface{}{
+		block,
+		&models.FileMeta{MetaId: "m", Name: "Dogs"},
+		&models.FileMetaInscription{MetaId: "m", FileId: "f0"},
+		&models.FileMetaInscription{MetaId: "m", FileId: "f1"},
+		&models.FileMetaInscription{MetaId: "m", FileId: "f2"},
+		&models.FileExchangeCollect{ExId: "e0", FileId: "f0", Tick: candleDoge, Amt: models.NewNumber(3e8)},
+		&models.FileExchangeCollect{ExId: "e1", FileId: "f1", Tick: candleDoge, Amt: models.NewNumber(2e8)},
+		&models.FileExchangeCollect{ExId: "e2", FileId: "f2", Tick: "WOW", Amt: models.NewNumber(1)},
+		&models.FileExchangeRevert{Op: "trade", ExId: "e0", BlockNumber: 100},
+		&models.FileExchangeRevert{Op: "trade", ExId: "e1", BlockNumber: 100},
+		&models.FileExchangeRevert{Op: "trade", ExId: "e2", BlockNumber: 100},
+	}
+	for _, row := range rows {
+		if err := conn.DB.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := conn.CandleBlock(conn.DB, block.BlockNumber, block.BlockTime, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &models.FileExchangeSummary{}
+	err := conn.DB.Where("meta_id = ? AND date_interval = ? AND last_date = ?", "m", "1d", CandleDate("1d", start)).First(c).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.MetaName != "Dogs" || c.LowestAsk != 2 || c.HighestBid != 3 || c.BaseVolume.Int().Int64() != 5e8 {
+		t.Fatalf("candle %s %v %v %s", c.MetaName, c.LowestAsk, c.HighestBid, c.BaseVolume.String())
+	}
+}
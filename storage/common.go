@@ -10,7 +10,8 @@ import (
 	"time"
 )
 
-func (e *DBClient) ScheduledTasks(height int64) error {
+// ScheduledTasks runs the work due at height, expire refunds the order-v1 orders that expire at it.
+func (e *DBClient) ScheduledTasks(height int64, expire bool) error {
 
 	s := time.Now()
 
@@ -29,6 +30,14 @@ func (e *DBClient) ScheduledTasks(height int64) error {
 		return err
 	}
 
+	if expire {
+		err = e.ExchangeExpireScheduled(tx, height)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	err = tx.Commit().Error
 	if err != nil {
 		tx.Rollback()
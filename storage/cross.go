@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"fmt"
 	"github.com/unielon-org/unielon-indexer/models"
 	"github.com/unielon-org/unielon-indexer/utils"
 	"gorm.io/gorm"
+	"strconv"
+	"strings"
 )
 
 func (c *DBClient) CrossDeploy(tx *gorm.DB, cross *models.CrossInfo) error {
@@ -26,6 +29,8 @@ func (c *DBClient) CrossDeploy(tx *gorm.DB, cross *models.CrossInfo) error {
 	cc := &models.CrossCollect{
 		Tick:          tick,
 		AdminAddress:  cross.AdminAddress,
+		Admins:        cross.AdminAddress,
+		Threshold:     1,
 		HolderAddress: cross.HolderAddress,
 	}
 
@@ -37,6 +42,7 @@ func (c *DBClient) CrossDeploy(tx *gorm.DB, cross *models.CrossInfo) error {
 	revert := &models.CrossRevert{
 		Op:          "deploy",
 		Tick:        tick,
+		TxHash:      cross.TxHash,
 		BlockNumber: cross.BlockNumber,
 	}
 
@@ -48,20 +54,246 @@ func (c *DBClient) CrossDeploy(tx *gorm.DB, cross *models.CrossInfo) error {
 	return nil
 }
 
+// CrossAdmins returns the admin set of a wrapped token.
+func CrossAdmins(cc *models.CrossCollect) []string {
+	if cc.Admins == "" {
+		return []string{cc.AdminAddress}
+	}
+	return strings.Split(cc.Admins, ",")
+}
+
+// CrossContent is what an admin approves with a mint, set-admin or set-threshold, the approvals of the
+// admins count together only when it is the same.
+func CrossContent(cross *models.CrossInfo) string {
+	switch cross.Op {
+	case "set-admin":
+		return "admins:" + cross.Admins
+	case "set-threshold":
+		return "threshold:" + strconv.Itoa(cross.Threshold)
+	default:
+		return cross.Amt.String() + ":" + cross.ToAddress + ":" + cross.Chain
+	}
+}
+
+// CrossMint approves the mint of a deposit made on another chain, it is minted once threshold admins
+// approved it and the deposit id is recorded so it is not minted twice. A mint without a deposit id is
+// minted at once.
 func (c *DBClient) CrossMint(tx *gorm.DB, cross *models.CrossInfo) error {
 
+	if cross.DepositId != "" {
+		cc := &models.CrossCollect{}
+		err := tx.Where("tick = ?", cross.Tick).First(cc).Error
+		if err != nil {
+			return fmt.Errorf("CrossMint err: %s", err.Error())
+		}
+
+		approved, err := c.crossApprove(tx, cc, cross, cross.DepositId)
+		if err != nil || !approved {
+			return err
+		}
+	}
+
 	err := c.MintDrc20(tx, cross.Tick, cross.ToAddress, cross.Amt.Int(), cross.TxHash, cross.BlockNumber, false)
 	if err != nil {
 		return err
 	}
 
+	if cross.DepositId == "" {
+		return nil
+	}
+
+	deposit := &models.CrossDeposit{
+		Tick:         cross.Tick,
+		DepositId:    cross.DepositId,
+		Chain:        cross.Chain,
+		Amt:          cross.Amt,
+		ToAddress:    cross.ToAddress,
+		AdminAddress: cross.HolderAddress,
+		TxHash:       cross.TxHash,
+		BlockNumber:  cross.BlockNumber,
+	}
+
+	err = tx.Create(deposit).Error
+	if err != nil {
+		return fmt.Errorf("CrossMint deposit err: %s", err.Error())
+	}
+
+	revert := &models.CrossRevert{
+		Op:          "deposit",
+		Tick:        cross.Tick,
+		TxHash:      cross.TxHash,
+		BlockNumber: cross.BlockNumber,
+	}
+
+	err = tx.Create(revert).Error
+	if err != nil {
+		return fmt.Errorf("CrossMint revert err: %s", err.Error())
+	}
+
 	return nil
 }
 
+// CrossBurn burns the wrapped tokens and queues their release to to_address on the chain of the burn.
 func (c *DBClient) CrossBurn(tx *gorm.DB, cross *models.CrossInfo) error {
 	err := c.BurnDrc20(tx, cross.Tick, cross.HolderAddress, cross.Amt.Int(), cross.TxHash, cross.BlockNumber, false)
 	if err != nil {
 		return err
 	}
+
+	withdrawal := &models.CrossWithdrawal{
+		Tick:          cross.Tick,
+		Chain:         cross.Chain,
+		Amt:           cross.Amt,
+		HolderAddress: cross.HolderAddress,
+		ToAddress:     cross.ToAddress,
+		BurnTxHash:    cross.TxHash,
+		BlockNumber:   cross.BlockNumber,
+		Status:        models.CrossWithdrawalPending,
+	}
+
+	err = tx.Create(withdrawal).Error
+	if err != nil {
+		return fmt.Errorf("CrossBurn withdrawal err: %s", err.Error())
+	}
+
+	revert := &models.CrossRevert{
+		Op:          "withdraw",
+		Tick:        cross.Tick,
+		TxHash:      cross.TxHash,
+		BlockNumber: cross.BlockNumber,
+	}
+
+	err = tx.Create(revert).Error
+	if err != nil {
+		return fmt.Errorf("CrossBurn revert err: %s", err.Error())
+	}
+
 	return nil
 }
+
+// CrossRelease marks the withdrawal of burn_tx_hash released by the admin with the transaction on the other chain.
+func (c *DBClient) CrossRelease(tx *gorm.DB, cross *models.CrossInfo) error {
+	update := map[string]interface{}{
+		"status":               models.CrossWithdrawalReleased,
+		"ext_tx_id":            cross.ExtTxId,
+		"release_address":      cross.HolderAddress,
+		"release_tx_hash":      cross.TxHash,
+		"release_block_number": cross.BlockNumber,
+	}
+
+	result := tx.Model(&models.CrossWithdrawal{}).
+		Where("tick = ? AND burn_tx_hash = ? AND status = ?", cross.Tick, cross.BurnTxHash, models.CrossWithdrawalPending).
+		Updates(update)
+	if result.Error != nil {
+		return fmt.Errorf("CrossRelease err: %s", result.Error.Error())
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("CrossRelease the withdrawal %s is not pending", cross.BurnTxHash)
+	}
+
+	revert := &models.CrossRevert{
+		Op:          "release",
+		Tick:        cross.Tick,
+		TxHash:      cross.TxHash,
+		BlockNumber: cross.BlockNumber,
+	}
+
+	err := tx.Create(revert).Error
+	if err != nil {
+		return fmt.Errorf("CrossRelease revert err: %s", err.Error())
+	}
+
+	return nil
+}
+
+// CrossSetAdmin approves a set-admin or set-threshold of the token, the admin set or threshold changes once
+// threshold admins approved the same at the current nonce.
+func (c *DBClient) CrossSetAdmin(tx *gorm.DB, cross *models.CrossInfo) error {
+	cc := &models.CrossCollect{}
+	err := tx.Where("tick = ?", cross.Tick).First(cc).Error
+	if err != nil {
+		return fmt.Errorf("CrossSetAdmin err: %s", err.Error())
+	}
+
+	approved, err := c.crossApprove(tx, cc, cross, strconv.FormatInt(cc.Nonce, 10))
+	if err != nil || !approved {
+		return err
+	}
+
+	revert := &models.CrossRevert{
+		Op:           "admin",
+		Tick:         cross.Tick,
+		TxHash:       cross.TxHash,
+		AdminAddress: cc.AdminAddress,
+		Admins:       cc.Admins,
+		Threshold:    cc.Threshold,
+		Nonce:        cc.Nonce,
+		BlockNumber:  cross.BlockNumber,
+	}
+
+	err = tx.Create(revert).Error
+	if err != nil {
+		return fmt.Errorf("CrossSetAdmin revert err: %s", err.Error())
+	}
+
+	update := map[string]interface{}{"nonce": cc.Nonce + 1}
+	if cross.Op == "set-admin" {
+		update["admins"] = cross.Admins
+		update["admin_address"] = strings.Split(cross.Admins, ",")[0]
+	} else {
+		update["threshold"] = cross.Threshold
+	}
+
+	err = tx.Model(&models.CrossCollect{}).Where("tick = ?", cross.Tick).Updates(update).Error
+	if err != nil {
+		return fmt.Errorf("CrossSetAdmin err: %s", err.Error())
+	}
+
+	return nil
+}
+
+// crossApprove records the approval of cross by its admin for ref and tells whether threshold admins of the
+// current set approved the same content.
+func (c *DBClient) crossApprove(tx *gorm.DB, cc *models.CrossCollect, cross *models.CrossInfo, ref string) (bool, error) {
+	approval := &models.CrossApproval{
+		Tick:         cross.Tick,
+		Op:           cross.Op,
+		Ref:          ref,
+		Content:      CrossContent(cross),
+		AdminAddress: cross.HolderAddress,
+		TxHash:       cross.TxHash,
+		BlockNumber:  cross.BlockNumber,
+	}
+
+	err := tx.Create(approval).Error
+	if err != nil {
+		return false, fmt.Errorf("crossApprove err: %s", err.Error())
+	}
+
+	revert := &models.CrossRevert{
+		Op:          "approve",
+		Tick:        cross.Tick,
+		TxHash:      cross.TxHash,
+		BlockNumber: cross.BlockNumber,
+	}
+
+	err = tx.Create(revert).Error
+	if err != nil {
+		return false, fmt.Errorf("crossApprove revert err: %s", err.Error())
+	}
+
+	var approvals int64
+	err = tx.Model(&models.CrossApproval{}).
+		Where("tick = ? AND op = ? AND ref = ? AND content = ? AND admin_address IN ?", approval.Tick, approval.Op, ref, approval.Content, CrossAdmins(cc)).
+		Distinct("admin_address").Count(&approvals).Error
+	if err != nil {
+		return false, fmt.Errorf("crossApprove err: %s", err.Error())
+	}
+
+	threshold := cc.Threshold
+	if threshold < 1 {
+		threshold = 1
+	}
+	return approvals >= int64(threshold), nil
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/unielon-org/unielon-indexer/models"
+)
+
+const testCrossTick = "WETH(WRAPPED-ETH)"
+
+func newCrossClient(t *testing.T, admins string, threshold int) *DBClient {
+	conn := newTestClient(t, &models.CrossCollect{}, &models.CrossRevert{}, &models.CrossApproval{}, &models.CrossDeposit{},
+		&models.Drc20Collect{}, &models.Drc20CollectAddress{}, &models.Drc20Revert{})
+
+	err := conn.DB.Create(&models.CrossCollect{Tick: testCrossTick, AdminAddress: "A", Admins: admins, Threshold: threshold}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = conn.DB.Create(&models.Drc20Collect{Tick: testCrossTick, AmtSum: models.NewNumber(0), Dec: 8}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func crossCollect(t *testing.T, conn *DBClient) *models.CrossCollect {
+	cc := &models.CrossCollect{}
+	if err := conn.DB.Where("tick = ?", testCrossTick).First(cc).Error; err != nil {
+		t.Fatal(err)
+	}
+	return cc
+}
+
+func crossMint(t *testing.T, conn *DBClient, n int, admin, amt string) {
+	cross := &models.CrossInfo{
+		Op:            "mint",
+		Tick:          testCrossTick,
+		Amt:           models.NewNumber(0),
+		Chain:         "eth",
+		ToAddress:     "DReceiver",
+		DepositId:     "deposit-1",
+		HolderAddress: admin,
+		TxHash:        fmt.Sprintf("mint-%d", n),
+		BlockNumber:   int64(n),
+	}
+	cross.Amt.SetString(amt, 10)
+
+	if err := conn.CrossMint(conn.DB, cross); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func crossMinted(t *testing.T, conn *DBClient) string {
+	balance := &models.Drc20CollectAddress{}
+	err := conn.DB.Where("tick = ? AND holder_address = ?", testCrossTick, "DReceiver").First(balance).Error
+	if err != nil {
+		return "0"
+	}
+	return balance.AmtSum.String()
+}
+
+func TestCrossApproveDistinctAdmins(t *testing.T) {
+	conn := newCrossClient(t, "A,B,C", 2)
+
+	// the same admin twice is one approval
+	crossMint(t, conn, 1, "A", "100")
+	crossMint(t, conn, 2, "A", "100")
+	if minted := crossMinted(t, conn); minted != "0" {
+		t.Fatalf("minted %s after one admin", minted)
+	}
+
+	// a second admin approving another amount does not count with the first
+	crossMint(t, conn, 3, "B", "200")
+	if minted := crossMinted(t, conn); minted != "0" {
+		t.Fatalf("minted %s after different contents", minted)
+	}
+
+	crossMint(t, conn, 4, "B", "100")
+	if minted := crossMinted(t, conn); minted != "100" {
+		t.Fatalf("minted %s after two admins, want 100", minted)
+	}
+}
+
+func TestCrossApproveCurrentSet(t *testing.T) {
+	conn := newCrossClient(t, "A,B", 2)
+
+	// an approval of an address that is not an admin, e.g. one removed from the set, does not count
+	crossMint(t, conn, 1, "D", "100")
+	crossMint(t, conn, 2, "A", "100")
+	if minted := crossMinted(t, conn); minted != "0" {
+		t.Fatalf("minted %s with an approval outside the set", minted)
+	}
+
+	crossMint(t, conn, 3, "B", "100")
+	if minted := crossMinted(t, conn); minted != "100" {
+		t.Fatalf("minted %s after two admins of the set, want 100", minted)
+	}
+}
+
+func TestCrossSetAdminNonce(t *testing.T) {
+	conn := newCrossClient(t, "A,B", 2)
+
+	setAdmin := func(n int, admin string) {
+		cross := &models.CrossInfo{Op: "set-admin", Tick: testCrossTick, Admins: "A,B,C", HolderAddress: admin, TxHash: fmt.Sprintf("admin-%d", n), BlockNumber: int64(n)}
+		if err := conn.CrossSetAdmin(conn.DB, cross); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setAdmin(1, "A")
+	if cc := crossCollect(t, conn); cc.Nonce != 0 || cc.Admins != "A,B" {
+		t.Fatalf("changed after one approval: nonce %d admins %s", cc.Nonce, cc.Admins)
+	}
+
+	setAdmin(2, "B")
+	if cc := crossCollect(t, conn); cc.Nonce != 1 || cc.Admins != "A,B,C" {
+		t.Fatalf("not changed after two approvals: nonce %d admins %s", cc.Nonce, cc.Admins)
+	}
+
+	// the approvals of nonce 0 do not count again at nonce 1
+	setAdmin(3, "A")
+	if cc := crossCollect(t, conn); cc.Nonce != 1 {
+		t.Fatalf("replayed to nonce %d with the approvals of the previous nonce", cc.Nonce)
+	}
+
+	setAdmin(4, "C")
+	if cc := crossCollect(t, conn); cc.Nonce != 2 {
+		t.Fatalf("nonce %d after two approvals at nonce 1, want 2", cc.Nonce)
+	}
+}
@@ -2,16 +2,13 @@ package storage
 
 import (
 	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/unielon-org/unielon-indexer/utils"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
-	"log"
-	"os"
 	"sync"
-	"time"
 )
 
 const (
@@ -24,31 +21,18 @@ type DBClient struct {
 	lock *sync.RWMutex
 }
 
-func NewSqliteClient(cfg utils.SqliteConfig) *DBClient {
-
-	newLogger := logger.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags),
-		logger.Config{
-			SlowThreshold:             time.Second,
-			LogLevel:                  logger.Warn,
-			IgnoreRecordNotFoundError: true,
-			Colorful:                  true,
-		},
-	)
-
+func NewSqliteClient(cfg utils.SqliteConfig) (*DBClient, error) {
 	// github.com/mattn/go-sqlite3
-	db, err := gorm.Open(sqlite.Open(cfg.Database), &gorm.Config{Logger: newLogger})
+	db, err := gorm.Open(sqlite.Open(cfg.Database), &gorm.Config{Logger: newGormLogger()})
 	if err != nil {
-		fmt.Printf("Open failed,err:%v  ", err)
-		os.Exit(0)
+		return nil, fmt.Errorf("NewSqliteClient open %s err: %s", cfg.Database, err.Error())
 	}
 
 	_ = db.Exec("PRAGMA journal_mode=WAL;")
 
-	sqlDB, dbError := db.DB()
-	if dbError != nil {
-		fmt.Printf("get db failed,err:%v  ", dbError)
-		os.Exit(0)
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("NewSqliteClient db err: %s", err.Error())
 	}
 
 	sqlDB.SetMaxIdleConns(10)
@@ -61,27 +45,16 @@ func NewSqliteClient(cfg utils.SqliteConfig) *DBClient {
 		lock: lock,
 	}
 
-	return conn
+	return conn, nil
 }
 
-func NewMysqlClient(cfg utils.MysqlConfig) *DBClient {
+func NewMysqlClient(cfg utils.MysqlConfig) (*DBClient, error) {
 
 	dsn := fmt.Sprintf("%s:%s@%s(%s:%d)/%s?parseTime=true", cfg.UserName, cfg.PassWord, NETWORK, cfg.Server, cfg.Port, cfg.Database)
 
-	newLogger := logger.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags),
-		logger.Config{
-			SlowThreshold:             time.Second, //
-			LogLevel:                  logger.Warn, //
-			IgnoreRecordNotFoundError: true,        //
-			Colorful:                  true,        //
-		},
-	)
-
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: newLogger})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: newGormLogger()})
 	if err != nil {
-		fmt.Printf("Open failed,err:%v  ", err)
-		os.Exit(0)
+		return nil, fmt.Errorf("NewMysqlClient open %s:%d/%s err: %s", cfg.Server, cfg.Port, cfg.Database, err.Error())
 	}
 
 	lock := new(sync.RWMutex)
@@ -90,14 +63,22 @@ func NewMysqlClient(cfg utils.MysqlConfig) *DBClient {
 		lock: lock,
 	}
 
-	return conn
+	return conn, nil
 }
 
 func (conn *DBClient) Stop() {
 	sqlDB, err := conn.DB.DB()
 	if err != nil {
-		fmt.Printf("get db failed,err:%v  ", err)
+		log.Error("database", "Stop", err)
 		return
 	}
 	sqlDB.Close()
 }
+
+// WithDB returns a client that runs every query on db, typically an open transaction.
+func (conn *DBClient) WithDB(db *gorm.DB) *DBClient {
+	return &DBClient{
+		DB:   db,
+		lock: conn.lock,
+	}
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/utils"
+)
+
+// newTestClient opens an empty sqlite database with the blocks table and the given tables.
+func newTestClient(t *testing.T, tables ...interface{}) *DBClient {
+	conn, err := NewSqliteClient(utils.SqliteConfig{Database: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(conn.Stop)
+
+	if err := conn.DB.AutoMigrate(append([]interface{}{&models.Block{}}, tables...)...); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
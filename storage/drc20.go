@@ -1 +1,85 @@
 package storage
+
+import (
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+	"gorm.io/gorm"
+	"math/big"
+)
+
+// Drc20Burned is the total holders burned of tick.
+func (e *DBClient) Drc20Burned(tx *gorm.DB, tick string) (*big.Int, error) {
+	burns := make([]*models.Drc20Burn, 0)
+	err := tx.Select("amt").Where("tick = ?", tick).Find(&burns).Error
+	if err != nil {
+		return nil, fmt.Errorf("Drc20Burned err: %s tick: %s", err.Error(), tick)
+	}
+
+	sum := big.NewInt(0)
+	for _, burn := range burns {
+		sum.Add(sum, burn.Amt.Int())
+	}
+	return sum, nil
+}
+
+// Drc20Minted is the total of the successful mints of tick to holderAddress.
+func (e *DBClient) Drc20Minted(tx *gorm.DB, tick, holderAddress string) (*big.Int, error) {
+	mints := make([]*models.Drc20Info, 0)
+	err := tx.Select("amt", "repeat_mint").
+		Where("tick = ? and op = 'mint' and holder_address = ? and order_status = 0", tick, holderAddress).
+		Find(&mints).Error
+	if err != nil {
+		return nil, fmt.Errorf("Drc20Minted err: %s tick: %s holder: %s", err.Error(), tick, holderAddress)
+	}
+
+	sum := big.NewInt(0)
+	for _, mint := range mints {
+		sum.Add(sum, big.NewInt(0).Mul(mint.Amt.Int(), big.NewInt(mint.Repeat)))
+	}
+	return sum, nil
+}
+
+// BurnDrc20Holder burns amt of the balance of the holder and keeps the burn, fork reverts it
+// with the drc20 revert and by deleting the burn.
+func (e *DBClient) BurnDrc20Holder(tx *gorm.DB, drc20 *models.Drc20Info) error {
+	err := e.BurnDrc20(tx, drc20.Tick, drc20.HolderAddress, drc20.Amt.Int(), drc20.TxHash, drc20.BlockNumber, false)
+	if err != nil {
+		return err
+	}
+
+	burn := &models.Drc20Burn{
+		Tick:          drc20.Tick,
+		HolderAddress: drc20.HolderAddress,
+		Amt:           drc20.Amt,
+		TxHash:        drc20.TxHash,
+		BlockNumber:   drc20.BlockNumber,
+	}
+	err = tx.Create(burn).Error
+	if err != nil {
+		return fmt.Errorf("BurnDrc20Holder Create err: %s", err.Error())
+	}
+	return nil
+}
+
+// FindDrc20Decimals returns the decimals of the deployed ticks among ticks.
+func (e *DBClient) FindDrc20Decimals(ticks []string) (map[string]uint, error) {
+	return findDrc20Decimals(e.DB, ticks)
+}
+
+func findDrc20Decimals(tx *gorm.DB, ticks []string) (map[string]uint, error) {
+	decs := make(map[string]uint)
+	if len(ticks) == 0 {
+		return decs, nil
+	}
+
+	collects := make([]*models.Drc20Collect, 0)
+	err := tx.Select("tick", "dec_").Where("tick in ?", ticks).Find(&collects).Error
+	if err != nil {
+		return nil, fmt.Errorf("FindDrc20Decimals err: %s", err.Error())
+	}
+
+	for _, collect := range collects {
+		decs[collect.Tick] = collect.Dec
+	}
+	return decs, nil
+}
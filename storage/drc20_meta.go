@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMetaNotFound   = errors.New("metadata submission not found")
+	ErrMetaNotPending = errors.New("metadata submission is not pending")
+)
+
+// drc20MetaFields is the part of drc20_collect a metadata submission changes, kept in the history as json.
+type drc20MetaFields struct {
+	Logo         string `json:"logo"`
+	Introduction string `json:"introduction"`
+	WhitePaper   string `json:"white_paper"`
+	Official     string `json:"official"`
+	Telegram     string `json:"telegram"`
+	Discorad     string `json:"discorad"`
+	Twitter      string `json:"twitter"`
+	Facebook     string `json:"facebook"`
+	Github       string `json:"github"`
+	IsCheck      uint64 `json:"is_check"`
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func collectMetaFields(collect *models.Drc20Collect) *drc20MetaFields {
+	return &drc20MetaFields{
+		Logo:         derefString(collect.Logo),
+		Introduction: derefString(collect.Introduction),
+		WhitePaper:   derefString(collect.WhitePaper),
+		Official:     derefString(collect.Official),
+		Telegram:     derefString(collect.Telegram),
+		Discorad:     derefString(collect.Discorad),
+		Twitter:      derefString(collect.Twitter),
+		Facebook:     derefString(collect.Facebook),
+		Github:       derefString(collect.Github),
+		IsCheck:      collect.IsCheck,
+	}
+}
+
+func metaFields(meta *models.Drc20Meta) *drc20MetaFields {
+	return &drc20MetaFields{
+		Logo:         meta.Logo,
+		Introduction: meta.Introduction,
+		WhitePaper:   meta.WhitePaper,
+		Official:     meta.Official,
+		Telegram:     meta.Telegram,
+		Discorad:     meta.Discorad,
+		Twitter:      meta.Twitter,
+		Facebook:     meta.Facebook,
+		Github:       meta.Github,
+	}
+}
+
+func toJson(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func addMetaHistory(tx *gorm.DB, tick string, metaId uint, action, actor, reason string, before, after interface{}) error {
+	history := &models.Drc20MetaHistory{
+		Tick:   tick,
+		MetaId: metaId,
+		Action: action,
+		Actor:  actor,
+		Before: toJson(before),
+		After:  toJson(after),
+		Reason: reason,
+	}
+	return tx.Create(history).Error
+}
+
+// SubmitDrc20Meta queues meta for review, a submission still pending for the same tick is superseded.
+func (conn *DBClient) SubmitDrc20Meta(meta *models.Drc20Meta) error {
+	meta.Status = models.MetaStatusPending
+	return conn.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Drc20Meta{}).
+			Where("tick = ? AND status = ?", meta.Tick, models.MetaStatusPending).
+			Update("status", models.MetaStatusSuperseded).Error
+		if err != nil {
+			return fmt.Errorf("SubmitDrc20Meta supersede err: %s", err.Error())
+		}
+
+		if err := tx.Create(meta).Error; err != nil {
+			return fmt.Errorf("SubmitDrc20Meta Create err: %s", err.Error())
+		}
+
+		return addMetaHistory(tx, meta.Tick, meta.ID, models.MetaActionSubmit, meta.HolderAddress, "", nil, metaFields(meta))
+	})
+}
+
+// ReviewDrc20Meta approves or rejects a pending submission. Approving copies the metadata to
+// drc20_collect and sets is_check.
+func (conn *DBClient) ReviewDrc20Meta(id uint, approve bool, reviewer, reason string) (*models.Drc20Meta, error) {
+	meta := &models.Drc20Meta{}
+	err := conn.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(meta).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMetaNotFound
+			}
+			return fmt.Errorf("ReviewDrc20Meta First err: %s", err.Error())
+		}
+
+		if meta.Status != models.MetaStatusPending {
+			return ErrMetaNotPending
+		}
+
+		meta.Reviewer = reviewer
+		meta.Reason = reason
+		if !approve {
+			meta.Status = models.MetaStatusRejected
+			if err := tx.Save(meta).Error; err != nil {
+				return fmt.Errorf("ReviewDrc20Meta Save err: %s", err.Error())
+			}
+			return addMetaHistory(tx, meta.Tick, meta.ID, models.MetaActionReject, reviewer, reason, nil, nil)
+		}
+
+		collect := &models.Drc20Collect{}
+		if err := tx.Where("tick = ?", meta.Tick).First(collect).Error; err != nil {
+			return fmt.Errorf("ReviewDrc20Meta collect err: %s", err.Error())
+		}
+
+		after := metaFields(meta)
+		after.IsCheck = 1
+		err := tx.Model(&models.Drc20Collect{}).Where("tick = ?", meta.Tick).Updates(map[string]interface{}{
+			"logo":         after.Logo,
+			"introduction": after.Introduction,
+			"white_paper":  after.WhitePaper,
+			"official":     after.Official,
+			"telegram":     after.Telegram,
+			"discorad":     after.Discorad,
+			"twitter":      after.Twitter,
+			"facebook":     after.Facebook,
+			"github":       after.Github,
+			"is_check":     after.IsCheck,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("ReviewDrc20Meta Updates err: %s", err.Error())
+		}
+
+		meta.Status = models.MetaStatusApproved
+		if err := tx.Save(meta).Error; err != nil {
+			return fmt.Errorf("ReviewDrc20Meta Save err: %s", err.Error())
+		}
+		return addMetaHistory(tx, meta.Tick, meta.ID, models.MetaActionApprove, reviewer, reason, collectMetaFields(collect), after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// RevokeDrc20Meta clears is_check of tick, the metadata itself is kept.
+func (conn *DBClient) RevokeDrc20Meta(tick, reviewer, reason string) error {
+	return conn.DB.Transaction(func(tx *gorm.DB) error {
+		collect := &models.Drc20Collect{}
+		if err := tx.Where("tick = ?", tick).First(collect).Error; err != nil {
+			return fmt.Errorf("RevokeDrc20Meta collect err: %s", err.Error())
+		}
+
+		before := collectMetaFields(collect)
+		if err := tx.Model(&models.Drc20Collect{}).Where("tick = ?", tick).Update("is_check", 0).Error; err != nil {
+			return fmt.Errorf("RevokeDrc20Meta Update err: %s", err.Error())
+		}
+
+		after := *before
+		after.IsCheck = 0
+		return addMetaHistory(tx, tick, 0, models.MetaActionRevoke, reviewer, reason, before, &after)
+	})
+}
+
+func (conn *DBClient) FindDrc20Metas(tick string, status *int64, limit, offset int) ([]*models.Drc20Meta, int64, error) {
+	query := conn.DB.Model(&models.Drc20Meta{})
+	if tick != "" {
+		query = query.Where("tick = ?", tick)
+	}
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+
+	total := int64(0)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("FindDrc20Metas Count err: %s", err.Error())
+	}
+
+	metas := make([]*models.Drc20Meta, 0)
+	if err := query.Order("id asc").Limit(limit).Offset(offset).Find(&metas).Error; err != nil {
+		return nil, 0, fmt.Errorf("FindDrc20Metas Find err: %s", err.Error())
+	}
+	return metas, total, nil
+}
+
+func (conn *DBClient) FindDrc20MetaHistory(tick string, limit, offset int) ([]*models.Drc20MetaHistory, int64, error) {
+	query := conn.DB.Model(&models.Drc20MetaHistory{}).Where("tick = ?", tick)
+
+	total := int64(0)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("FindDrc20MetaHistory Count err: %s", err.Error())
+	}
+
+	history := make([]*models.Drc20MetaHistory, 0)
+	if err := query.Order("id desc").Limit(limit).Offset(offset).Find(&history).Error; err != nil {
+		return nil, 0, fmt.Errorf("FindDrc20MetaHistory Find err: %s", err.Error())
+	}
+	return history, total, nil
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"fmt"
 	"github.com/unielon-org/unielon-indexer/models"
 	"gorm.io/gorm"
 	"math/big"
@@ -14,6 +15,10 @@ func (e *DBClient) ExchangeCreate(tx *gorm.DB, ex *models.ExchangeInfo, reserves
 		Tick1:           ex.Tick1,
 		Amt0:            ex.Amt0,
 		Amt1:            ex.Amt1,
+		Amt0Finish:      models.NewNumber(0),
+		Amt1Finish:      models.NewNumber(0),
+		Expire:          ex.Expire,
+		Status:          models.ExchangeStatusOpen,
 		HolderAddress:   ex.HolderAddress,
 		ReservesAddress: reservesAddress,
 	}
@@ -29,9 +34,10 @@ func (e *DBClient) ExchangeCreate(tx *gorm.DB, ex *models.ExchangeInfo, reserves
 	}
 
 	exr := &models.ExchangeRevert{
-		Op:     "create",
-		ExId:   ex.ExId,
-		TxHash: ex.TxHash,
+		Op:          "create",
+		ExId:        ex.ExId,
+		TxHash:      ex.TxHash,
+		BlockNumber: ex.BlockNumber,
 	}
 
 	err = tx.Save(exr).Error
@@ -62,14 +68,24 @@ func (e *DBClient) ExchangeTrade(tx *gorm.DB, ex *models.ExchangeInfo) error {
 	amt0Finish := new(big.Int).Add(exc.Amt0Finish.Int(), amt0Out)
 	amt1Finish := new(big.Int).Add(exc.Amt1Finish.Int(), ex.Amt1.Int())
 
-	tx.Model(&models.ExchangeCollect{}).Where("ex_id = ?", ex.ExId).Updates(map[string]interface{}{"amt0_finish": amt0Finish.String(), "amt1_finish": amt1Finish.String()})
+	status := models.ExchangeStatusPartial
+	if amt0Finish.Cmp(exc.Amt0.Int()) >= 0 {
+		status = models.ExchangeStatusFilled
+	}
+
+	err = tx.Model(&models.ExchangeCollect{}).Where("ex_id = ?", ex.ExId).Updates(map[string]interface{}{"amt0_finish": amt0Finish.String(), "amt1_finish": amt1Finish.String(), "status": status}).Error
+	if err != nil {
+		return err
+	}
 
 	exr := &models.ExchangeRevert{
-		Op:     "trade",
-		ExId:   ex.ExId,
-		Amt0:   (*models.Number)(amt0Out),
-		Amt1:   ex.Amt1,
-		TxHash: ex.TxHash,
+		Op:          "trade",
+		ExId:        ex.ExId,
+		Amt0:        (*models.Number)(amt0Out),
+		Amt1:        ex.Amt1,
+		Status:      exc.Status,
+		TxHash:      ex.TxHash,
+		BlockNumber: ex.BlockNumber,
 	}
 
 	err = tx.Save(exr).Error
@@ -110,7 +126,12 @@ func (e *DBClient) ExchangeCancel(tx *gorm.DB, ex *models.ExchangeInfo) error {
 
 	amt0Finish := new(big.Int).Add(exc.Amt0Finish.Int(), ex.Amt0.Int())
 
-	err = tx.Model(&models.ExchangeCollect{}).Where("ex_id = ?", ex.ExId).Update("amt0_finish", amt0Finish.String()).Error
+	status := exc.Status
+	if amt0Finish.Cmp(exc.Amt0.Int()) >= 0 {
+		status = models.ExchangeStatusCancelled
+	}
+
+	err = tx.Model(&models.ExchangeCollect{}).Where("ex_id = ?", ex.ExId).Updates(map[string]interface{}{"amt0_finish": amt0Finish.String(), "status": status}).Error
 	if err != nil {
 		return err
 	}
@@ -119,10 +140,12 @@ func (e *DBClient) ExchangeCancel(tx *gorm.DB, ex *models.ExchangeInfo) error {
 	ex.Tick1 = exc.Tick1
 
 	exr := &models.ExchangeRevert{
-		Op:     "cancel",
-		ExId:   ex.ExId,
-		Amt0:   ex.Amt0,
-		TxHash: ex.TxHash,
+		Op:          "cancel",
+		ExId:        ex.ExId,
+		Amt0:        ex.Amt0,
+		Status:      exc.Status,
+		TxHash:      ex.TxHash,
+		BlockNumber: ex.BlockNumber,
 	}
 
 	err = tx.Save(exr).Error
@@ -132,3 +155,44 @@ func (e *DBClient) ExchangeCancel(tx *gorm.DB, ex *models.ExchangeInfo) error {
 
 	return nil
 }
+
+// ExchangeExpireScheduled refunds what remains of the orders that expire at height to their holders.
+func (e *DBClient) ExchangeExpireScheduled(tx *gorm.DB, height int64) error {
+
+	excs := make([]*models.ExchangeCollect, 0)
+	err := tx.Where("expire > 0 and expire <= ? and status in ?", height, []string{models.ExchangeStatusOpen, models.ExchangeStatusPartial}).Find(&excs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, exc := range excs {
+		remaining := new(big.Int).Sub(exc.Amt0.Int(), exc.Amt0Finish.Int())
+		if remaining.Cmp(big.NewInt(0)) > 0 {
+			err = e.TransferDrc20(tx, exc.Tick0, exc.ReservesAddress, exc.HolderAddress, remaining, exc.ExId, height, false)
+			if err != nil {
+				return fmt.Errorf("ExchangeExpireScheduled err: %s ex_id: %s", err.Error(), exc.ExId)
+			}
+		}
+
+		err = tx.Model(&models.ExchangeCollect{}).Where("ex_id = ?", exc.ExId).Updates(map[string]interface{}{"amt0_finish": exc.Amt0.String(), "status": models.ExchangeStatusExpired}).Error
+		if err != nil {
+			return err
+		}
+
+		exr := &models.ExchangeRevert{
+			Op:          "expire",
+			ExId:        exc.ExId,
+			Amt0:        (*models.Number)(remaining),
+			Status:      exc.Status,
+			TxHash:      exc.ExId,
+			BlockNumber: height,
+		}
+
+		err = tx.Save(exr).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+	"math/big"
+	"sort"
+)
+
+const (
+	MaxBookLevels = 100
+	MaxBestFills  = 50
+)
+
+// ExchangeBookLevel is the open order-v1 volume at one price, price is tick1 per tick0 of the book.
+type ExchangeBookLevel struct {
+	Price  float64        `json:"price"`
+	Amt0   *models.Number `json:"amt0"`
+	Amt1   *models.Number `json:"amt1"`
+	Depth0 *models.Number `json:"depth0"`
+	Depth1 *models.Number `json:"depth1"`
+	Orders int            `json:"orders"`
+}
+
+// ExchangeBook holds the asks, orders selling tick0 for tick1, from the lowest price and the bids,
+// orders paying tick1 for tick0, from the highest price.
+type ExchangeBook struct {
+	Tick0 string               `json:"tick0"`
+	Tick1 string               `json:"tick1"`
+	Asks  []*ExchangeBookLevel `json:"asks"`
+	Bids  []*ExchangeBookLevel `json:"bids"`
+}
+
+// ExchangeFill is one trade of a fill path, amt_in is the amt1 of the trade inscription.
+type ExchangeFill struct {
+	ExId   string         `json:"exid"`
+	AmtIn  *models.Number `json:"amt_in"`
+	AmtOut *models.Number `json:"amt_out"`
+	Price  float64        `json:"price"`
+}
+
+type ExchangeBest struct {
+	TickIn  string          `json:"tick_in"`
+	TickOut string          `json:"tick_out"`
+	AmtIn   *models.Number  `json:"amt_in"`
+	AmtOut  *models.Number  `json:"amt_out"`
+	Filled  bool            `json:"filled"`
+	Fills   []*ExchangeFill `json:"fills"`
+}
+
+// bookOrder is an order with what remains of it, price is amt1 per amt0 of the order.
+type bookOrder struct {
+	order     *models.ExchangeCollect
+	remaining *big.Int
+	price     *big.Rat
+}
+
+// liveExchangeOrders are the orders selling tick0 for tick1 that can still be traded at the next block.
+func (e *DBClient) liveExchangeOrders(tick0, tick1 string) ([]*bookOrder, error) {
+	height := int64(0)
+	err := e.DB.Model(&models.Block{}).Select("COALESCE(max(block_number), 0)").Scan(&height).Error
+	if err != nil {
+		return nil, fmt.Errorf("liveExchangeOrders err: %s", err.Error())
+	}
+
+	excs := make([]*models.ExchangeCollect, 0)
+	err = e.DB.Where("tick0 = ? and tick1 = ? and status in ? and (expire = 0 or expire > ?)",
+		tick0, tick1, []string{models.ExchangeStatusOpen, models.ExchangeStatusPartial}, height+1).
+		Order("id").
+		Find(&excs).Error
+	if err != nil {
+		return nil, fmt.Errorf("liveExchangeOrders err: %s", err.Error())
+	}
+
+	orders := make([]*bookOrder, 0, len(excs))
+	for _, exc := range excs {
+		remaining := new(big.Int).Sub(exc.Amt0.Int(), exc.Amt0Finish.Int())
+		if remaining.Sign() <= 0 || exc.Amt0.Int().Sign() <= 0 || exc.Amt1.Int().Sign() <= 0 {
+			continue
+		}
+
+		orders = append(orders, &bookOrder{
+			order:     exc,
+			remaining: remaining,
+			price:     new(big.Rat).SetFrac(exc.Amt1.Int(), exc.Amt0.Int()),
+		})
+	}
+
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].price.Cmp(orders[j].price) < 0
+	})
+	return orders, nil
+}
+
+// ExchangeOrderBook aggregates the live orders of the pair into at most levels price levels per side.
+func (e *DBClient) ExchangeOrderBook(tick0, tick1 string, levels int) (*ExchangeBook, error) {
+	if levels <= 0 || levels > MaxBookLevels {
+		levels = MaxBookLevels
+	}
+
+	book := &ExchangeBook{Tick0: tick0, Tick1: tick1, Asks: make([]*ExchangeBookLevel, 0), Bids: make([]*ExchangeBookLevel, 0)}
+
+	asks, err := e.liveExchangeOrders(tick0, tick1)
+	if err != nil {
+		return nil, err
+	}
+
+	// an ask sells its remaining tick0 for the tick1 a trade pays for it
+	book.Asks = bookLevels(asks, levels, func(o *bookOrder) (*big.Rat, *big.Int, *big.Int) {
+		amt1 := new(big.Int).Mul(o.remaining, o.order.Amt1.Int())
+		amt1.Div(amt1, o.order.Amt0.Int())
+		return o.price, o.remaining, amt1
+	})
+
+	bids, err := e.liveExchangeOrders(tick1, tick0)
+	if err != nil {
+		return nil, err
+	}
+
+	// a bid pays its remaining tick1 for tick0, the cheapest tick0 per tick1 is the most tick1 per tick0
+	// so the orders already come best bid first
+	book.Bids = bookLevels(bids, levels, func(o *bookOrder) (*big.Rat, *big.Int, *big.Int) {
+		amt0 := new(big.Int).Mul(o.remaining, o.order.Amt1.Int())
+		amt0.Div(amt0, o.order.Amt0.Int())
+		return new(big.Rat).Inv(o.price), amt0, o.remaining
+	})
+
+	return book, nil
+}
+
+// bookLevels merges sorted orders of the same price, side returns the price and the tick0 and tick1 amounts of an order.
+func bookLevels(orders []*bookOrder, levels int, side func(o *bookOrder) (*big.Rat, *big.Int, *big.Int)) []*ExchangeBookLevel {
+	result := make([]*ExchangeBookLevel, 0)
+	depth0 := big.NewInt(0)
+	depth1 := big.NewInt(0)
+
+	var last *big.Rat
+	for _, o := range orders {
+		price, amt0, amt1 := side(o)
+		if last == nil || price.Cmp(last) != 0 {
+			if len(result) == levels {
+				break
+			}
+
+			f, _ := price.Float64()
+			result = append(result, &ExchangeBookLevel{
+				Price: f,
+				Amt0:  models.NewNumber(0),
+				Amt1:  models.NewNumber(0),
+			})
+			last = price
+		}
+
+		level := result[len(result)-1]
+		level.Amt0 = (*models.Number)(new(big.Int).Add(level.Amt0.Int(), amt0))
+		level.Amt1 = (*models.Number)(new(big.Int).Add(level.Amt1.Int(), amt1))
+		level.Orders++
+
+		depth0.Add(depth0, amt0)
+		depth1.Add(depth1, amt1)
+		level.Depth0 = (*models.Number)(new(big.Int).Set(depth0))
+		level.Depth1 = (*models.Number)(new(big.Int).Set(depth1))
+	}
+	return result
+}
+
+// ExchangeBestFill finds the cheapest orders that deliver amtOut of tickOut for tickIn, one trade per order.
+// Orders of holderAddress are skipped as they can not be traded by it. The amounts follow ExchangeTrade,
+// a trade paying amt1 receives amt1 * amt0 / amt1 of the order rounded down and never more than remains.
+func (e *DBClient) ExchangeBestFill(tickIn, tickOut string, amtOut *big.Int, holderAddress string) (*ExchangeBest, error) {
+	if amtOut.Sign() <= 0 {
+		return nil, fmt.Errorf("the amount of tokens exceeds the 0")
+	}
+
+	orders, err := e.liveExchangeOrders(tickOut, tickIn)
+	if err != nil {
+		return nil, err
+	}
+
+	best := &ExchangeBest{TickIn: tickIn, TickOut: tickOut, Fills: make([]*ExchangeFill, 0)}
+	totalIn := big.NewInt(0)
+	totalOut := big.NewInt(0)
+
+	for _, o := range orders {
+		if totalOut.Cmp(amtOut) >= 0 || len(best.Fills) == MaxBestFills {
+			break
+		}
+
+		if holderAddress != "" && o.order.HolderAddress == holderAddress {
+			continue
+		}
+
+		want := new(big.Int).Sub(amtOut, totalOut)
+		if want.Cmp(o.remaining) > 0 {
+			want = o.remaining
+		}
+
+		amt0, amt1 := o.order.Amt0.Int(), o.order.Amt1.Int()
+
+		// the least payment that receives want
+		pay := new(big.Int).Mul(want, amt1)
+		pay.Add(pay, new(big.Int).Sub(amt0, big.NewInt(1)))
+		pay.Div(pay, amt0)
+
+		out := new(big.Int).Mul(pay, amt0)
+		out.Div(out, amt1)
+		if out.Cmp(o.remaining) > 0 {
+			// rounding up would overfill, pay the most that still fits what remains
+			pay = new(big.Int).Mul(o.remaining, amt1)
+			pay.Div(pay, amt0)
+			out = new(big.Int).Mul(pay, amt0)
+			out.Div(out, amt1)
+		}
+
+		if pay.Sign() <= 0 || out.Sign() <= 0 {
+			continue
+		}
+
+		f, _ := o.price.Float64()
+		best.Fills = append(best.Fills, &ExchangeFill{
+			ExId:   o.order.ExId,
+			AmtIn:  (*models.Number)(pay),
+			AmtOut: (*models.Number)(out),
+			Price:  f,
+		})
+		totalIn.Add(totalIn, pay)
+		totalOut.Add(totalOut, out)
+	}
+
+	best.AmtIn = (*models.Number)(totalIn)
+	best.AmtOut = (*models.Number)(totalOut)
+	best.Filled = totalOut.Cmp(amtOut) >= 0
+	return best, nil
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/unielon-org/unielon-indexer/models"
+)
+
+func TestExchangeOrderBookBids(t *testing.T) {
+	conn := newTestClient(t, &models.ExchangeCollect{})
+
+	// both bids sell tick1 for tick0: the first pays 2 tick1 per tick0, the second 4
+	orders := []*models.ExchangeCollect{
+		{ExId: "a", Tick0: "WDOGE", Tick1: "CARDI", Amt0: models.NewNumber(100), Amt1: models.NewNumber(50)},
+		{ExId: "b", Tick0: "WDOGE", Tick1: "CARDI", Amt0: models.NewNumber(100), Amt1: models.NewNumber(25)},
+		{ExId: "c", Tick0: "CARDI", Tick1: "WDOGE", Amt0: models.NewNumber(10), Amt1: models.NewNumber(30)},
+	}
+	for _, o := range orders {
+		o.Amt0Finish = models.NewNumber(0)
+		o.Amt1Finish = models.NewNumber(0)
+		o.Status = models.ExchangeStatusOpen
+		if err := conn.DB.Create(o).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	book, err := conn.ExchangeOrderBook("CARDI", "WDOGE", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(book.Asks) != 1 || book.Asks[0].Price != 3 {
+		t.Fatalf("asks %+v", book.Asks)
+	}
+
+	if len(book.Bids) != 2 {
+		t.Fatalf("bids %+v", book.Bids)
+	}
+
+	want := []struct {
+		price      float64
+		amt0, amt1 int64
+		depth0     int64
+	}{
+		{price: 4, amt0: 25, amt1: 100, depth0: 25},
+		{price: 2, amt0: 50, amt1: 100, depth0: 75},
+	}
+	for i, w := range want {
+		bid := book.Bids[i]
+		if bid.Price != w.price || bid.Amt0.Int().Int64() != w.amt0 || bid.Amt1.Int().Int64() != w.amt1 || bid.Depth0.Int().Int64() != w.depth0 {
+			t.Fatalf("bid %d = %v %s %s %s, want %+v", i, bid.Price, bid.Amt0.String(), bid.Amt1.String(), bid.Depth0.String(), w)
+		}
+	}
+}
+
+func TestExchangeBestFill(t *testing.T) {
+	conn := newTestClient(t, &models.ExchangeCollect{})
+
+	// asks selling CARDI for WDOGE at 3 and 4, the one at 4 is already half filled
+	orders := []*models.ExchangeCollect{
+		{ExId: "d", Tick0: "CARDI", Tick1: "WDOGE", Amt0: models.NewNumber(20), Amt1: models.NewNumber(80), Amt0Finish: models.NewNumber(10), Status: models.ExchangeStatusPartial, HolderAddress: "Dother"},
+		{ExId: "c", Tick0: "CARDI", Tick1: "WDOGE", Amt0: models.NewNumber(10), Amt1: models.NewNumber(30), Amt0Finish: models.NewNumber(0), Status: models.ExchangeStatusOpen, HolderAddress: "Dme"},
+	}
+	for _, o := range orders {
+		o.Amt1Finish = models.NewNumber(0)
+		if err := conn.DB.Create(o).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	best, err := conn.ExchangeBestFill("WDOGE", "CARDI", big.NewInt(15), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !best.Filled || best.AmtIn.Int().Int64() != 50 || best.AmtOut.Int().Int64() != 15 || len(best.Fills) != 2 {
+		t.Fatalf("best fill %s for %s, filled %v", best.AmtIn.String(), best.AmtOut.String(), best.Filled)
+	}
+
+	if best.Fills[0].ExId != "c" || best.Fills[0].AmtIn.Int().Int64() != 30 || best.Fills[1].ExId != "d" || best.Fills[1].AmtIn.Int().Int64() != 20 {
+		t.Fatalf("fills %s %s, %s %s", best.Fills[0].ExId, best.Fills[0].AmtIn.String(), best.Fills[1].ExId, best.Fills[1].AmtIn.String())
+	}
+
+	// the own order is skipped and the rest does not cover the amount
+	best, err = conn.ExchangeBestFill("WDOGE", "CARDI", big.NewInt(15), "Dme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if best.Filled || best.AmtOut.Int().Int64() != 10 || len(best.Fills) != 1 || best.Fills[0].ExId != "d" {
+		t.Fatalf("best fill without the own order %s, filled %v", best.AmtOut.String(), best.Filled)
+	}
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"time"
+)
+
+const slowQuery = time.Second
+
+// gormLogger writes the gorm logs as "database" records, slow queries as warnings and
+// failed ones, except for record not found, as errors.
+type gormLogger struct {
+	level logger.LogLevel
+}
+
+func newGormLogger() logger.Interface {
+	return &gormLogger{level: logger.Warn}
+}
+
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &gormLogger{level: level}
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		log.Info("database", "msg", fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		log.Warn("database", "msg", fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		log.Error("database", "msg", fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= logger.Error:
+		sql, rows := fc()
+		log.Error("database", "err", err, "elapsed", elapsed, "rows", rows, "sql", sql)
+	case elapsed > slowQuery && l.level >= logger.Warn:
+		sql, rows := fc()
+		log.Warn("database", "slow", elapsed, "rows", rows, "sql", sql)
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		log.Trace("database", "elapsed", elapsed, "rows", rows, "sql", sql)
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// AcquireLease takes the lease name for holder, or renews it when holder already has it, until now + ttl.
+// It returns false when another holder has a lease that has not expired yet. The time is the clock of the
+// database, so the replicas agree on it whatever their own clocks say, and the call gives up after ttl/6.
+func (conn *DBClient) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ttl/6)
+	defer cancel()
+	db := conn.DB.WithContext(ctx)
+
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LeaderLease{Name: name}).Error
+	if err != nil {
+		return false, fmt.Errorf("AcquireLease Create err: %s", err.Error())
+	}
+
+	now := conn.nowMillis()
+	result := db.Model(&models.LeaderLease{}).
+		Where("name = ? AND (holder = ? OR expire_at < "+now+")", name, holder).
+		Updates(map[string]interface{}{"holder": holder, "expire_at": gorm.Expr(now+" + ?", ttl.Milliseconds())})
+	if result.Error != nil {
+		return false, fmt.Errorf("AcquireLease Update err: %s", result.Error.Error())
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// HoldsLease tells whether holder has the lease name in tx, which makes it the fence of the writes of tx.
+// On mysql the lease row stays locked until tx ends, so it cannot be taken over before tx has committed.
+func (conn *DBClient) HoldsLease(tx *gorm.DB, name, holder string) (bool, error) {
+	query := tx.Model(&models.LeaderLease{}).Where("name = ? AND holder = ? AND expire_at > "+conn.nowMillis(), name, holder)
+	if conn.DB.Dialector.Name() == "mysql" {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("HoldsLease err: %s", err.Error())
+	}
+	return count > 0, nil
+}
+
+// nowMillis is the sql expression of the unix milliseconds of the database clock.
+func (conn *DBClient) nowMillis() string {
+	if conn.DB.Dialector.Name() == "mysql" {
+		return "CAST(UNIX_TIMESTAMP(NOW(3)) * 1000 AS SIGNED)"
+	}
+	return "CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER)"
+}
+
+// ReleaseLease gives the lease up so that another holder can take it without waiting for it to expire.
+func (conn *DBClient) ReleaseLease(name, holder string) error {
+	err := conn.DB.Model(&models.LeaderLease{}).
+		Where("name = ? AND holder = ?", name, holder).
+		Updates(map[string]interface{}{"holder": "", "expire_at": 0}).Error
+	if err != nil {
+		return fmt.Errorf("ReleaseLease err: %s", err.Error())
+	}
+	return nil
+}
+
+// FindLease returns the current state of the lease name.
+func (conn *DBClient) FindLease(name string) (*models.LeaderLease, error) {
+	lease := &models.LeaderLease{}
+	err := conn.DB.Where("name = ?", name).First(lease).Error
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/dogecoinw/go-dogecoin/rlp"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/unielon-org/unielon-indexer/utils"
 	"sync"
 )
@@ -62,3 +63,45 @@ func (conn *LevelDB) GetCacheOrderAddress(key string) (*utils.OrderAddressCache,
 	return value, nil
 
 }
+
+func (conn *LevelDB) SetCache(key string, value []byte) error {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+
+	return conn.DB.Put([]byte("cache-"+key), value, nil)
+}
+
+func (conn *LevelDB) GetCache(key string) ([]byte, error) {
+	conn.lock.RLock()
+	defer conn.lock.RUnlock()
+
+	return conn.DB.Get([]byte("cache-"+key), nil)
+}
+
+// DeleteCache removes the entry of key written by SetCache.
+func (conn *LevelDB) DeleteCache(key string) error {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+
+	return conn.DB.Delete([]byte("cache-"+key), nil)
+}
+
+// ClearCache removes every entry written by SetCache.
+func (conn *LevelDB) ClearCache() error {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+
+	iter := conn.DB.NewIterator(util.BytesPrefix([]byte("cache-")), nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	return conn.DB.Write(batch, nil)
+}
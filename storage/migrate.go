@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+	"gorm.io/gorm"
+)
+
+// migrateModels are the tables added after the released database snapshots, they are created when missing.
+var migrateModels = []interface{}{
+	&models.LeaderLease{},
+	&models.RejectedInscription{},
+	&models.ApiKey{},
+	&models.Drc20Meta{},
+	&models.Drc20MetaHistory{},
+	&models.AuthNonce{},
+	&models.Drc20Burn{},
+	&models.SwapLiquidityRevert{},
+	&models.CrossDeposit{},
+	&models.CrossWithdrawal{},
+	&models.CrossApproval{},
+}
+
+// migrateColumn is a column added to a table of the released database snapshots, backfill fills the
+// rows that exist when the column is added.
+type migrateColumn struct {
+	model    interface{}
+	field    string
+	backfill func(conn *DBClient) error
+}
+
+var migrateColumns = []*migrateColumn{
+	{model: &models.ExchangeInfo{}, field: "Expire"},
+	{model: &models.ExchangeCollect{}, field: "Expire"},
+	{model: &models.ExchangeCollect{}, field: "Status", backfill: backfillExchangeStatus},
+	{model: &models.ExchangeRevert{}, field: "Status"},
+	{model: &models.Block{}, field: "BlockTime"},
+	{model: &models.SwapInfo{}, field: "Fee"},
+	{model: &models.SwapLiquidity{}, field: "Fee"},
+	{model: &models.SwapLiquidity{}, field: "KLast"},
+	{model: &models.CrossInfo{}, field: "DepositId"},
+	{model: &models.CrossInfo{}, field: "BurnTxHash"},
+	{model: &models.CrossInfo{}, field: "ExtTxId"},
+	{model: &models.CrossRevert{}, field: "TxHash"},
+	{model: &models.CrossInfo{}, field: "Admins"},
+	{model: &models.CrossInfo{}, field: "Threshold"},
+	{model: &models.CrossCollect{}, field: "Admins"},
+	{model: &models.CrossCollect{}, field: "Threshold"},
+	{model: &models.CrossCollect{}, field: "Nonce"},
+	{model: &models.CrossRevert{}, field: "AdminAddress"},
+	{model: &models.CrossRevert{}, field: "Admins"},
+	{model: &models.CrossRevert{}, field: "Threshold"},
+	{model: &models.CrossRevert{}, field: "Nonce"},
+	{model: &models.FileExchangeSummary{}, field: "MetaId"},
+}
+
+// Migrate creates the tables and columns that older databases do not have yet.
+func (conn *DBClient) Migrate() error {
+	err := conn.DB.AutoMigrate(migrateModels...)
+	if err != nil {
+		return fmt.Errorf("Migrate err: %s", err.Error())
+	}
+
+	migrator := conn.DB.Migrator()
+	for _, column := range migrateColumns {
+		if !migrator.HasTable(column.model) || migrator.HasColumn(column.model, column.field) {
+			continue
+		}
+
+		err = migrator.AddColumn(column.model, column.field)
+		if err != nil {
+			return fmt.Errorf("Migrate AddColumn %s err: %s", column.field, err.Error())
+		}
+
+		if column.backfill != nil {
+			err = column.backfill(conn)
+			if err != nil {
+				return fmt.Errorf("Migrate backfill %s err: %s", column.field, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// backfillExchangeStatus derives the status of existing orders from their amounts, a cancel also counts
+// towards amt0_finish so a closed order that was not paid in full is taken as cancelled.
+func backfillExchangeStatus(conn *DBClient) error {
+	return conn.DB.Model(&models.ExchangeCollect{}).Where("1 = 1").Update("status", gorm.Expr(
+		"CASE WHEN amt1_finish = amt1 THEN ? WHEN amt0_finish = amt0 THEN ? WHEN amt0_finish != '0' THEN ? ELSE ? END",
+		models.ExchangeStatusFilled, models.ExchangeStatusCancelled, models.ExchangeStatusPartial, models.ExchangeStatusOpen,
+	)).Error
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+	"gorm.io/gorm/clause"
+)
+
+// SaveRejectedInscription records r, a second rejection of the same transaction replaces the first.
+func (conn *DBClient) SaveRejectedInscription(r *models.RejectedInscription) error {
+	err := conn.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tx_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"block_hash", "block_number", "p", "op", "raw", "err_class", "err_info", "status", "update_date"}),
+	}).Create(r).Error
+	if err != nil {
+		return fmt.Errorf("SaveRejectedInscription err: %s", err.Error())
+	}
+	return nil
+}
+
+type RejectedFilter struct {
+	TxHashes  []string
+	P         string
+	ErrClass  string
+	Status    *int64
+	FromBlock int64
+	ToBlock   int64
+	Limit     int
+	Offset    int
+}
+
+func (conn *DBClient) FindRejectedInscriptions(f *RejectedFilter) ([]*models.RejectedInscription, int64, error) {
+	query := conn.DB.Model(&models.RejectedInscription{})
+	if len(f.TxHashes) > 0 {
+		query = query.Where("tx_hash in ?", f.TxHashes)
+	}
+	if f.P != "" {
+		query = query.Where("p = ?", f.P)
+	}
+	if f.ErrClass != "" {
+		query = query.Where("err_class = ?", f.ErrClass)
+	}
+	if f.Status != nil {
+		query = query.Where("status = ?", *f.Status)
+	}
+	if f.FromBlock > 0 {
+		query = query.Where("block_number >= ?", f.FromBlock)
+	}
+	if f.ToBlock > 0 {
+		query = query.Where("block_number <= ?", f.ToBlock)
+	}
+
+	total := int64(0)
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("FindRejectedInscriptions Count err: %s", err.Error())
+	}
+
+	if f.Limit > 0 {
+		query = query.Limit(f.Limit).Offset(f.Offset)
+	}
+
+	list := make([]*models.RejectedInscription, 0)
+	err = query.Order("block_number asc, id asc").Find(&list).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("FindRejectedInscriptions err: %s", err.Error())
+	}
+	return list, total, nil
+}
@@ -12,13 +12,21 @@ import (
 
 const (
 	MINI_LIQUIDITY = 1000
+
+	// DefaultSwapFee is the fee in basis points of the pools created without one
+	DefaultSwapFee = 30
 )
 
-func (e *DBClient) SwapCreate(tx *gorm.DB, swap *models.SwapInfo) error {
+// SwapCreate creates the pool of swap with its fee, feeTo is the address of the protocol fee or empty when it is off.
+func (e *DBClient) SwapCreate(tx *gorm.DB, swap *models.SwapInfo, feeTo string) error {
 
 	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(swap.Tick0+swap.Tick1), &chaincfg.MainNetParams)
 	swap.Tick = swap.Tick0 + "-SWAP-" + swap.Tick1
 
+	if swap.Fee == 0 {
+		swap.Fee = DefaultSwapFee
+	}
+
 	liquidityBase := new(big.Int).Sqrt(new(big.Int).Mul(swap.Amt0.Int(), swap.Amt1.Int()))
 	if liquidityBase.Cmp(big.NewInt(MINI_LIQUIDITY)) > 0 {
 		liquidityBase = new(big.Int).Sub(liquidityBase, big.NewInt(MINI_LIQUIDITY))
@@ -37,6 +45,8 @@ func (e *DBClient) SwapCreate(tx *gorm.DB, swap *models.SwapInfo) error {
 		HolderAddress:   swap.HolderAddress,
 		ReservesAddress: reservesAddress.String(),
 		LiquidityTotal:  (*models.Number)(liquidityBase),
+		Fee:             swap.Fee,
+		KLast:           models.NewNumber(0),
 	}
 
 	err := tx.Create(sl).Error
@@ -44,6 +54,18 @@ func (e *DBClient) SwapCreate(tx *gorm.DB, swap *models.SwapInfo) error {
 		return fmt.Errorf("SwapCreate Create err: %s", err.Error())
 	}
 
+	revert := &models.SwapLiquidityRevert{
+		Op:          "create",
+		Tick:        swap.Tick,
+		TxHash:      swap.TxHash,
+		BlockNumber: swap.BlockNumber,
+	}
+
+	err = tx.Create(revert).Error
+	if err != nil {
+		return fmt.Errorf("SwapCreate revert err: %s", err.Error())
+	}
+
 	err = e.TransferDrc20(tx, swap.Tick0, swap.HolderAddress, reservesAddress.String(), swap.Amt0.Int(), swap.TxHash, swap.BlockNumber, false)
 	if err != nil {
 		return err
@@ -80,10 +102,11 @@ func (e *DBClient) SwapCreate(tx *gorm.DB, swap *models.SwapInfo) error {
 		return err
 	}
 
-	return nil
+	return e.swapUpdateKLast(tx, swap.Tick, feeTo, swap.TxHash, swap.BlockNumber)
 }
 
-func (e *DBClient) SwapAdd(tx *gorm.DB, swap *models.SwapInfo) error {
+// SwapAdd adds liquidity to the pool of swap, the protocol fee is minted to feeTo first when it is not empty.
+func (e *DBClient) SwapAdd(tx *gorm.DB, swap *models.SwapInfo, feeTo string) error {
 
 	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(swap.Tick0+swap.Tick1), &chaincfg.MainNetParams)
 	swap.Tick = swap.Tick0 + "-SWAP-" + swap.Tick1
@@ -97,6 +120,11 @@ func (e *DBClient) SwapAdd(tx *gorm.DB, swap *models.SwapInfo) error {
 		return fmt.Errorf("SwapAdd Find error: %s", err.Error())
 	}
 
+	err = e.swapMintFee(tx, swapl, feeTo, swap.TxHash, swap.BlockNumber)
+	if err != nil {
+		return err
+	}
+
 	amountBOptimal := big.NewInt(0).Mul(swap.Amt0.Int(), swapl.Amt1.Int())
 	amountBOptimal = big.NewInt(0).Div(amountBOptimal, swapl.Amt0.Int())
 	if amountBOptimal.Cmp(swap.Amt1Min.Int()) >= 0 && swap.Amt1.Int().Cmp(amountBOptimal) >= 0 {
@@ -126,6 +154,8 @@ func (e *DBClient) SwapAdd(tx *gorm.DB, swap *models.SwapInfo) error {
 
 	swap.Amt0Out = (*models.Number)(amt0Out)
 	swap.Amt1Out = (*models.Number)(amt1Out)
+	// the liquidity minted, an add inscription has none of its own
+	swap.Liquidity = (*models.Number)(liquidity)
 
 	err = e.TransferDrc20(tx, swap.Tick0, swap.HolderAddress, reservesAddress.String(), amt0Out, swap.TxHash, swap.BlockNumber, false)
 	if err != nil {
@@ -152,10 +182,11 @@ func (e *DBClient) SwapAdd(tx *gorm.DB, swap *models.SwapInfo) error {
 		return err
 	}
 
-	return nil
+	return e.swapUpdateKLast(tx, swap.Tick, feeTo, swap.TxHash, swap.BlockNumber)
 }
 
-func (e *DBClient) SwapRemove(tx *gorm.DB, swap *models.SwapInfo) error {
+// SwapRemove removes liquidity from the pool of swap, the protocol fee is minted to feeTo first when it is not empty.
+func (e *DBClient) SwapRemove(tx *gorm.DB, swap *models.SwapInfo, feeTo string) error {
 
 	swapl := &models.SwapLiquidity{}
 	err := tx.Where("tick0 = ? and tick1 = ?", swap.Tick0, swap.Tick1).First(swapl).Error
@@ -163,6 +194,11 @@ func (e *DBClient) SwapRemove(tx *gorm.DB, swap *models.SwapInfo) error {
 		return fmt.Errorf("swapRemove FindSwapLiquidity error: %v", err)
 	}
 
+	err = e.swapMintFee(tx, swapl, feeTo, swap.TxHash, swap.BlockNumber)
+	if err != nil {
+		return err
+	}
+
 	amt0Out := new(big.Int).Mul(swap.Liquidity.Int(), swapl.Amt0.Int())
 	amt0Out = new(big.Int).Div(amt0Out, swapl.LiquidityTotal.Int())
 
@@ -196,7 +232,7 @@ func (e *DBClient) SwapRemove(tx *gorm.DB, swap *models.SwapInfo) error {
 		return err
 	}
 
-	return nil
+	return e.swapUpdateKLast(tx, swapl.Tick, feeTo, swap.TxHash, swap.BlockNumber)
 }
 
 func (e *DBClient) SwapExec(tx *gorm.DB, swap *models.SwapInfo) error {
@@ -213,12 +249,7 @@ func (e *DBClient) SwapExec(tx *gorm.DB, swap *models.SwapInfo) error {
 	amtMap[swapl.Tick0] = swapl.Amt0.Int()
 	amtMap[swapl.Tick1] = swapl.Amt1.Int()
 
-	amtfee0 := new(big.Int).Div(swap.Amt0.Int(), big.NewInt(1000))
-	amtin := new(big.Int).Mul(amtfee0, big.NewInt(3))
-	amtin = new(big.Int).Sub(swap.Amt0.Int(), amtin)
-
-	amtout := new(big.Int).Mul(amtin, amtMap[swap.Tick1])
-	amtout = new(big.Int).Div(amtout, new(big.Int).Add(amtMap[swap.Tick0], amtin))
+	amtout := SwapAmountOut(swap.Amt0.Int(), amtMap[swap.Tick0], amtMap[swap.Tick1], swapl.Fee)
 
 	swap.Amt1Out = (*models.Number)(amtout)
 
@@ -240,6 +271,143 @@ func (e *DBClient) SwapExec(tx *gorm.DB, swap *models.SwapInfo) error {
 	return nil
 }
 
+// swapFee is the part of amtIn a swap keeps in the pool, fee is in basis points and taken from every
+// whole 1000 of the input, which makes the 30 of the pools before the tiers exactly amtIn/1000*3.
+func swapFee(amtIn *big.Int, fee int) *big.Int {
+	amtfee := new(big.Int).Div(amtIn, big.NewInt(1000))
+	amtfee.Mul(amtfee, big.NewInt(int64(fee)))
+	return amtfee.Div(amtfee, big.NewInt(10))
+}
+
+// SwapAmountOut returns the output of a swap after the fee of the pool is taken from the input.
+func SwapAmountOut(amtIn, reserveIn, reserveOut *big.Int, fee int) *big.Int {
+	amtin := new(big.Int).Sub(amtIn, swapFee(amtIn, fee))
+
+	amtout := new(big.Int).Mul(amtin, reserveOut)
+	return amtout.Div(amtout, new(big.Int).Add(reserveIn, amtin))
+}
+
+// SwapAmountIn returns the smallest input for which SwapAmountOut yields at least amtOut.
+func SwapAmountIn(amtOut, reserveIn, reserveOut *big.Int, fee int) (*big.Int, error) {
+	if amtOut.Sign() <= 0 {
+		return nil, fmt.Errorf("the amount of tokens exceeds the 0")
+	}
+
+	if amtOut.Cmp(reserveOut) >= 0 {
+		return nil, fmt.Errorf("the amount of tokens exceeds the reserves")
+	}
+
+	// the input left after the fee: ceil(amtOut * reserveIn / (reserveOut - amtOut))
+	numerator := new(big.Int).Mul(amtOut, reserveIn)
+	denominator := new(big.Int).Sub(reserveOut, amtOut)
+	effective, rem := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if rem.Sign() > 0 {
+		effective.Add(effective, big.NewInt(1))
+	}
+
+	// amtIn = 1000q + r leaves kept(q) + r after the fee, kept(q) = 1000q - q*fee/10 grows with q.
+	// Pick the smallest q whose last input 1000q + 999 can reach effective.
+	kept := func(q *big.Int) *big.Int {
+		return new(big.Int).Sub(new(big.Int).Mul(q, big.NewInt(1000)), swapFee(new(big.Int).Mul(q, big.NewInt(1000)), fee))
+	}
+
+	need := new(big.Int).Sub(effective, big.NewInt(999))
+	q := big.NewInt(0)
+	if need.Sign() > 0 {
+		q.Mul(need, big.NewInt(10))
+		q.Add(q, big.NewInt(int64(9999-fee)))
+		q.Div(q, big.NewInt(int64(10000-fee)))
+		for q.Sign() > 0 && kept(new(big.Int).Sub(q, big.NewInt(1))).Cmp(need) >= 0 {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+
+	r := new(big.Int).Sub(effective, kept(q))
+	if r.Sign() < 0 {
+		r.SetInt64(0)
+	}
+
+	amtIn := new(big.Int).Add(new(big.Int).Mul(q, big.NewInt(1000)), r)
+	for SwapAmountOut(amtIn, reserveIn, reserveOut, fee).Cmp(amtOut) < 0 {
+		amtIn.Add(amtIn, big.NewInt(1))
+	}
+
+	return amtIn, nil
+}
+
+// swapMintFee mints to feeTo the protocol share of the fees the pool earned since k_last, a sixth of the
+// growth of sqrt(k) as in Uniswap v2. It runs before liquidity is added or removed and keeps
+// swapl.LiquidityTotal up to date for the amounts computed after it.
+func (e *DBClient) swapMintFee(tx *gorm.DB, swapl *models.SwapLiquidity, feeTo, txHash string, height int64) error {
+	if feeTo == "" || swapl.KLast == nil || swapl.KLast.Int().Sign() <= 0 {
+		return nil
+	}
+
+	rootK := new(big.Int).Sqrt(new(big.Int).Mul(swapl.Amt0.Int(), swapl.Amt1.Int()))
+	rootKLast := new(big.Int).Sqrt(swapl.KLast.Int())
+	if rootK.Cmp(rootKLast) <= 0 {
+		return nil
+	}
+
+	numerator := new(big.Int).Mul(swapl.LiquidityTotal.Int(), new(big.Int).Sub(rootK, rootKLast))
+	denominator := new(big.Int).Add(new(big.Int).Mul(rootK, big.NewInt(5)), rootKLast)
+	liquidity := numerator.Div(numerator, denominator)
+	if liquidity.Sign() <= 0 {
+		return nil
+	}
+
+	err := e.MintDrc20(tx, swapl.Tick, feeTo, liquidity, txHash, height, false)
+	if err != nil {
+		return fmt.Errorf("swapMintFee err: %s", err.Error())
+	}
+
+	swapl.LiquidityTotal = (*models.Number)(new(big.Int).Add(swapl.LiquidityTotal.Int(), liquidity))
+	return nil
+}
+
+// swapUpdateKLast sets k_last to the reserves product after an add or remove while the protocol fee is on
+// and clears it while it is off, the former value is kept for a fork.
+func (e *DBClient) swapUpdateKLast(tx *gorm.DB, tick, feeTo, txHash string, height int64) error {
+	swapl := &models.SwapLiquidity{}
+	err := tx.Where("tick = ?", tick).First(swapl).Error
+	if err != nil {
+		return fmt.Errorf("swapUpdateKLast err: %s", err.Error())
+	}
+
+	kLast := big.NewInt(0)
+	if feeTo != "" {
+		kLast.Mul(swapl.Amt0.Int(), swapl.Amt1.Int())
+	}
+
+	before := big.NewInt(0)
+	if swapl.KLast != nil {
+		before = swapl.KLast.Int()
+	}
+
+	if kLast.Cmp(before) == 0 {
+		return nil
+	}
+
+	revert := &models.SwapLiquidityRevert{
+		Op:          "k_last",
+		Tick:        tick,
+		KLast:       (*models.Number)(before),
+		TxHash:      txHash,
+		BlockNumber: height,
+	}
+
+	err = tx.Create(revert).Error
+	if err != nil {
+		return fmt.Errorf("swapUpdateKLast revert err: %s", err.Error())
+	}
+
+	err = tx.Model(&models.SwapLiquidity{}).Where("tick = ?", tick).Update("k_last", kLast.String()).Error
+	if err != nil {
+		return fmt.Errorf("swapUpdateKLast err: %s", err.Error())
+	}
+	return nil
+}
+
 func (e *DBClient) UpdateLiquidity(tx *gorm.DB, tick string) error {
 
 	err := tx.Exec(`UPDATE swap_liquidity
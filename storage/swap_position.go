@@ -0,0 +1,381 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+	"gorm.io/gorm"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// SwapPositionEvent is a create, add or remove of a holder, the amounts are the tokens deposited or withdrawn
+// and liquidity the holder balance after it. Liquidity total and share are empty when the pool supply
+// at the time is not known.
+type SwapPositionEvent struct {
+	Op              string         `json:"op"`
+	TxHash          string         `json:"tx_hash"`
+	BlockNumber     int64          `json:"block_number"`
+	Amt0            *models.Number `json:"amt0"`
+	Amt1            *models.Number `json:"amt1"`
+	LiquidityChange *models.Number `json:"liquidity_change"`
+	Liquidity       *models.Number `json:"liquidity"`
+	LiquidityTotal  *models.Number `json:"liquidity_total"`
+	Share           float64        `json:"share"`
+}
+
+// SwapPosition is the liquidity of a holder in a pool. Deposits are the average cost of the liquidity held,
+// fees the part of the underlying amounts earned by swaps since, impermanent loss and pnl compare the value
+// without and with the fees to holding the deposits, values are counted in tick1 at the pool price.
+// Liquidity received from others is given the cost of the tracked one, untracked is its amount.
+type SwapPosition struct {
+	Tick            string               `json:"tick"`
+	Tick0           string               `json:"tick0"`
+	Tick1           string               `json:"tick1"`
+	Liquidity       *models.Number       `json:"liquidity"`
+	LiquidityTotal  *models.Number       `json:"liquidity_total"`
+	Share           float64              `json:"share"`
+	Amt0            *models.Number       `json:"amt0"`
+	Amt1            *models.Number       `json:"amt1"`
+	Deposit0        *models.Number       `json:"deposit0"`
+	Deposit1        *models.Number       `json:"deposit1"`
+	Fee0            *models.Number       `json:"fee0"`
+	Fee1            *models.Number       `json:"fee1"`
+	ValueLp         *models.Number       `json:"value_lp"`
+	ValueHold       *models.Number       `json:"value_hold"`
+	ImpermanentLoss float64              `json:"impermanent_loss"`
+	Pnl             float64              `json:"pnl"`
+	Untracked       *models.Number       `json:"untracked"`
+	History         []*SwapPositionEvent `json:"history"`
+}
+
+// positionRevertBatch is the number of drc20_revert rows read at once for the supply of a pool.
+const positionRevertBatch = 1000
+
+// positionSupply is a mint or burn of a pool liquidity tick and the supply after it.
+type positionSupply struct {
+	address string
+	mint    bool
+	amt     *big.Int
+	total   *big.Int
+	used    bool
+}
+
+// FindSwapPositions returns the positions of holderAddress, in the pools it holds liquidity of or has added to,
+// tick limits them to a pool.
+func (c *DBClient) FindSwapPositions(holderAddress, tick string, limit, offset int) ([]*SwapPosition, int64, error) {
+	pools := make(map[string]bool)
+
+	var held []string
+	query := c.DB.Model(&models.Drc20CollectAddress{}).
+		Where("holder_address = ? AND tick LIKE ? AND amt_sum != ?", holderAddress, "%-SWAP-%", "0")
+	if tick != "" {
+		query = query.Where("tick = ?", tick)
+	}
+	err := query.Pluck("tick", &held).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("FindSwapPositions err: %s", err.Error())
+	}
+
+	for _, t := range held {
+		pools[t] = true
+	}
+
+	type pair struct {
+		Tick0 string
+		Tick1 string
+	}
+	var pairs []pair
+	query = c.DB.Model(&models.SwapInfo{}).Distinct("tick0", "tick1").
+		Where("holder_address = ? AND order_status = 0 AND op IN ?", holderAddress, []string{"create", "add", "remove"})
+	if tick != "" {
+		ticks := strings.SplitN(tick, "-SWAP-", 2)
+		if len(ticks) != 2 {
+			return nil, 0, fmt.Errorf("tick %s is not a pool", tick)
+		}
+		query = query.Where("tick0 = ? AND tick1 = ?", ticks[0], ticks[1])
+	}
+	err = query.Scan(&pairs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("FindSwapPositions err: %s", err.Error())
+	}
+
+	for _, p := range pairs {
+		pools[p.Tick0+"-SWAP-"+p.Tick1] = true
+	}
+
+	ticks := make([]string, 0, len(pools))
+	for t := range pools {
+		ticks = append(ticks, t)
+	}
+	sort.Strings(ticks)
+
+	total := int64(len(ticks))
+	if offset >= len(ticks) {
+		return []*SwapPosition{}, total, nil
+	}
+	ticks = ticks[offset:]
+	if limit > 0 && limit < len(ticks) {
+		ticks = ticks[:limit]
+	}
+
+	positions := make([]*SwapPosition, 0, len(ticks))
+	for _, t := range ticks {
+		position, err := c.findSwapPosition(holderAddress, t)
+		if err != nil {
+			return nil, 0, err
+		}
+		if position != nil {
+			positions = append(positions, position)
+		}
+	}
+	return positions, total, nil
+}
+
+func (c *DBClient) findSwapPosition(holderAddress, tick string) (*SwapPosition, error) {
+	swapl := &models.SwapLiquidity{}
+	err := c.DB.Where("tick = ?", tick).Limit(1).Find(swapl).Error
+	if err != nil {
+		return nil, fmt.Errorf("findSwapPosition err: %s", err.Error())
+	}
+	if swapl.Tick == "" {
+		return nil, nil
+	}
+
+	balance := &models.Drc20CollectAddress{}
+	err = c.DB.Where("tick = ? AND holder_address = ?", tick, holderAddress).Limit(1).Find(balance).Error
+	if err != nil {
+		return nil, fmt.Errorf("findSwapPosition err: %s", err.Error())
+	}
+
+	infos := make([]*models.SwapInfo, 0)
+	err = c.DB.Where("holder_address = ? AND tick0 = ? AND tick1 = ? AND order_status = 0 AND op IN ?",
+		holderAddress, swapl.Tick0, swapl.Tick1, []string{"create", "add", "remove"}).
+		Order("block_number, id").Find(&infos).Error
+	if err != nil {
+		return nil, fmt.Errorf("findSwapPosition err: %s", err.Error())
+	}
+
+	supplies, err := c.findPositionSupplies(tick, infos)
+	if err != nil {
+		return nil, err
+	}
+
+	position := &SwapPosition{
+		Tick:           tick,
+		Tick0:          swapl.Tick0,
+		Tick1:          swapl.Tick1,
+		Liquidity:      (*models.Number)(positionInt(balance.AmtSum)),
+		LiquidityTotal: (*models.Number)(positionInt(swapl.LiquidityTotal)),
+		History:        make([]*SwapPositionEvent, 0, len(infos)),
+	}
+
+	// lp is the liquidity of the holder by its own history, cost0 and cost1 the deposits of it and root the
+	// sqrt(amt0 * amt1) of them, the part of sqrt(k) it owned at entry
+	lp, cost0, cost1, root := big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0)
+	for _, info := range infos {
+		event := &SwapPositionEvent{Op: info.Op, TxHash: info.TxHash, BlockNumber: info.BlockNumber}
+		mint := info.Op != "remove"
+
+		var liquidity *big.Int
+		if info.Liquidity != nil && info.Liquidity.Int().Sign() > 0 {
+			liquidity = info.Liquidity.Int()
+		}
+
+		// the liquidity of an add is not recorded on the older orders, it is the amount minted to the holder
+		for _, s := range supplies[info.TxHash] {
+			if s.used || s.mint != mint || s.address != holderAddress {
+				continue
+			}
+			if liquidity != nil && s.amt.Cmp(liquidity) != 0 {
+				continue
+			}
+			s.used = true
+			liquidity = s.amt
+			event.LiquidityTotal = (*models.Number)(s.total)
+			break
+		}
+		if liquidity == nil {
+			liquidity = big.NewInt(0)
+		}
+
+		amt0, amt1 := positionInt(info.Amt0Out), positionInt(info.Amt1Out)
+		event.Amt0 = (*models.Number)(amt0)
+		event.Amt1 = (*models.Number)(amt1)
+
+		if mint {
+			event.LiquidityChange = (*models.Number)(liquidity)
+			lp.Add(lp, liquidity)
+			cost0.Add(cost0, amt0)
+			cost1.Add(cost1, amt1)
+			if info.Op == "create" {
+				root.Add(root, liquidity)
+			} else {
+				root.Add(root, new(big.Int).Sqrt(new(big.Int).Mul(amt0, amt1)))
+			}
+		} else {
+			event.LiquidityChange = (*models.Number)(new(big.Int).Neg(liquidity))
+			if lp.Sign() > 0 {
+				left := new(big.Int).Sub(lp, liquidity)
+				if left.Sign() < 0 {
+					left.SetInt64(0)
+				}
+				cost0 = positionScale(cost0, left, lp)
+				cost1 = positionScale(cost1, left, lp)
+				root = positionScale(root, left, lp)
+				lp = left
+			}
+		}
+
+		event.Liquidity = (*models.Number)(new(big.Int).Set(lp))
+		if event.LiquidityTotal != nil {
+			event.Share = positionShare(lp, event.LiquidityTotal.Int())
+		}
+		position.History = append(position.History, event)
+	}
+
+	position.fill(swapl, lp, cost0, cost1, root)
+	return position, nil
+}
+
+// findPositionSupplies returns the mints and burns of the pool liquidity tick by the transactions of infos,
+// with the supply after each. The supply is summed over every mint and burn up to the last of infos, they
+// are read in batches of positionRevertBatch and only those of infos are kept.
+func (c *DBClient) findPositionSupplies(tick string, infos []*models.SwapInfo) (map[string][]*positionSupply, error) {
+	supplies := make(map[string][]*positionSupply)
+	if len(infos) == 0 {
+		return supplies, nil
+	}
+
+	txHashes := make(map[string]bool, len(infos))
+	last := int64(0)
+	for _, info := range infos {
+		txHashes[info.TxHash] = true
+		if info.BlockNumber > last {
+			last = info.BlockNumber
+		}
+	}
+
+	supply := big.NewInt(0)
+	reverts := make([]*models.Drc20Revert, 0, positionRevertBatch)
+	err := c.DB.Select("id", "from_address", "to_address", "amt", "tx_hash").
+		Where("tick = ? AND (from_address = '' OR to_address = '') AND block_number <= ?", tick, last).
+		FindInBatches(&reverts, positionRevertBatch, func(tx *gorm.DB, batch int) error {
+			for _, revert := range reverts {
+				s := &positionSupply{amt: positionInt(revert.Amt)}
+				if revert.FromAddress == "" {
+					s.mint, s.address = true, revert.ToAddress
+					supply.Add(supply, s.amt)
+				} else {
+					s.address = revert.FromAddress
+					supply.Sub(supply, s.amt)
+				}
+
+				if txHashes[revert.TxHash] {
+					s.total = new(big.Int).Set(supply)
+					supplies[revert.TxHash] = append(supplies[revert.TxHash], s)
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, fmt.Errorf("findSwapPosition err: %s", err.Error())
+	}
+	return supplies, nil
+}
+
+// fill sets the current amounts of the position from the pool reserves and its tracked cost.
+func (p *SwapPosition) fill(swapl *models.SwapLiquidity, lp, cost0, cost1, root *big.Int) {
+	balance := p.Liquidity.Int()
+	supply := p.LiquidityTotal.Int()
+	reserve0, reserve1 := positionInt(swapl.Amt0), positionInt(swapl.Amt1)
+
+	p.Untracked = (*models.Number)(new(big.Int).Sub(balance, lp))
+	if p.Untracked.Int().Sign() < 0 {
+		p.Untracked = models.NewNumber(0)
+	}
+
+	zero := func() {
+		p.Deposit0, p.Deposit1 = models.NewNumber(0), models.NewNumber(0)
+		p.Fee0, p.Fee1 = models.NewNumber(0), models.NewNumber(0)
+		p.ValueHold = models.NewNumber(0)
+	}
+
+	if supply.Sign() <= 0 || reserve0.Sign() <= 0 {
+		p.Amt0, p.Amt1, p.ValueLp = models.NewNumber(0), models.NewNumber(0), models.NewNumber(0)
+		zero()
+		return
+	}
+
+	p.Share = positionShare(balance, supply)
+	amt0 := positionScale(reserve0, balance, supply)
+	amt1 := positionScale(reserve1, balance, supply)
+	p.Amt0, p.Amt1 = (*models.Number)(amt0), (*models.Number)(amt1)
+
+	// the value in tick1 of amt0 at the pool price is amt1, the reserves are even in value
+	valueLp := new(big.Int).Mul(amt1, big.NewInt(2))
+	p.ValueLp = (*models.Number)(valueLp)
+
+	if lp.Sign() <= 0 || balance.Sign() <= 0 {
+		zero()
+		return
+	}
+
+	// the liquidity transferred in or out keeps the cost of the tracked one
+	cost0 = positionScale(cost0, balance, lp)
+	cost1 = positionScale(cost1, balance, lp)
+	root = positionScale(root, balance, lp)
+	p.Deposit0, p.Deposit1 = (*models.Number)(cost0), (*models.Number)(cost1)
+
+	// sqrt(amt0 * amt1) grows with the fees only, the part of it above root is earned
+	rootNow := new(big.Int).Sqrt(new(big.Int).Mul(amt0, amt1))
+	earned := new(big.Int).Sub(rootNow, root)
+	if earned.Sign() < 0 || rootNow.Sign() == 0 {
+		earned.SetInt64(0)
+	}
+	p.Fee0 = (*models.Number)(positionScale(amt0, earned, rootNow))
+	p.Fee1 = (*models.Number)(positionScale(amt1, earned, rootNow))
+
+	price := new(big.Float).Quo(new(big.Float).SetInt(reserve1), new(big.Float).SetInt(reserve0))
+	valueHold, _ := new(big.Float).Add(new(big.Float).Mul(new(big.Float).SetInt(cost0), price), new(big.Float).SetInt(cost1)).Int(nil)
+	p.ValueHold = (*models.Number)(valueHold)
+	if valueHold.Sign() <= 0 {
+		return
+	}
+
+	valueFees := new(big.Int).Mul(p.Fee1.Int(), big.NewInt(2))
+	hold, _ := new(big.Float).SetInt(valueHold).Float64()
+	withFees, _ := new(big.Float).SetInt(valueLp).Float64()
+	withoutFees, _ := new(big.Float).SetInt(new(big.Int).Sub(valueLp, valueFees)).Float64()
+	p.ImpermanentLoss = positionPercent(withoutFees/hold - 1)
+	p.Pnl = positionPercent(withFees/hold - 1)
+}
+
+func positionInt(n *models.Number) *big.Int {
+	if n == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(n.Int())
+}
+
+// positionScale returns amt * num / den, 0 for an empty den.
+func positionScale(amt, num, den *big.Int) *big.Int {
+	if den.Sign() == 0 {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Div(new(big.Int).Mul(amt, num), den)
+}
+
+// positionShare returns the percent of total that liquidity is.
+func positionShare(liquidity, total *big.Int) float64 {
+	if total.Sign() <= 0 {
+		return 0
+	}
+	share, _ := new(big.Float).Quo(new(big.Float).SetInt(liquidity), new(big.Float).SetInt(total)).Float64()
+	return positionPercent(share)
+}
+
+func positionPercent(f float64) float64 {
+	return math.Round(f*1e6) / 1e4
+}
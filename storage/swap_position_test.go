@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/unielon-org/unielon-indexer/models"
+)
+
+func TestSwapPositionFill(t *testing.T) {
+	tests := []struct {
+		name       string
+		reserve0   int64
+		reserve1   int64
+		balance    int64
+		supply     int64
+		fee0, fee1 int64
+		deposit0   int64
+		valueLp    int64
+		valueHold  int64
+		il, pnl    float64
+		untracked  int64
+	}{
+		{
+			// sqrt(k) grew from 200 to 242 by the fees alone
+			name: "fees", reserve0: 121, reserve1: 484, balance: 200, supply: 200,
+			fee0: 21, fee1: 84, deposit0: 100, valueLp: 968, valueHold: 800, il: 0, pnl: 21,
+		},
+		{
+			// the price moved from 4 to 16 with no fee earned
+			name: "price move", reserve0: 50, reserve1: 800, balance: 200, supply: 200,
+			fee0: 0, fee1: 0, deposit0: 100, valueLp: 1600, valueHold: 2000, il: -20, pnl: -20,
+		},
+		{
+			// half of the pool is held by someone else
+			name: "half the pool", reserve0: 200, reserve1: 800, balance: 200, supply: 400,
+			fee0: 0, fee1: 0, deposit0: 100, valueLp: 800, valueHold: 800, il: 0, pnl: 0,
+		},
+		{
+			// 100 more liquidity was transferred in, it takes the cost of the tracked 200
+			name: "transferred in", reserve0: 150, reserve1: 600, balance: 300, supply: 300,
+			fee0: 0, fee1: 0, deposit0: 150, valueLp: 1200, valueHold: 1200, il: 0, pnl: 0, untracked: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		swapl := &models.SwapLiquidity{Amt0: models.NewNumber(tt.reserve0), Amt1: models.NewNumber(tt.reserve1)}
+		p := &SwapPosition{Liquidity: models.NewNumber(tt.balance), LiquidityTotal: models.NewNumber(tt.supply)}
+
+		// the holder created the pool with 100 and 400, 200 liquidity
+		p.fill(swapl, big.NewInt(200), big.NewInt(100), big.NewInt(400), big.NewInt(200))
+
+		got := []int64{p.Fee0.Int().Int64(), p.Fee1.Int().Int64(), p.Deposit0.Int().Int64(), p.ValueLp.Int().Int64(), p.ValueHold.Int().Int64(), p.Untracked.Int().Int64()}
+		want := []int64{tt.fee0, tt.fee1, tt.deposit0, tt.valueLp, tt.valueHold, tt.untracked}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: fee0, fee1, deposit0, value_lp, value_hold, untracked = %v want %v", tt.name, got, want)
+			}
+		}
+
+		if p.ImpermanentLoss != tt.il || p.Pnl != tt.pnl {
+			t.Fatalf("%s: impermanent loss %v pnl %v want %v %v", tt.name, p.ImpermanentLoss, p.Pnl, tt.il, tt.pnl)
+		}
+	}
+}
+
+func TestPositionShare(t *testing.T) {
+	cases := []struct {
+		liquidity, total int64
+		share            float64
+	}{
+		{1, 3, 33.3333},
+		{200, 400, 50},
+		{5, 0, 0},
+	}
+	for _, c := range cases {
+		if share := positionShare(big.NewInt(c.liquidity), big.NewInt(c.total)); share != c.share {
+			t.Fatalf("share of %d in %d = %v want %v", c.liquidity, c.total, share, c.share)
+		}
+	}
+}
+
+func TestPositionSupplies(t *testing.T) {
+	conn := newTestClient(t, &models.Drc20Revert{})
+
+	// mints and burns of the pool by two holders, the transfer between them does not change the supply
+	reverts := []*models.Drc20Revert{
+		{ToAddress: "Dother", Amt: models.NewNumber(100), TxHash: "t0", BlockNumber: 1},
+		{ToAddress: "Dme", Amt: models.NewNumber(50), TxHash: "t1", BlockNumber: 2},
+		{FromAddress: "Dme", ToAddress: "Dother", Amt: models.NewNumber(10), TxHash: "t2", BlockNumber: 3},
+		{FromAddress: "Dother", Amt: models.NewNumber(30), TxHash: "t3", BlockNumber: 4},
+		{FromAddress: "Dme", Amt: models.NewNumber(20), TxHash: "t4", BlockNumber: 5},
+		{ToAddress: "Dme", Amt: models.NewNumber(70), TxHash: "t5", BlockNumber: 6},
+	}
+	for _, revert := range reverts {
+		revert.Tick = "A-SWAP-B"
+		if err := conn.DB.Create(revert).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	infos := []*models.SwapInfo{{TxHash: "t1", BlockNumber: 2}, {TxHash: "t4", BlockNumber: 5}}
+	supplies, err := conn.findPositionSupplies("A-SWAP-B", infos)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(supplies) != 2 || len(supplies["t1"]) != 1 || len(supplies["t4"]) != 1 {
+		t.Fatalf("supplies of other transactions are kept: %v", supplies)
+	}
+
+	if s := supplies["t1"][0]; !s.mint || s.address != "Dme" || s.total.Int64() != 150 {
+		t.Fatalf("mint %v %s supply %s, want 150", s.mint, s.address, s.total)
+	}
+
+	if s := supplies["t4"][0]; s.mint || s.address != "Dme" || s.total.Int64() != 100 {
+		t.Fatalf("burn %v %s supply %s, want 100", s.mint, s.address, s.total)
+	}
+}
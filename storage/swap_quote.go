@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+	"math/big"
+)
+
+const (
+	MaxQuoteHops = 3
+)
+
+type SwapQuoteHop struct {
+	Tick       string         `json:"tick"`
+	TickIn     string         `json:"tick_in"`
+	TickOut    string         `json:"tick_out"`
+	AmtIn      *models.Number `json:"amt_in"`
+	AmtOut     *models.Number `json:"amt_out"`
+	ReserveIn  *models.Number `json:"reserve_in"`
+	ReserveOut *models.Number `json:"reserve_out"`
+	Fee        int            `json:"fee"`
+}
+
+type SwapQuote struct {
+	TickIn      string          `json:"tick_in"`
+	TickOut     string          `json:"tick_out"`
+	AmtIn       *models.Number  `json:"amt_in"`
+	AmtOut      *models.Number  `json:"amt_out"`
+	PriceImpact float64         `json:"price_impact"`
+	Route       []*SwapQuoteHop `json:"route"`
+}
+
+type quotePool struct {
+	liquidity *models.SwapLiquidity
+	reserves  map[string]*big.Int
+}
+
+// SwapQuoteExactIn finds the route of at most MaxQuoteHops pools that returns the most tokens for amtIn.
+func (c *DBClient) SwapQuoteExactIn(tickIn, tickOut string, amtIn *big.Int) (*SwapQuote, error) {
+	if amtIn.Sign() <= 0 {
+		return nil, fmt.Errorf("the amount of tokens exceeds the 0")
+	}
+
+	paths, err := c.quotePaths(tickIn, tickOut)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *SwapQuote
+	for _, path := range paths {
+		quote := &SwapQuote{TickIn: tickIn, TickOut: tickOut, AmtIn: (*models.Number)(amtIn)}
+		amt := amtIn
+		tick := tickIn
+		for _, pool := range path {
+			next := pool.other(tick)
+			out := SwapAmountOut(amt, pool.reserves[tick], pool.reserves[next], pool.liquidity.Fee)
+			quote.Route = append(quote.Route, pool.hop(tick, next, amt, out))
+			amt, tick = out, next
+		}
+
+		if amt.Sign() <= 0 {
+			continue
+		}
+
+		quote.AmtOut = (*models.Number)(amt)
+		if best == nil || amt.Cmp(best.AmtOut.Int()) > 0 {
+			best = quote
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("the amount of tokens is too small to swap")
+	}
+
+	best.PriceImpact = priceImpact(best)
+	return best, nil
+}
+
+// SwapQuoteExactOut finds the route of at most MaxQuoteHops pools that needs the least input to return amtOut.
+func (c *DBClient) SwapQuoteExactOut(tickIn, tickOut string, amtOut *big.Int) (*SwapQuote, error) {
+	if amtOut.Sign() <= 0 {
+		return nil, fmt.Errorf("the amount of tokens exceeds the 0")
+	}
+
+	paths, err := c.quotePaths(tickIn, tickOut)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *SwapQuote
+	for _, path := range paths {
+		quote := &SwapQuote{TickIn: tickIn, TickOut: tickOut, AmtOut: (*models.Number)(amtOut)}
+		hops := make([]*SwapQuoteHop, len(path))
+		amt := amtOut
+		tick := tickOut
+		ok := true
+		for i := len(path) - 1; i >= 0; i-- {
+			prev := path[i].other(tick)
+			in, err := SwapAmountIn(amt, path[i].reserves[prev], path[i].reserves[tick], path[i].liquidity.Fee)
+			if err != nil {
+				ok = false
+				break
+			}
+			hops[i] = path[i].hop(prev, tick, in, amt)
+			amt, tick = in, prev
+		}
+
+		if !ok {
+			continue
+		}
+
+		quote.AmtIn = (*models.Number)(amt)
+		quote.Route = hops
+		if best == nil || amt.Cmp(best.AmtIn.Int()) < 0 {
+			best = quote
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("the amount of tokens exceeds the reserves")
+	}
+
+	best.PriceImpact = priceImpact(best)
+	return best, nil
+}
+
+// quotePaths lists every route of at most MaxQuoteHops pools from tickIn to tickOut.
+func (c *DBClient) quotePaths(tickIn, tickOut string) ([][]*quotePool, error) {
+	if tickIn == tickOut {
+		return nil, fmt.Errorf("the token symbol must be different")
+	}
+
+	liquidityAll := make([]*models.SwapLiquidity, 0)
+	err := c.DB.Where("liquidity_total != '0'").Find(&liquidityAll).Error
+	if err != nil {
+		return nil, fmt.Errorf("quotePaths FindSwapLiquidity error: %v", err)
+	}
+
+	pools := make(map[string][]*quotePool)
+	for _, l := range liquidityAll {
+		if l.Amt0 == nil || l.Amt1 == nil || l.Amt0.Int().Sign() <= 0 || l.Amt1.Int().Sign() <= 0 {
+			continue
+		}
+
+		pool := &quotePool{
+			liquidity: l,
+			reserves:  map[string]*big.Int{l.Tick0: l.Amt0.Int(), l.Tick1: l.Amt1.Int()},
+		}
+		pools[l.Tick0] = append(pools[l.Tick0], pool)
+		pools[l.Tick1] = append(pools[l.Tick1], pool)
+	}
+
+	paths := make([][]*quotePool, 0)
+	visited := map[string]bool{tickIn: true}
+	var walk func(tick string, path []*quotePool)
+	walk = func(tick string, path []*quotePool) {
+		if len(path) == MaxQuoteHops {
+			return
+		}
+
+		for _, pool := range pools[tick] {
+			next := pool.other(tick)
+			if visited[next] {
+				continue
+			}
+
+			route := append(append([]*quotePool{}, path...), pool)
+			if next == tickOut {
+				paths = append(paths, route)
+				continue
+			}
+
+			visited[next] = true
+			walk(next, route)
+			visited[next] = false
+		}
+	}
+	walk(tickIn, nil)
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("the contract does not exist")
+	}
+
+	return paths, nil
+}
+
+func (p *quotePool) other(tick string) string {
+	if p.liquidity.Tick0 == tick {
+		return p.liquidity.Tick1
+	}
+	return p.liquidity.Tick0
+}
+
+func (p *quotePool) hop(tickIn, tickOut string, amtIn, amtOut *big.Int) *SwapQuoteHop {
+	return &SwapQuoteHop{
+		Tick:       p.liquidity.Tick,
+		TickIn:     tickIn,
+		TickOut:    tickOut,
+		AmtIn:      (*models.Number)(amtIn),
+		AmtOut:     (*models.Number)(amtOut),
+		ReserveIn:  (*models.Number)(p.reserves[tickIn]),
+		ReserveOut: (*models.Number)(p.reserves[tickOut]),
+		Fee:        p.liquidity.Fee,
+	}
+}
+
+// priceImpact compares the execution price with the spot price along the route, fees included, in percent.
+func priceImpact(quote *SwapQuote) float64 {
+	spot := big.NewRat(1, 1)
+	for _, hop := range quote.Route {
+		spot.Mul(spot, new(big.Rat).SetFrac(hop.ReserveOut.Int(), hop.ReserveIn.Int()))
+	}
+
+	exec := new(big.Rat).SetFrac(quote.AmtOut.Int(), quote.AmtIn.Int())
+	impact := new(big.Rat).Sub(big.NewRat(1, 1), new(big.Rat).Quo(exec, spot))
+	impact.Mul(impact, big.NewRat(100, 1))
+
+	f, _ := impact.Float64()
+	return f
+}
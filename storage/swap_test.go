@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/unielon-org/unielon-indexer/models"
+)
+
+func bigInt(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("bad number " + s)
+	}
+	return n
+}
+
+func TestSwapFeeLegacy(t *testing.T) {
+	amounts := []string{"0", "1", "999", "1000", "1999", "123456789", "100000000000000000007"}
+	reserves := [][2]string{{"1000", "1000"}, {"500000000000", "7000000000000"}, {"3", "100000000000000000000"}}
+
+	for _, a := range amounts {
+		amt := bigInt(a)
+
+		// the fee of the pools before the tiers
+		legacy := new(big.Int).Div(amt, big.NewInt(1000))
+		legacy.Mul(legacy, big.NewInt(3))
+		if fee := swapFee(amt, 30); fee.Cmp(legacy) != 0 {
+			t.Fatalf("swapFee(%s, 30) = %s want %s", a, fee, legacy)
+		}
+
+		for _, r := range reserves {
+			reserveIn, reserveOut := bigInt(r[0]), bigInt(r[1])
+			amtin := new(big.Int).Sub(amt, legacy)
+			want := new(big.Int).Mul(amtin, reserveOut)
+			want.Div(want, new(big.Int).Add(reserveIn, amtin))
+
+			if out := SwapAmountOut(amt, reserveIn, reserveOut, 30); out.Cmp(want) != 0 {
+				t.Fatalf("SwapAmountOut(%s, %s, %s, 30) = %s want %s", a, r[0], r[1], out, want)
+			}
+		}
+	}
+}
+
+func TestSwapAmountIn(t *testing.T) {
+	reserves := [][2]string{{"1000000", "1000000"}, {"500000000000", "7000000000000"}, {"7000000000000", "500000000"}}
+	outs := []string{"1", "2", "999", "1000", "12345", "99999"}
+
+	for _, fee := range []int{1, 5, 30, 100, 300, 1000, 9999} {
+		for _, r := range reserves {
+			reserveIn, reserveOut := bigInt(r[0]), bigInt(r[1])
+			for _, o := range outs {
+				amtOut := bigInt(o)
+
+				amtIn, err := SwapAmountIn(amtOut, reserveIn, reserveOut, fee)
+				if err != nil {
+					t.Fatalf("fee %d reserves %v out %s: %s", fee, r, o, err)
+				}
+
+				if got := SwapAmountOut(amtIn, reserveIn, reserveOut, fee); got.Cmp(amtOut) < 0 {
+					t.Fatalf("fee %d reserves %v: %s in gives %s, less than %s", fee, r, amtIn, got, o)
+				}
+
+				less := new(big.Int).Sub(amtIn, big.NewInt(1))
+				if got := SwapAmountOut(less, reserveIn, reserveOut, fee); got.Cmp(amtOut) >= 0 {
+					t.Fatalf("fee %d reserves %v: %s in is not minimal, %s gives %s", fee, r, amtIn, less, got)
+				}
+			}
+		}
+	}
+
+	if _, err := SwapAmountIn(big.NewInt(1000), big.NewInt(1000), big.NewInt(1000), 30); err == nil {
+		t.Fatal("an output of all the reserves must be refused")
+	}
+
+	if _, err := SwapAmountIn(big.NewInt(0), big.NewInt(1000), big.NewInt(1000), 30); err == nil {
+		t.Fatal("an output of 0 must be refused")
+	}
+}
+
+func TestSwapMintFee(t *testing.T) {
+	conn := newTestClient(t, &models.Drc20Collect{}, &models.Drc20CollectAddress{}, &models.Drc20Revert{})
+
+	tick := "WOW-SWAP-CARDI"
+	err := conn.DB.Create(&models.Drc20Collect{Tick: tick, AmtSum: models.NewNumber(1000000)}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sqrt(k) grew from 1000000 to 1100000, the protocol gets a sixth of the growth:
+	// 1000000 * 100000 / (5 * 1100000 + 1000000)
+	pool := func(kLast int64) *models.SwapLiquidity {
+		return &models.SwapLiquidity{
+			Tick:           tick,
+			Amt0:           models.NewNumber(550000),
+			Amt1:           models.NewNumber(2200000),
+			LiquidityTotal: models.NewNumber(1000000),
+			KLast:          (*models.Number)(new(big.Int).Mul(big.NewInt(kLast), big.NewInt(kLast))),
+		}
+	}
+
+	swapl := pool(1000000)
+	tx := conn.DB.Begin()
+	defer tx.Rollback()
+
+	if err := conn.swapMintFee(tx, swapl, "DFeeTo", "tx", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	minted := &models.Drc20CollectAddress{}
+	if err := tx.Where("tick = ? and holder_address = ?", tick, "DFeeTo").First(minted).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if minted.AmtSum.Int().Int64() != 15384 || swapl.LiquidityTotal.Int().Int64() != 1015384 {
+		t.Fatalf("minted %s, liquidity total %s", minted.AmtSum.String(), swapl.LiquidityTotal.String())
+	}
+
+	// nothing is minted while the fee is off, without k_last or without growth
+	idle := []struct {
+		name  string
+		swapl *models.SwapLiquidity
+		feeTo string
+	}{
+		{name: "fee off", swapl: pool(1000000)},
+		{name: "no k_last", swapl: pool(0), feeTo: "DFeeTo"},
+		{name: "no growth", swapl: pool(1100000), feeTo: "DFeeTo"},
+	}
+	for _, tt := range idle {
+		if err := conn.swapMintFee(tx, tt.swapl, tt.feeTo, "tx", 11); err != nil {
+			t.Fatal(err)
+		}
+
+		if tt.swapl.LiquidityTotal.Int().Int64() != 1000000 {
+			t.Fatalf("%s: liquidity total %s", tt.name, tt.swapl.LiquidityTotal.String())
+		}
+	}
+}
@@ -3,6 +3,7 @@ package storage_v3
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/dogecoinw/go-dogecoin/log"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/unielon-org/unielon-indexer/models"
@@ -27,20 +28,19 @@ type MysqlClient struct {
 	lock    *sync.RWMutex
 }
 
-func NewSqliteClient(cfg utils.SqliteConfig) *MysqlClient {
+func NewSqliteClient(cfg utils.SqliteConfig) (*MysqlClient, error) {
 
 	db, err := sql.Open("sqlite3", cfg.Database)
 	if err != nil {
-		log.Error("NewMysqlClient", "err", err)
-		return nil
+		return nil, fmt.Errorf("NewSqliteClient open %s err: %s", cfg.Database, err.Error())
 	}
 
 	_, err = db.Exec("PRAGMA journal_mode=WAL;")
 	_, err = db.Exec("PRAGMA busy_timeout=3000;")
 	_, err = db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
 	if err != nil {
-		log.Error("NewMysqlClient", "err", err)
-		return nil
+		db.Close()
+		return nil, fmt.Errorf("NewSqliteClient %s err: %s", cfg.Database, err.Error())
 	}
 
 	lock := new(sync.RWMutex)
@@ -49,7 +49,7 @@ func NewSqliteClient(cfg utils.SqliteConfig) *MysqlClient {
 		lock:    lock,
 	}
 
-	return conn
+	return conn, nil
 }
 
 func (conn *MysqlClient) Stop() {
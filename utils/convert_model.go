@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/unielon-org/unielon-indexer/models"
 	"strings"
 )
@@ -120,6 +122,9 @@ func ConvertCross(inscription *models.CrossInscription) (*models.CrossInfo, erro
 		AdminAddress:  inscription.AdminAddress,
 		HolderAddress: inscription.HolderAddress,
 		ToAddress:     inscription.ToAddress,
+		DepositId:     inscription.DepositId,
+		BurnTxHash:    inscription.BurnTxHash,
+		ExtTxId:       inscription.ExtTxId,
 	}
 
 	var err error
@@ -131,6 +136,31 @@ func ConvertCross(inscription *models.CrossInscription) (*models.CrossInfo, erro
 	return swap, nil
 }
 
+// ConvertCrossAdmin parses the admins of a set-admin or the threshold of a set-threshold into cross.
+func ConvertCrossAdmin(cross *models.CrossInfo, inscription *models.CrossInscription) error {
+	switch cross.Op {
+	case "set-admin":
+		admins := make([]string, 0)
+		if err := json.Unmarshal(inscription.Admins, &admins); err != nil {
+			return fmt.Errorf("admins error")
+		}
+
+		for _, admin := range admins {
+			if strings.Contains(admin, ",") {
+				return fmt.Errorf("admins error")
+			}
+		}
+		cross.Admins = strings.Join(admins, ",")
+	case "set-threshold":
+		threshold, err := ConvertRawInt(inscription.Threshold)
+		if err != nil {
+			return err
+		}
+		cross.Threshold = int(threshold)
+	}
+	return nil
+}
+
 func ConvertNft(inscription *models.NftInscription) (*models.NftInfo, error) {
 	nft := &models.NftInfo{
 		Op:     inscription.Op,
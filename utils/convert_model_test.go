@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/unielon-org/unielon-indexer/models"
+)
+
+func TestConvertCrossAdmin(t *testing.T) {
+	cases := []struct {
+		data      string
+		admins    string
+		threshold int
+		err       bool
+	}{
+		{`{"op":"set-admin","admins":["A","B"]}`, "A,B", 0, false},
+		{`{"op":"set-admin","admins":"A"}`, "", 0, true},
+		{`{"op":"set-admin","admins":["A,B"]}`, "", 0, true},
+		{`{"op":"set-threshold","threshold":2}`, "", 2, false},
+		{`{"op":"set-threshold","threshold":"2"}`, "", 2, false},
+		{`{"op":"set-threshold","threshold":1.5}`, "", 0, true},
+		// other ops keep whatever they carry unparsed
+		{`{"op":"mint","admins":"A","threshold":1.5}`, "", 0, false},
+	}
+
+	for _, c := range cases {
+		inscription := &models.CrossInscription{}
+		if err := json.Unmarshal([]byte(c.data), inscription); err != nil {
+			t.Fatalf("%s: %s", c.data, err)
+		}
+
+		cross, err := ConvertCross(inscription)
+		if err != nil {
+			t.Fatalf("%s: %s", c.data, err)
+		}
+
+		err = ConvertCrossAdmin(cross, inscription)
+		if (err != nil) != c.err {
+			t.Errorf("%s: err %v", c.data, err)
+			continue
+		}
+		if err == nil && (cross.Admins != c.admins || cross.Threshold != c.threshold) {
+			t.Errorf("%s: admins %q threshold %d", c.data, cross.Admins, cross.Threshold)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// FormatDecimal renders the integer amount n of a tick with dec decimals, "150000000" with 8 decimals is "1.5".
+func FormatDecimal(n *big.Int, dec uint) string {
+	s := new(big.Int).Abs(n).String()
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+	}
+
+	if dec == 0 {
+		return sign + s
+	}
+
+	if uint(len(s)) <= dec {
+		s = strings.Repeat("0", int(dec)-len(s)+1) + s
+	}
+
+	whole, frac := s[:uint(len(s))-dec], strings.TrimRight(s[uint(len(s))-dec:], "0")
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
+
+// ParseDecimal is the inverse of FormatDecimal, amounts with more fractional digits than dec are rejected.
+func ParseDecimal(s string, dec uint) (*big.Int, error) {
+	whole, frac, found := strings.Cut(s, ".")
+	if whole == "" || (found && frac == "") {
+		return nil, fmt.Errorf("number error")
+	}
+
+	if uint(len(frac)) > dec {
+		return nil, fmt.Errorf("more than %d decimals", dec)
+	}
+
+	for _, r := range whole + frac {
+		if r < '0' || r > '9' {
+			return nil, fmt.Errorf("number error")
+		}
+	}
+
+	n, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", int(dec)-len(frac)), 10)
+	if !ok {
+		return nil, fmt.Errorf("number error")
+	}
+	return n, nil
+}
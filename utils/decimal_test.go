@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestDecimal(t *testing.T) {
+	cases := []struct {
+		n   string
+		dec uint
+		s   string
+	}{
+		{"150000000", 8, "1.5"},
+		{"1", 8, "0.00000001"},
+		{"0", 8, "0"},
+		{"100000000", 8, "1"},
+		{"123", 0, "123"},
+		{"-25", 2, "-0.25"},
+		{"1000000000000000000000000000001", 18, "1000000000000.000000000000000001"},
+	}
+
+	for _, c := range cases {
+		n, _ := new(big.Int).SetString(c.n, 10)
+		if s := FormatDecimal(n, c.dec); s != c.s {
+			t.Errorf("FormatDecimal(%s, %d) = %s, want %s", c.n, c.dec, s, c.s)
+		}
+
+		if n.Sign() < 0 {
+			continue
+		}
+
+		back, err := ParseDecimal(c.s, c.dec)
+		if err != nil || back.Cmp(n) != 0 {
+			t.Errorf("ParseDecimal(%s, %d) = %v, %v, want %s", c.s, c.dec, back, err, c.n)
+		}
+	}
+
+	for _, s := range []string{"", ".5", "1.", "1.123456789", "1e8", "-1", "1,5", "+1"} {
+		if _, err := ParseDecimal(s, 8); err == nil {
+			t.Errorf("ParseDecimal(%q) should fail", s)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/unielon-org/unielon-indexer/models"
+	"strconv"
 	"strings"
 )
 
@@ -70,6 +71,9 @@ func FormatSwapToJson(swap *models.SwapInfo) []byte {
 		data["amt1"] = swap.Amt1.String()
 		data["amt0_min"] = swap.Amt0Min.String()
 		data["amt1_min"] = swap.Amt1Min.String()
+		if swap.Fee != 0 {
+			data["fee"] = swap.Fee
+		}
 		jsonData, err := json.Marshal(data)
 		if err != nil {
 			fmt.Println("JSON encoding failed:", err)
@@ -220,6 +224,9 @@ func FormatExChangeToJson(ex *models.ExchangeInfo) []byte {
 		data["tick1"] = ex.Tick1
 		data["amt0"] = ex.Amt0.String()
 		data["amt1"] = ex.Amt1.String()
+		if ex.Expire != 0 {
+			data["expire"] = strconv.FormatInt(ex.Expire, 10)
+		}
 		jsonData, err := json.Marshal(data)
 		if err != nil {
 			fmt.Println("JSON encoding failed:", err)
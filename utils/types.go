@@ -9,8 +9,57 @@ import (
 
 // Config
 type HttpConfig struct {
+	Switch      bool            `json:"switch"`
+	Server      string          `json:"server"`
+	ReadyMaxLag int64           `json:"ready_max_lag"`
+	Cache       CacheConfig     `json:"cache"`
+	Cors        CorsConfig      `json:"cors"`
+	Auth        AuthConfig      `json:"auth"`
+	RateLimit   RateLimitConfig `json:"rate_limit"`
+}
+
+type CorsConfig struct {
+	AllowOrigins []string `json:"allow_origins"`
+	MaxAge       int64    `json:"max_age"`
+}
+
+// AuthConfig identifies callers by API key. Keys come from Keys and the api_keys table,
+// with Switch off requests without a key are still served with the anonymous limits.
+type AuthConfig struct {
+	Switch bool           `json:"switch"`
+	Header string         `json:"header"`
+	Keys   []ApiKeyConfig `json:"keys"`
+}
+
+// ApiKeyConfig is a caller of the api, Admin keys may also moderate submitted metadata.
+type ApiKeyConfig struct {
+	Key   string `json:"key"`
+	Name  string `json:"name"`
+	Tier  string `json:"tier"`
+	Admin bool   `json:"admin"`
+}
+
+// RateLimitConfig limits requests with token buckets, per API key or per client IP without one.
+// Expensive routes draw from their own, usually smaller, bucket.
+type RateLimitConfig struct {
+	Switch    bool             `json:"switch"`
+	Tiers     []RateTierConfig `json:"tiers"`
+	Expensive []string         `json:"expensive"`
+}
+
+// RateTierConfig is the request rate per second and burst of one tier, "anonymous" applies to requests without a key.
+type RateTierConfig struct {
+	Name           string  `json:"name"`
+	Rate           float64 `json:"rate"`
+	Burst          int     `json:"burst"`
+	ExpensiveRate  float64 `json:"expensive_rate"`
+	ExpensiveBurst int     `json:"expensive_burst"`
+}
+
+type CacheConfig struct {
 	Switch bool   `json:"switch"`
-	Server string `json:"server"`
+	Store  string `json:"store"`
+	Size   int    `json:"size"`
 }
 
 type LevelDBConfig struct {
@@ -32,17 +81,77 @@ type MysqlConfig struct {
 }
 
 type ChainConfig struct {
-	ChainName string `json:"chain_name"`
-	Rpc       string `json:"rpc"`
-	UserName  string `json:"user_name"`
-	PassWord  string `json:"pass_word"`
+	ChainName string       `json:"chain_name"`
+	Rpc       string       `json:"rpc"`
+	UserName  string       `json:"user_name"`
+	PassWord  string       `json:"pass_word"`
+	Timeout   int64        `json:"timeout"`
+	Retries   int          `json:"retries"`
+	Fallbacks []NodeConfig `json:"fallbacks"`
+}
+
+// NodeConfig is a dogecoind node used when the primary one fails.
+type NodeConfig struct {
+	Rpc      string `json:"rpc"`
+	UserName string `json:"user_name"`
+	PassWord string `json:"pass_word"`
 }
 
 type ExplorerConfig struct {
-	Switch       bool  `json:"switch"`
-	FromBlock    int64 `json:"from_block"`
-	InitMintData bool  `json:"init_mint_data"`
-	InitForkData bool  `json:"init_fork_data"`
+	Switch    bool         `json:"switch"`
+	FromBlock int64        `json:"from_block"`
+	Leader    LeaderConfig `json:"leader"`
+}
+
+// LeaderConfig lets replicas sharing one database elect the one that runs the explorer.
+type LeaderConfig struct {
+	Switch bool   `json:"switch"`
+	Name   string `json:"name"`
+	Id     string `json:"id"`
+	Ttl    int64  `json:"ttl"`
+}
+
+// ActivationConfig holds the block heights from which protocol rules apply, 0 never activates a rule.
+type ActivationConfig struct {
+	Drc20Burn int64 `json:"drc20_burn"`
+	Drc20Func int64 `json:"drc20_func"`
+	// ExchangeFill rejects order-v1 trades that overfill an order and trades or cancels of closed orders
+	ExchangeFill int64 `json:"exchange_fill"`
+	// ExchangeExpire reads the expire height of order-v1 creates, orders are refunded at that height
+	ExchangeExpire int64 `json:"exchange_expire"`
+	// SwapFee lets pair-v1 pools be created with a fee of swap.fee_tiers and turns the protocol fee on
+	SwapFee int64 `json:"swap_fee"`
+	// CrossQueue requires a unique deposit_id on cross mints and a chain and to_address on burns, and takes
+	// the release acknowledgments of the admin
+	CrossQueue int64 `json:"cross_queue"`
+	// CrossAdmin takes the set-admin and set-threshold cross ops, the admins of a token approve its mints
+	// together, it needs cross_queue at or before it
+	CrossAdmin int64 `json:"cross_admin"`
+}
+
+// SwapConfig holds the fees in basis points a pair-v1 pool can be created with and the address the
+// protocol share of the fees is minted to, an empty address leaves all fees to the liquidity providers.
+type SwapConfig struct {
+	FeeTiers      []int  `json:"fee_tiers"`
+	ProtocolFeeTo string `json:"protocol_fee_to"`
+}
+
+// PriceOracleConfig selects the DOGE/USD price recorded on the candles, source is static, file or http
+// and an empty source records none. Url must hold {time} for the unix seconds of the candle start.
+type PriceOracleConfig struct {
+	Source  string  `json:"source"`
+	Price   float64 `json:"price"`
+	File    string  `json:"file"`
+	Url     string  `json:"url"`
+	Field   string  `json:"field"`
+	Timeout int64   `json:"timeout"`
+	Ttl     int64   `json:"ttl"`
+}
+
+// LogConfig selects the log output, Modules sets the level of single modules, e.g. "explorer=debug,chain=warn".
+type LogConfig struct {
+	Format  string `json:"format"`
+	Modules string `json:"modules"`
 }
 
 type HttpResult struct {
@@ -2,18 +2,22 @@ package utils
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"github.com/dogecoinw/doged/btcec/ecdsa"
-	"github.com/dogecoinw/doged/btcutil"
-	"github.com/dogecoinw/doged/chaincfg"
 	"github.com/unielon-org/unielon-indexer/models"
 	"math"
 	"math/big"
+	"strconv"
 	"time"
 )
 
+const (
+	WDogeFeeAddress  = "D86Dc4n49LZDiXvB41ds2XaDAP1BFjP1qy"
+	WDogeCoolAddress = "DKMyk8cfSTGfnCVXfmo8gXta9F6gziu7Z5"
+	NftFeeAddress    = "DBFQmJ5oGCgtnDVxUU7xEraztpEyqJHdxz"
+)
+
 var (
 	MAX_NUMBER, _ = big.NewInt(0).SetString("99999999999999999999999999999999999999999", 10)
 )
@@ -40,6 +44,28 @@ func ConvetStringToNumber(number string) (*models.Number, error) {
 	return new(models.Number), nil
 }
 
+// ConvertRawInt parses an integer field of an inscription that is written either as a number or as a
+// string, a missing field is 0. Fields that only count from an activation keep the raw json until then,
+// so that an older inscription with any value still decodes.
+func ConvertRawInt(raw json.RawMessage) (int64, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, nil
+	}
+
+	number := string(raw)
+	if raw[0] == '"' {
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return 0, fmt.Errorf("number error")
+		}
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("number error")
+	}
+	return n, nil
+}
+
 func SortTokens(Tick0 string, Tick1 string, Amt0, Amt1, Amt0Min, Amt1Min *models.Number) (string, string, *models.Number, *models.Number, *models.Number, *models.Number) {
 	if Tick0 > Tick1 {
 		return Tick1, Tick0, Amt1, Amt0, Amt1Min, Amt0Min
@@ -210,33 +236,3 @@ func WriteVarInt(w *bytes.Buffer, n int64) {
 		})
 	}
 }
-
-func GetAddressFromSig(msg string, sig string) (string, error) {
-
-	message := msg
-	sigBytes, err := base64.StdEncoding.DecodeString(sig)
-	if err != nil {
-		return "", err
-	}
-
-	var buf bytes.Buffer
-	buf.Write([]byte("\x18Bitcoin Signed Message:\n"))
-	WriteVarInt(&buf, int64(len(message)))
-	buf.Write([]byte(message))
-
-	// Compute the hash of the preprocessed message
-	hash := sha256.Sum256(buf.Bytes())
-	hash = sha256.Sum256(hash[:])
-
-	publicKey, _, err := ecdsa.RecoverCompact(sigBytes, hash[:])
-	if err != nil {
-		return "", err
-	}
-
-	inadress, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(publicKey.SerializeCompressed()), &chaincfg.MainNetParams)
-	if err != nil {
-		return "", err
-	}
-
-	return inadress.String(), nil
-}
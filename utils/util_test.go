@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConvertRawInt(t *testing.T) {
+	cases := []struct {
+		raw string
+		n   int64
+		err bool
+	}{
+		{"", 0, false},
+		{"null", 0, false},
+		{"30", 30, false},
+		{`"30"`, 30, false},
+		{"-1", -1, false},
+		{`""`, 0, true},
+		{"1.5", 0, true},
+		{`"abc"`, 0, true},
+		{"true", 0, true},
+		{"[1]", 0, true},
+	}
+
+	for _, c := range cases {
+		n, err := ConvertRawInt(json.RawMessage(c.raw))
+		if (err != nil) != c.err || n != c.n {
+			t.Errorf("ConvertRawInt(%s) = %d, %v, want %d, err %v", c.raw, n, err, c.n, c.err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package verifys
+
+import (
+	"errors"
+	"fmt"
+	"github.com/unielon-org/unielon-indexer/models"
+	"gorm.io/gorm"
+	"math/big"
+	"strings"
+)
+
+// The burn and func fields of a drc-20 deploy only have a meaning for ticks deployed at or after
+// their activation height, older ticks keep minting like before whatever the fields hold.
+//
+//	burn  ""/"0"           holders can not burn
+//	      "1"              holders may burn their balance with {"op":"burn","tick":..,"amt":..},
+//	                       a burned amount is taken off the supply and can not be minted again
+//	func  ""/"open"        anyone mints up to lim per mint
+//	      "deployer"       only mints to the deployer address are valid
+//	      "fair:<amount>"  every address can be minted at most amount in total
+const (
+	Drc20BurnOff = "0"
+	Drc20BurnOn  = "1"
+
+	Drc20FuncOpen     = "open"
+	Drc20FuncDeployer = "deployer"
+	drc20FuncFair     = "fair:"
+)
+
+// Drc20Policy is the mint policy selected by the func field.
+type Drc20Policy struct {
+	DeployerOnly bool
+	AddressCap   *big.Int
+}
+
+func ParseDrc20Func(f string) (*Drc20Policy, error) {
+	switch {
+	case f == "" || f == Drc20FuncOpen:
+		return &Drc20Policy{}, nil
+	case f == Drc20FuncDeployer:
+		return &Drc20Policy{DeployerOnly: true}, nil
+	case strings.HasPrefix(f, drc20FuncFair):
+		cap, ok := big.NewInt(0).SetString(strings.TrimPrefix(f, drc20FuncFair), 10)
+		if !ok || cap.Cmp(Number0) < 1 {
+			return nil, fmt.Errorf("the fair mint cap must be a positive integer")
+		}
+		return &Drc20Policy{AddressCap: cap}, nil
+	default:
+		return nil, fmt.Errorf("unknown func %q", f)
+	}
+}
+
+func validDrc20Burn(b string) bool {
+	return b == "" || b == Drc20BurnOff || b == Drc20BurnOn
+}
+
+func (v *Verifys) burnActive(height int64) bool {
+	return v.activation.Drc20Burn > 0 && height >= v.activation.Drc20Burn
+}
+
+func (v *Verifys) funcActive(height int64) bool {
+	return v.activation.Drc20Func > 0 && height >= v.activation.Drc20Func
+}
+
+// drc20Rules is what the burn and func fields of a deployed tick mean.
+type drc20Rules struct {
+	burnable bool
+	policy   *Drc20Policy
+}
+
+func (v *Verifys) rulesOf(collect *models.Drc20Collect) (*drc20Rules, error) {
+	rules := &drc20Rules{policy: &Drc20Policy{}}
+	if v.activation.Drc20Burn == 0 && v.activation.Drc20Func == 0 {
+		return rules, nil
+	}
+
+	deploy := &models.Drc20Info{}
+	err := v.dbc.DB.Select("block_number").Where("tx_hash = ? and op = 'deploy'", collect.TxHash).First(deploy).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return rules, nil
+		}
+		return nil, fmt.Errorf("find deploy err %s", err.Error())
+	}
+
+	rules.burnable = v.burnActive(deploy.BlockNumber) && collect.Burn == Drc20BurnOn
+
+	if v.funcActive(deploy.BlockNumber) {
+		policy, err := ParseDrc20Func(collect.Func)
+		if err != nil {
+			return nil, err
+		}
+		rules.policy = policy
+	}
+	return rules, nil
+}
+
+func (v *Verifys) verifyBurn(card *models.Drc20Info) error {
+	if !v.burnActive(card.BlockNumber) {
+		return fmt.Errorf("burn is not active")
+	}
+
+	if card.Amt.Int().Cmp(Number0) < 1 {
+		return fmt.Errorf("the amount of tokens exceeds the 0")
+	}
+
+	collect := &models.Drc20Collect{}
+	err := v.dbc.DB.Where("tick = ?", card.Tick).First(collect).Error
+	if err != nil {
+		return fmt.Errorf("the contract does not exist")
+	}
+
+	rules, err := v.rulesOf(collect)
+	if err != nil {
+		return err
+	}
+
+	if !rules.burnable {
+		return fmt.Errorf("the token can not be burned")
+	}
+
+	balance := &models.Drc20CollectAddress{}
+	err = v.dbc.DB.Where("tick = ? and holder_address = ?", card.Tick, card.HolderAddress).First(balance).Error
+	if err != nil {
+		return fmt.Errorf("the contract does not exist")
+	}
+
+	if card.Amt.Int().Cmp(balance.AmtSum.Int()) > 0 {
+		return fmt.Errorf("the amount of tokens exceeds the balance")
+	}
+	return nil
+}
+
+// verifyMintRules checks a mint of amount against the burned supply and the mint policy of the tick.
+func (v *Verifys) verifyMintRules(card *models.Drc20Info, collect *models.Drc20Collect, amount *big.Int) error {
+	rules, err := v.rulesOf(collect)
+	if err != nil {
+		return err
+	}
+
+	if rules.burnable {
+		burned, err := v.dbc.Drc20Burned(v.dbc.DB, collect.Tick)
+		if err != nil {
+			return err
+		}
+
+		total := big.NewInt(0).Add(collect.AmtSum.Int(), burned)
+		if total.Add(total, amount).Cmp(collect.Max.Int()) > 0 {
+			return fmt.Errorf("the amount of tokens exceeds the maximum")
+		}
+	}
+
+	if rules.policy.DeployerOnly && card.HolderAddress != collect.HolderAddress {
+		return fmt.Errorf("only the deployer can mint")
+	}
+
+	if rules.policy.AddressCap != nil {
+		minted, err := v.dbc.Drc20Minted(v.dbc.DB, collect.Tick, card.HolderAddress)
+		if err != nil {
+			return err
+		}
+
+		if minted.Add(minted, amount).Cmp(rules.policy.AddressCap) > 0 {
+			return fmt.Errorf("the amount of tokens exceeds the address cap")
+		}
+	}
+	return nil
+}
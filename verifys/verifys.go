@@ -8,21 +8,31 @@ import (
 	"github.com/unielon-org/unielon-indexer/utils"
 	"gorm.io/gorm"
 	"math/big"
+	"strconv"
 	"strings"
 )
 
+const (
+	// MaxCrossAdmins is the size limit of the admin set of a wrapped token
+	MaxCrossAdmins = 16
+)
+
 var (
 	Number0            = big.NewInt(0)
 	maxAllowedValue, _ = big.NewInt(0).SetString("99999999999999999999999999999999999999999", 10)
 )
 
 type Verifys struct {
-	dbc *storage.DBClient
+	dbc        *storage.DBClient
+	activation utils.ActivationConfig
+	swap       utils.SwapConfig
 }
 
-func NewVerifys(dbc *storage.DBClient) *Verifys {
+func NewVerifys(dbc *storage.DBClient, activation utils.ActivationConfig, swap utils.SwapConfig) *Verifys {
 	return &Verifys{
-		dbc: dbc,
+		dbc:        dbc,
+		activation: activation,
+		swap:       swap,
 	}
 }
 
@@ -34,6 +44,8 @@ func (v *Verifys) VerifyDrc20(card *models.Drc20Info) error {
 		return v.verifyMint(card)
 	case "transfer":
 		return v.verifyTransfer(card)
+	case "burn":
+		return v.verifyBurn(card)
 	default:
 		return fmt.Errorf("do not support the type of tokens")
 	}
@@ -57,6 +69,21 @@ func (v *Verifys) verifyDeploy(card *models.Drc20Info) error {
 		return fmt.Errorf("the maximum value is less than the limit value")
 	}
 
+	if v.burnActive(card.BlockNumber) && !validDrc20Burn(card.Burn) {
+		return fmt.Errorf("burn must be empty, 0 or 1")
+	}
+
+	if v.funcActive(card.BlockNumber) {
+		policy, err := ParseDrc20Func(card.Func)
+		if err != nil {
+			return err
+		}
+
+		if policy.AddressCap != nil && policy.AddressCap.Cmp(card.Max.Int()) > 0 {
+			return fmt.Errorf("the fair mint cap is greater than the maximum")
+		}
+	}
+
 	err := v.dbc.DB.Where("tick = ?", card.Tick).First(&models.Drc20Collect{}).Error
 	if err == nil {
 		return fmt.Errorf("has been deployed contracts")
@@ -95,7 +122,7 @@ func (v *Verifys) verifyMint(card *models.Drc20Info) error {
 		return fmt.Errorf("the amount of tokens exceeds the maximum")
 	}
 
-	return nil
+	return v.verifyMintRules(card, card1, amount)
 }
 
 func (v *Verifys) verifyTransfer(card *models.Drc20Info) error {
@@ -144,6 +171,10 @@ func (v *Verifys) verifySwapCreate(tx *gorm.DB, swap *models.SwapInfo) error {
 		return fmt.Errorf("the token symbol must be different")
 	}
 
+	if v.SwapFeeActive(swap.BlockNumber) && swap.Fee != 0 && !v.swapFeeTier(swap.Fee) {
+		return fmt.Errorf("the fee %d is not one of the fee tiers %v", swap.Fee, v.swap.FeeTiers)
+	}
+
 	tick0, tick1, amt0, amt1, _, _ := utils.SortTokens(swap.Tick0, swap.Tick1, swap.Amt0, swap.Amt1, nil, nil)
 
 	err := tx.Where("tick0 = ? and tick1 = ?", tick0, tick1).First(&models.SwapLiquidity{}).Error
@@ -272,12 +303,7 @@ func (v *Verifys) verifySwapExec(tx *gorm.DB, swap *models.SwapInfo) error {
 	amtMap[swapLiquidity.Tick0] = swapLiquidity.Amt0.Int()
 	amtMap[swapLiquidity.Tick1] = swapLiquidity.Amt1.Int()
 
-	amtfee0 := new(big.Int).Div(swap.Amt0.Int(), big.NewInt(1000))
-	amtin := new(big.Int).Mul(amtfee0, big.NewInt(3))
-	amtin = new(big.Int).Sub(swap.Amt0.Int(), amtin)
-
-	amtout := new(big.Int).Mul(amtin, amtMap[swap.Tick1])
-	amtout = new(big.Int).Div(amtout, new(big.Int).Add(amtMap[swap.Tick0], amtin))
+	amtout := storage.SwapAmountOut(swap.Amt0.Int(), amtMap[swap.Tick0], amtMap[swap.Tick1], swapLiquidity.Fee)
 
 	if amtout.Cmp(swap.Amt1Min.Int()) < 0 {
 		return fmt.Errorf("the minimum output less than the limit.")
@@ -309,6 +335,28 @@ func (v *Verifys) verifySwapExec(tx *gorm.DB, swap *models.SwapInfo) error {
 	return nil
 }
 
+// SwapFeeActive tells whether pools created at height take their fee from the inscription.
+func (v *Verifys) SwapFeeActive(height int64) bool {
+	return v.activation.SwapFee > 0 && height >= v.activation.SwapFee
+}
+
+// SwapProtocolFeeTo returns the address the protocol fee is minted to at height, empty while it is off.
+func (v *Verifys) SwapProtocolFeeTo(height int64) string {
+	if !v.SwapFeeActive(height) {
+		return ""
+	}
+	return v.swap.ProtocolFeeTo
+}
+
+func (v *Verifys) swapFeeTier(fee int) bool {
+	for _, tier := range v.swap.FeeTiers {
+		if tier == fee {
+			return true
+		}
+	}
+	return false
+}
+
 func (v *Verifys) verifySwapRemove(tx *gorm.DB, swap *models.SwapInfo) error {
 
 	if swap.Liquidity.Int().Cmp(Number0) < 1 {
@@ -698,6 +746,10 @@ func (v *Verifys) VerifyExchangeCreate(ex *models.ExchangeInfo) error {
 		return fmt.Errorf("the amount of tokens exceeds the 0")
 	}
 
+	if ex.Expire < 0 || (ex.Expire > 0 && ex.Expire <= ex.BlockNumber) {
+		return fmt.Errorf("the expire height must be after the block of the order")
+	}
+
 	return nil
 }
 
@@ -716,6 +768,23 @@ func (v *Verifys) VerifyExchangeTrade(ex *models.ExchangeInfo) error {
 		return fmt.Errorf("the same address cannot be traded")
 	}
 
+	if err := verifyExchangeOpen(exc, ex.BlockNumber); err != nil {
+		return err
+	}
+
+	if v.fillActive(ex.BlockNumber) {
+		if exc.Status == models.ExchangeStatusFilled || exc.Status == models.ExchangeStatusCancelled {
+			return fmt.Errorf("the order is %s", exc.Status)
+		}
+
+		remaining := new(big.Int).Sub(exc.Amt0.Int(), exc.Amt0Finish.Int())
+		amt0Out := new(big.Int).Mul(ex.Amt1.Int(), exc.Amt0.Int())
+		amt0Out.Div(amt0Out, exc.Amt1.Int())
+		if amt0Out.Cmp(remaining) > 0 {
+			return fmt.Errorf("the trade fills %s %s but only %s remains", amt0Out.String(), exc.Tick0, remaining.String())
+		}
+	}
+
 	return nil
 }
 
@@ -730,9 +799,41 @@ func (v *Verifys) VerifyExchangeCancel(ex *models.ExchangeInfo) error {
 		return fmt.Errorf("the contract does not exist err %s", err.Error())
 	}
 
+	if err := verifyExchangeOpen(exc, ex.BlockNumber); err != nil {
+		return err
+	}
+
+	if v.fillActive(ex.BlockNumber) {
+		if exc.Status == models.ExchangeStatusFilled || exc.Status == models.ExchangeStatusCancelled {
+			return fmt.Errorf("the order is %s", exc.Status)
+		}
+
+		remaining := new(big.Int).Sub(exc.Amt0.Int(), exc.Amt0Finish.Int())
+		if ex.Amt0.Int().Cmp(remaining) > 0 {
+			return fmt.Errorf("the cancel of %s %s exceeds the remaining %s", ex.Amt0.String(), exc.Tick0, remaining.String())
+		}
+	}
+
 	return nil
 }
 
+// verifyExchangeOpen rejects orders that expired, the refund runs at the start of the expire block.
+func verifyExchangeOpen(exc *models.ExchangeCollect, height int64) error {
+	if exc.Status == models.ExchangeStatusExpired || (exc.Expire > 0 && height >= exc.Expire) {
+		return fmt.Errorf("the order expired at %d", exc.Expire)
+	}
+	return nil
+}
+
+func (v *Verifys) fillActive(height int64) bool {
+	return v.activation.ExchangeFill > 0 && height >= v.activation.ExchangeFill
+}
+
+// ExchangeExpireActive tells whether order-v1 creates at height take their expire height.
+func (v *Verifys) ExchangeExpireActive(height int64) bool {
+	return v.activation.ExchangeExpire > 0 && height >= v.activation.ExchangeExpire
+}
+
 func (v *Verifys) VerifyFileExchange(ex *models.FileExchangeInfo) error {
 
 	switch ex.Op {
@@ -884,6 +985,10 @@ func (v *Verifys) VerifyCross(cross *models.CrossInfo) error {
 		return v.VerifyCrossMint(cross)
 	case "burn":
 		return v.VerifyCrossBurn(cross)
+	case "release":
+		return v.VerifyCrossRelease(cross)
+	case "set-admin", "set-threshold":
+		return v.VerifyCrossAdmin(cross)
 	default:
 		return fmt.Errorf("do not support the type of tokens")
 	}
@@ -917,14 +1022,25 @@ func (v *Verifys) VerifyCrossMint(cross *models.CrossInfo) error {
 		return fmt.Errorf("the contract does not exist err %s", err.Error())
 	}
 
-	cc := &models.CrossCollect{}
-	err = v.dbc.DB.Where("tick = ? ", cross.Tick).First(cc).Error
+	cc, err := v.crossAdmin(cross)
 	if err != nil {
-		return fmt.Errorf("the contract does not exist err %s", err.Error())
+		return err
 	}
 
-	if cross.HolderAddress != cc.AdminAddress {
-		return fmt.Errorf("the administrator is not the same")
+	if v.CrossQueueActive(cross.BlockNumber) || cc.Threshold > 1 {
+		if cross.DepositId == "" || len(cross.DepositId) > 128 {
+			return fmt.Errorf("the deposit id must be 1 to 128 characters")
+		}
+
+		err = v.dbc.DB.Where("tick = ? AND deposit_id = ?", cross.Tick, cross.DepositId).First(&models.CrossDeposit{}).Error
+		if err == nil {
+			return fmt.Errorf("the deposit %s is already minted", cross.DepositId)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return v.crossApproved(cross, cross.DepositId, "mint")
 	}
 
 	return nil
@@ -946,5 +1062,124 @@ func (v *Verifys) VerifyCrossBurn(cross *models.CrossInfo) error {
 		return fmt.Errorf("the amount of tokens exceeds the balance")
 	}
 
+	if v.CrossQueueActive(cross.BlockNumber) && (cross.Chain == "" || cross.ToAddress == "") {
+		return fmt.Errorf("the chain and address to release to are required")
+	}
+
+	return nil
+}
+
+func (v *Verifys) VerifyCrossRelease(cross *models.CrossInfo) error {
+
+	if !v.CrossQueueActive(cross.BlockNumber) {
+		return fmt.Errorf("do not support the type of tokens")
+	}
+
+	if cross.BurnTxHash == "" || cross.ExtTxId == "" {
+		return fmt.Errorf("the burn tx hash and the release tx id are required")
+	}
+
+	_, err := v.crossAdmin(cross)
+	if err != nil {
+		return err
+	}
+
+	withdrawal := &models.CrossWithdrawal{}
+	err = v.dbc.DB.Where("tick = ? AND burn_tx_hash = ?", cross.Tick, cross.BurnTxHash).First(withdrawal).Error
+	if err != nil {
+		return fmt.Errorf("the withdrawal does not exist err %s", err.Error())
+	}
+
+	if withdrawal.Status != models.CrossWithdrawalPending {
+		return fmt.Errorf("the withdrawal is already %s", withdrawal.Status)
+	}
+
 	return nil
 }
+
+// CrossQueueActive tells whether cross mints at height need a deposit id, burns an address to release to and
+// the admins can acknowledge releases.
+func (v *Verifys) CrossQueueActive(height int64) bool {
+	return v.activation.CrossQueue > 0 && height >= v.activation.CrossQueue
+}
+
+// CrossAdminActive tells whether the admin set and threshold of a token can be changed at height.
+func (v *Verifys) CrossAdminActive(height int64) bool {
+	return v.CrossQueueActive(height) && v.activation.CrossAdmin > 0 && height >= v.activation.CrossAdmin
+}
+
+// CrossAdminOp tells whether op is signed by an admin of the token, its holder is the address of the input.
+func CrossAdminOp(op string) bool {
+	switch op {
+	case "mint", "release", "set-admin", "set-threshold":
+		return true
+	default:
+		return false
+	}
+}
+
+// crossAdmin returns the token of cross, it fails when the inscriber is not one of its admins.
+func (v *Verifys) crossAdmin(cross *models.CrossInfo) (*models.CrossCollect, error) {
+	cc := &models.CrossCollect{}
+	err := v.dbc.DB.Where("tick = ? ", cross.Tick).First(cc).Error
+	if err != nil {
+		return nil, fmt.Errorf("the contract does not exist err %s", err.Error())
+	}
+
+	for _, admin := range storage.CrossAdmins(cc) {
+		if admin == cross.HolderAddress {
+			return cc, nil
+		}
+	}
+	return nil, fmt.Errorf("the administrator is not the same")
+}
+
+// crossApproved fails when the inscriber already approved an op of ops for ref.
+func (v *Verifys) crossApproved(cross *models.CrossInfo, ref string, ops ...string) error {
+	err := v.dbc.DB.Where("tick = ? AND ref = ? AND op IN ? AND admin_address = ?", cross.Tick, ref, ops, cross.HolderAddress).
+		First(&models.CrossApproval{}).Error
+	if err == nil {
+		return fmt.Errorf("the administrator already approved %s", ref)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (v *Verifys) VerifyCrossAdmin(cross *models.CrossInfo) error {
+
+	if !v.CrossAdminActive(cross.BlockNumber) {
+		return fmt.Errorf("do not support the type of tokens")
+	}
+
+	cc, err := v.crossAdmin(cross)
+	if err != nil {
+		return err
+	}
+
+	if cross.Op == "set-admin" {
+		admins := strings.Split(cross.Admins, ",")
+		if cross.Admins == "" || len(admins) > MaxCrossAdmins {
+			return fmt.Errorf("the admins must be 1 to %d addresses", MaxCrossAdmins)
+		}
+
+		seen := make(map[string]bool)
+		for _, admin := range admins {
+			if admin == "" || seen[admin] {
+				return fmt.Errorf("the admins must be different addresses")
+			}
+			seen[admin] = true
+		}
+
+		if len(admins) < cc.Threshold {
+			return fmt.Errorf("the admins must be at least the threshold %d", cc.Threshold)
+		}
+	} else {
+		if cross.Threshold < 1 || cross.Threshold > len(storage.CrossAdmins(cc)) {
+			return fmt.Errorf("the threshold must be 1 to the number of admins")
+		}
+	}
+
+	return v.crossApproved(cross, strconv.FormatInt(cc.Nonce, 10), "set-admin", "set-threshold")
+}
@@ -0,0 +1,65 @@
+package verifys
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/unielon-org/unielon-indexer/models"
+	"github.com/unielon-org/unielon-indexer/storage"
+	"github.com/unielon-org/unielon-indexer/utils"
+)
+
+func TestCrossAdminOp(t *testing.T) {
+	for op, admin := range map[string]bool{"mint": true, "release": true, "set-admin": true, "set-threshold": true, "deploy": false, "burn": false} {
+		if CrossAdminOp(op) != admin {
+			t.Errorf("CrossAdminOp(%s) = %v", op, !admin)
+		}
+	}
+}
+
+func TestVerifyCrossAdmin(t *testing.T) {
+	dbc, err := storage.NewSqliteClient(utils.SqliteConfig{Database: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(dbc.Stop)
+
+	if err := dbc.DB.AutoMigrate(&models.CrossCollect{}, &models.CrossApproval{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tick := "WETH(WRAPPED-ETH)"
+	if err := dbc.DB.Create(&models.CrossCollect{Tick: tick, AdminAddress: "A", Admins: "A,B", Threshold: 2, Nonce: 3}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbc.DB.Create(&models.CrossApproval{Tick: tick, Op: "set-admin", Ref: "2", Content: "admins:A,B,C", AdminAddress: "B"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbc.DB.Create(&models.CrossApproval{Tick: tick, Op: "set-threshold", Ref: "3", Content: "threshold:1", AdminAddress: "A"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	v := NewVerifys(dbc, utils.ActivationConfig{CrossQueue: 10, CrossAdmin: 20}, utils.SwapConfig{})
+	cases := []struct {
+		name  string
+		cross *models.CrossInfo
+		ok    bool
+	}{
+		{"before the activation", &models.CrossInfo{Op: "set-admin", Admins: "A,B,C", HolderAddress: "B", BlockNumber: 19}, false},
+		{"admin of an older nonce", &models.CrossInfo{Op: "set-admin", Admins: "A,B,C", HolderAddress: "B", BlockNumber: 20}, true},
+		{"admin already approved the nonce", &models.CrossInfo{Op: "set-admin", Admins: "A,B,C", HolderAddress: "A", BlockNumber: 20}, false},
+		{"not an admin", &models.CrossInfo{Op: "set-admin", Admins: "A,B,C", HolderAddress: "C", BlockNumber: 20}, false},
+		{"duplicate admins", &models.CrossInfo{Op: "set-admin", Admins: "B,B", HolderAddress: "B", BlockNumber: 20}, false},
+		{"fewer admins than the threshold", &models.CrossInfo{Op: "set-admin", Admins: "B", HolderAddress: "B", BlockNumber: 20}, false},
+		{"threshold above the admins", &models.CrossInfo{Op: "set-threshold", Threshold: 3, HolderAddress: "B", BlockNumber: 20}, false},
+		{"threshold", &models.CrossInfo{Op: "set-threshold", Threshold: 1, HolderAddress: "B", BlockNumber: 20}, true},
+	}
+
+	for _, c := range cases {
+		c.cross.Tick = tick
+		err := v.VerifyCrossAdmin(c.cross)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: err %v", c.name, err)
+		}
+	}
+}